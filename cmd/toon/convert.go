@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// runEncode implements "toon encode".
+func runEncode(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("encode", "[flags] [file]", "Converts JSON to TOON.", stderr)
+	strict := &optionalBool{}
+	fs.Var(strict, "strict", "fail on key collisions when flattening paths (default false)")
+	enc := addEncodeFlags(fs, strict)
+	outPath := fs.String("o", "", "write output to file")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	return convertFile("encode", fs.Args(), *outPath, stdin, stdout, stderr, func(in io.Reader, out io.Writer) error {
+		return encodeJSON(in, out, enc)
+	})
+}
+
+// runDecode implements "toon decode".
+func runDecode(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("decode", "[flags] [file]", "Converts TOON to JSON.", stderr)
+	strict := &optionalBool{}
+	fs.Var(strict, "strict", "validate indentation and array lengths (default true)")
+	dec := addDecodeFlags(fs, strict)
+	outPath := fs.String("o", "", "write output to file")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	return convertFile("decode", fs.Args(), *outPath, stdin, stdout, stderr, func(in io.Reader, out io.Writer) error {
+		return decodeTOON(in, out, dec)
+	})
+}
+
+// runConvert implements "toon convert".
+func runConvert(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("convert", "[flags] [file]",
+		"Converts JSON input to TOON and any other input, read as TOON, to JSON.", stderr)
+	strict := &optionalBool{}
+	fs.Var(strict, "strict", "encoding: fail on key collisions (default false); decoding: validate input (default true)")
+	enc := addEncodeFlags(fs, strict)
+	dec := addDecodeFlags(fs, strict)
+	outPath := fs.String("o", "", "write output to file")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	return convertFile("convert", fs.Args(), *outPath, stdin, stdout, stderr, func(in io.Reader, out io.Writer) error {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		if json.Valid(data) {
+			return encodeJSON(bytes.NewReader(data), out, enc)
+		}
+		return decodeTOON(bytes.NewReader(data), out, dec)
+	})
+}
+
+// convertFile runs convert from the input file in args to the output at
+// outPath and returns the exit code.
+func convertFile(name string, args []string, outPath string, stdin io.Reader, stdout, stderr io.Writer, convert func(io.Reader, io.Writer) error) int {
+	in, err := openInput(args, stdin)
+	if err != nil {
+		return fail(stderr, name, err)
+	}
+	defer in.Close()
+
+	out, err := openOutput(outPath, stdout)
+	if err != nil {
+		return fail(stderr, name, err)
+	}
+	err = convert(in, out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fail(stderr, name, err)
+	}
+	return exitOK
+}
+
+// encodeJSON converts JSON to TOON. It streams unless path flattening or
+// key sorting needs the whole document, which is then decoded with its key
+// order and number text intact.
+func encodeJSON(in io.Reader, out io.Writer, f *encodeFlags) error {
+	opts, err := f.options()
+	if err != nil {
+		return err
+	}
+	if !f.flatten && !f.sortKeys {
+		return toon.TranscodeJSON(out, in, opts...)
+	}
+
+	v, err := toon.FromJSON(in)
+	if err != nil {
+		return err
+	}
+	return toon.Marshal(v, out, opts...)
+}
+
+// decodeTOON converts TOON to JSON, keeping key order and number text. It
+// streams unless path expansion needs the whole document.
+func decodeTOON(in io.Reader, out io.Writer, f *decodeFlags) error {
+	opts, err := f.options()
+	if err != nil {
+		return err
+	}
+	return toon.TranscodeToJSONIndent(out, in, f.jsonIndent, opts...)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// fmtFlags holds the mode flags of "toon fmt".
+type fmtFlags struct {
+	write bool
+	list  bool
+	check bool
+	diff  bool
+	opts  []toon.EncodeOption
+}
+
+// runFmt implements "toon fmt".
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("fmt", "[flags] [path ...]",
+		"Formats TOON files in the layout the encoder produces. Directories are\n"+
+			"searched for .toon files; without paths, standard input is formatted.", stderr)
+	f := &fmtFlags{}
+	flags.BoolVar(&f.write, "w", false, "write the result to the file instead of standard output")
+	flags.BoolVar(&f.list, "l", false, "list files whose formatting differs")
+	flags.BoolVar(&f.check, "check", false, "exit with status 1 if any file is not formatted")
+	flags.BoolVar(&f.diff, "d", false, "print a unified diff instead of the formatted file")
+	strict := &optionalBool{}
+	flags.Var(strict, "strict", "fail on key collisions when flattening paths (default false)")
+	enc := addEncodeFlags(flags, strict)
+	if code := parseFlags(flags, args); code >= 0 {
+		return code
+	}
+
+	opts, err := enc.options()
+	if err != nil {
+		return fail(stderr, "fmt", err)
+	}
+	f.opts = opts
+
+	if flags.NArg() == 0 {
+		if f.write {
+			return fail(stderr, "fmt", &usageError{"cannot use -w with standard input"})
+		}
+		changed, code := f.format("<standard input>", stdin, stdout, stderr)
+		return f.exitCode(changed, code)
+	}
+
+	anyChanged := false
+	code := forEachFile(flags.Args(), "fmt", stderr, func(path string) int {
+		changed, code := f.formatFile(path, stdout, stderr)
+		anyChanged = anyChanged || changed
+		return code
+	})
+	return f.exitCode(anyChanged, code)
+}
+
+// formatFile formats the file at path and reports whether it changed.
+func (f *fmtFlags) formatFile(path string, stdout, stderr io.Writer) (bool, int) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fail(stderr, "fmt", err)
+	}
+	defer file.Close()
+	return f.format(path, file, stdout, stderr)
+}
+
+// format formats the input read from in, named name, according to the mode
+// flags and reports whether its formatting differs.
+func (f *fmtFlags) format(name string, in io.Reader, stdout, stderr io.Writer) (bool, int) {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return false, fail(stderr, "fmt", fmt.Errorf("%s: %w", name, err))
+	}
+	out, err := toon.Format(src, f.opts...)
+	if err != nil {
+		return false, fail(stderr, "fmt", fmt.Errorf("%s: %w", name, err))
+	}
+	changed := !bytes.Equal(src, out)
+
+	if changed && f.list {
+		fmt.Fprintln(stdout, name)
+	}
+	if changed && f.diff {
+		io.WriteString(stdout, unifiedDiff(name+".orig", name, src, out))
+	}
+	if changed && f.write {
+		if err := writeFile(name, out); err != nil {
+			return changed, fail(stderr, "fmt", err)
+		}
+	}
+	if !f.list && !f.diff && !f.write && !f.check {
+		stdout.Write(out)
+	}
+	return changed, exitOK
+}
+
+// exitCode returns the exit code of a run: code if an input failed, exitError
+// if -check found an unformatted input, and exitOK otherwise.
+func (f *fmtFlags) exitCode(changed bool, code int) int {
+	if code != exitOK {
+		return code
+	}
+	if f.check && changed {
+		return exitError
+	}
+	return exitOK
+}
+
+// writeFile replaces the contents of the file at path, keeping its mode.
+func writeFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return errors.New(path + ": not a regular file")
+	}
+	return os.WriteFile(path, data, info.Mode().Perm())
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunFmt(t *testing.T) {
+	dir := t.TempDir()
+	messy := filepath.Join(dir, "messy.toon")
+	clean := filepath.Join(dir, "sub", "clean.toon")
+	other := filepath.Join(dir, "sub", "notes.txt")
+	writeTestFile(t, messy, "users[3]{id,name}:\n    1,Ada\n    2,\"Bob\"")
+	writeTestFile(t, clean, "a[2]: 1,2\n")
+	writeTestFile(t, other, "not toon: [")
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantCode int
+		wantOut  string
+	}{
+		{"stdin", []string{"fmt"}, "b:   \"x\"\na[1|]: 1.50", exitOK, "b: x\na[1]: 1.50\n"},
+		{"stdin options", []string{"fmt", "-indent", "4", "-delimiter", "tab"}, "o:\n  t[2]: a,b", exitOK, "o:\n    t[2\t]: a\tb\n"},
+		{"stdin invalid", []string{"fmt"}, "a: \"x", exitInvalid, ""},
+		{"stdin write", []string{"fmt", "-w"}, "a: 1", exitUsage, ""},
+		{"file", []string{"fmt", messy}, "", exitOK, "users[2]{id,name}:\n  1,Ada\n  2,Bob\n"},
+		{"list", []string{"fmt", "-l", dir}, "", exitOK, messy + "\n"},
+		{"check", []string{"fmt", "-check", dir}, "", exitError, ""},
+		{"check formatted", []string{"fmt", "-check", clean}, "", exitOK, ""},
+		{"missing file", []string{"fmt", filepath.Join(dir, "missing.toon")}, "", exitError, ""},
+		{
+			name:     "diff",
+			args:     []string{"fmt", "-d", messy},
+			wantCode: exitOK,
+			wantOut: "--- " + messy + ".orig\n+++ " + messy + "\n" +
+				"@@ -1,3 +1,3 @@\n" +
+				"-users[3]{id,name}:\n-    1,Ada\n-    2,\"Bob\"\n\\ No newline at end of file\n" +
+				"+users[2]{id,name}:\n+  1,Ada\n+  2,Bob\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			if stdout.String() != tt.wantOut {
+				t.Errorf("run() output =\n%q\nwant\n%q", stdout.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestRunFmtWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.toon")
+	writeTestFile(t, path, "items[1]:\n  - id: 1\n    name: Ada\n")
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"fmt", "-w", "-l", dir}, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+	}
+	if stdout.String() != path+"\n" {
+		t.Errorf("run() output = %q, want %q", stdout.String(), path+"\n")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "items[1]{id,name}:\n  1,Ada\n"; string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"fmt", "-check", "-l", path}, strings.NewReader(""), &stdout, &stderr); code != exitOK || stdout.Len() != 0 {
+		t.Errorf("run(-check) after -w = %d, output %q", code, stdout.String())
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	want := "--- a\n+++ b\n" +
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+		"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n"
+	if got := unifiedDiff("a", "b", []byte(a), []byte(b)); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("a", "b", []byte(a), []byte(a)); got != "" {
+		t.Errorf("unifiedDiff(equal) = %q, want empty", got)
+	}
+	if got, want := unifiedDiff("a", "b", nil, []byte("x\n")), "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n"; got != want {
+		t.Errorf("unifiedDiff(empty) = %q, want %q", got, want)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// runHeatmap implements "toon heatmap".
+func runHeatmap(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("heatmap", "[flags] [file]",
+		"Reports the bytes and tokens of every value of the input in its TOON\n"+
+			"encoding and as JSON, with the most expensive subtrees first.\n"+
+			"The input may be JSON or TOON.", stderr)
+	vocab := flags.String("vocab", "", "count with the tiktoken vocabulary file at this path instead of estimating")
+	depth := flags.Int("depth", 0, "show values at most this many levels below the root (0 = all)")
+	strict := &optionalBool{}
+	flags.Var(strict, "strict", "fail on key collisions when flattening paths (default false)")
+	enc := addEncodeFlags(flags, strict)
+	if code := parseFlags(flags, args); code >= 0 {
+		return code
+	}
+
+	tok, err := loadTokenizer(*vocab)
+	if err != nil {
+		return fail(stderr, "heatmap", err)
+	}
+
+	return convertFile("heatmap", flags.Args(), "", stdin, stdout, stderr, func(in io.Reader, out io.Writer) error {
+		opts, err := enc.options()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		v, err := statsInput(data)
+		if err != nil {
+			return err
+		}
+		cost, err := toon.Heatmap(v, tok, opts...)
+		if err != nil {
+			return err
+		}
+		return writeHeatmap(out, cost, *depth)
+	})
+}
+
+// writeHeatmap writes the cost tree as an aligned table, indenting paths
+// by depth and ordering siblings by TOON tokens, most expensive first.
+func writeHeatmap(w io.Writer, root *toon.Cost, maxDepth int) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tTOON BYTES\tTOON TOKENS\tJSON BYTES\tJSON TOKENS\tSHARE")
+
+	var walk func(c *toon.Cost, depth int)
+	walk = func(c *toon.Cost, depth int) {
+		label := c.Path
+		if depth == 0 {
+			label = "total"
+		}
+		share := "-"
+		if root.Tokens > 0 {
+			share = fmt.Sprintf("%.1f%%", 100*float64(c.Tokens)/float64(root.Tokens))
+		}
+		fmt.Fprintf(tw, "%s%s\t%d\t%d\t%d\t%d\t%s\n", strings.Repeat("  ", depth), label,
+			c.Bytes, c.Tokens, c.JSONBytes, c.JSONTokens, share)
+
+		if maxDepth > 0 && depth >= maxDepth {
+			return
+		}
+		children := append([]*toon.Cost(nil), c.Children...)
+		sort.SliceStable(children, func(i, j int) bool { return children[i].Tokens > children[j].Tokens })
+		for _, child := range children {
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 0)
+	return tw.Flush()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunHeatmap(t *testing.T) {
+	input := `{"page": 1, "users": [{"id": 1, "name": "Ada"}, {"id": 2, "name": "Bob"}]}`
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"heatmap"}, strings.NewReader(input), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	var paths []string
+	for _, line := range lines[1:] {
+		paths = append(paths, strings.Fields(line)[0])
+	}
+	// The most expensive field comes first
+	if got, want := strings.Join(paths, " "), "total users users[0] users[1] page"; got != want {
+		t.Errorf("paths = %q, want %q\n%s", got, want, stdout.String())
+	}
+	// users: "users[2]{id,name}:\n  1,Ada\n  2,Bob" after the newline ending "page: 1"
+	if fields := strings.Fields(lines[2]); fields[1] != "35" || !strings.HasSuffix(fields[5], "%") {
+		t.Errorf("users row = %q", lines[2])
+	}
+	if !strings.HasPrefix(lines[3], "    users[0]") {
+		t.Errorf("row %q is not indented below users", lines[3])
+	}
+
+	stdout.Reset()
+	if code := run([]string{"heatmap", "-depth", "1"}, strings.NewReader(input), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run(-depth 1) = %d; stderr: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "users[0]") {
+		t.Errorf("run(-depth 1) output =\n%s\nwant only top-level fields", stdout.String())
+	}
+
+	if code := run([]string{"heatmap"}, strings.NewReader("a[2]: 1"), &stdout, &stderr); code != exitInvalid {
+		t.Errorf("run(invalid input) = %d, want %d", code, exitInvalid)
+	}
+}
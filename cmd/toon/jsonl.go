@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// runJSONLines implements "toon jsonl".
+func runJSONLines(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("jsonl", "[flags] [file]",
+		"Reads newline-delimited JSON records and writes them as one TOON array.", stderr)
+	key := fs.String("key", "rows", "key of the array; empty for a root array")
+	columns := fs.String("columns", "", "comma-separated fields to keep, in order")
+	limit := fs.Int("limit", 0, "maximum number of records to read (0 = all)")
+	strict := &optionalBool{}
+	fs.Var(strict, "strict", "fail on key collisions when flattening paths (default false)")
+	enc := addEncodeFlags(fs, strict)
+	outPath := fs.String("o", "", "write output to file")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	var jsonlOpts []toon.JSONLinesOption
+	if *columns != "" {
+		jsonlOpts = append(jsonlOpts, toon.WithJSONLinesColumns(strings.Split(*columns, ",")...))
+	}
+	if *limit > 0 {
+		jsonlOpts = append(jsonlOpts, toon.WithJSONLinesLimit(*limit))
+	}
+
+	return convertFile("jsonl", fs.Args(), *outPath, stdin, stdout, stderr, func(in io.Reader, out io.Writer) error {
+		opts, err := enc.options()
+		if err != nil {
+			return err
+		}
+		v, err := toon.FromJSONLines(in, *key, jsonlOpts...)
+		if err != nil {
+			return err
+		}
+		return toon.Marshal(v, out, opts...)
+	})
+}
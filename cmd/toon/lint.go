@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sstraus/toon_go/toon/lint"
+)
+
+// lintResult is the diagnostics of one input.
+type lintResult struct {
+	file  string
+	diags []lint.Diagnostic
+}
+
+// runLint implements "toon lint".
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("lint", "[flags] [path ...]",
+		"Reports problems in TOON files. Directories are searched for .toon files;\n"+
+			"without paths, standard input is checked.", stderr)
+	format := flags.String("format", "text", `output format: "text", "json" or "sarif"`)
+	failOn := flags.String("fail-on", "warning", `exit with status 1 on diagnostics of this severity or higher: "info", "warning", "error" or "none"`)
+	disable := flags.String("disable", "", "comma-separated rule IDs to turn off")
+	indentSize := flags.Int("indent-size", 0, "expected indentation in spaces (0 = from the first indented line)")
+	if code := parseFlags(flags, args); code >= 0 {
+		return code
+	}
+
+	write, ok := lintWriters[*format]
+	if !ok {
+		return fail(stderr, "lint", &usageError{fmt.Sprintf("invalid format %q", *format)})
+	}
+	threshold := lint.SeverityError + 1
+	if *failOn != "none" {
+		s, err := lint.ParseSeverity(*failOn)
+		if err != nil {
+			return fail(stderr, "lint", &usageError{fmt.Sprintf("invalid severity %q", *failOn)})
+		}
+		threshold = s
+	}
+	opts := []lint.Option{lint.WithIndentSize(*indentSize)}
+	if *disable != "" {
+		opts = append(opts, lint.WithDisabled(strings.Split(*disable, ",")...))
+	}
+
+	var results []lintResult
+	check := func(name string, in io.Reader) int {
+		src, err := io.ReadAll(in)
+		if err != nil {
+			return fail(stderr, "lint", err)
+		}
+		results = append(results, lintResult{file: name, diags: lint.Lint(src, opts...)})
+		return exitOK
+	}
+
+	var code int
+	if flags.NArg() == 0 {
+		code = check("<standard input>", stdin)
+	} else {
+		code = forEachFile(flags.Args(), "lint", stderr, func(path string) int {
+			f, err := os.Open(path)
+			if err != nil {
+				return fail(stderr, "lint", err)
+			}
+			defer f.Close()
+			return check(path, f)
+		})
+	}
+
+	if err := write(stdout, results); err != nil {
+		return fail(stderr, "lint", err)
+	}
+	if code != exitOK {
+		return code
+	}
+	for _, r := range results {
+		for _, d := range r.diags {
+			if d.Severity >= threshold {
+				return exitError
+			}
+		}
+	}
+	return exitOK
+}
+
+var lintWriters = map[string]func(io.Writer, []lintResult) error{
+	"text":  writeLintText,
+	"json":  writeLintJSON,
+	"sarif": writeLintSARIF,
+}
+
+// writeLintText writes one "file:line:column: severity: message (rule)"
+// line per diagnostic.
+func writeLintText(w io.Writer, results []lintResult) error {
+	for _, r := range results {
+		for _, d := range r.diags {
+			loc := r.file
+			if start := d.Span.Start; start.Line > 0 {
+				loc = fmt.Sprintf("%s:%d:%d", r.file, start.Line, start.Column)
+			}
+			if _, err := fmt.Fprintf(w, "%s: %s: %s (%s)\n", loc, d.Severity, d.Message, d.Rule); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonDiagnostic is a diagnostic in the JSON output.
+type jsonDiagnostic struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Severity  string `json:"severity"`
+	Rule      string `json:"rule"`
+	Message   string `json:"message"`
+}
+
+// writeLintJSON writes the diagnostics as a JSON array.
+func writeLintJSON(w io.Writer, results []lintResult) error {
+	out := []jsonDiagnostic{}
+	for _, r := range results {
+		for _, d := range r.diags {
+			out = append(out, jsonDiagnostic{
+				File:      r.file,
+				Line:      d.Span.Start.Line,
+				Column:    d.Span.Start.Column,
+				EndLine:   d.Span.End.Line,
+				EndColumn: d.Span.End.Column,
+				Severity:  d.Severity.String(),
+				Rule:      d.Rule,
+				Message:   d.Message,
+			})
+		}
+	}
+	return writeJSON(w, out)
+}
+
+// SARIF 2.1.0 log, reduced to the properties code review tools read.
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string       `json:"id"`
+		ShortDescription     sarifMessage `json:"shortDescription"`
+		DefaultConfiguration sarifConfig  `json:"defaultConfiguration"`
+	}
+	sarifConfig struct {
+		Level string `json:"level"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifact `json:"artifactLocation"`
+		Region           *sarifRegion  `json:"region,omitempty"`
+	}
+	sarifArtifact struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+		EndLine     int `json:"endLine,omitempty"`
+		EndColumn   int `json:"endColumn,omitempty"`
+	}
+)
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(s lint.Severity) string {
+	switch s {
+	case lint.SeverityError:
+		return "error"
+	case lint.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// writeLintSARIF writes the diagnostics as a SARIF 2.1.0 log.
+func writeLintSARIF(w io.Writer, results []lintResult) error {
+	driver := sarifDriver{Name: "toon lint", InformationURI: "https://github.com/sstraus/toon_go"}
+	for _, r := range lint.Rules() {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   r.ID,
+			ShortDescription:     sarifMessage{r.Description},
+			DefaultConfiguration: sarifConfig{sarifLevel(r.Severity)},
+		})
+	}
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, r := range results {
+		for _, d := range r.diags {
+			loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: r.file}}
+			if start := d.Span.Start; start.Line > 0 {
+				loc.Region = &sarifRegion{
+					StartLine:   start.Line,
+					StartColumn: start.Column,
+					EndLine:     d.Span.End.Line,
+					EndColumn:   d.Span.End.Column,
+				}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    d.Rule,
+				Level:     sarifLevel(d.Severity),
+				Message:   sarifMessage{d.Message},
+				Locations: []sarifLocation{{PhysicalLocation: loc}},
+			})
+		}
+	}
+
+	return writeJSON(w, sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
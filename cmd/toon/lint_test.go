@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunLint(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.toon")
+	good := filepath.Join(dir, "good.toon")
+	writeTestFile(t, bad, "a[3]: 1,2\n\"k\": 1\n")
+	writeTestFile(t, good, "a[2]: 1,2\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantCode int
+		wantOut  string
+	}{
+		{"stdin", []string{"lint"}, "a[3]: 1,2", exitError, "<standard input>:1:2: warning: array declares 3 items but has 2 (length-mismatch)\n"},
+		{"clean", []string{"lint", "-fail-on", "info", good}, "", exitOK, ""},
+		{
+			name:     "directory",
+			args:     []string{"lint", dir},
+			wantCode: exitError,
+			wantOut: bad + ":1:2: warning: array declares 3 items but has 2 (length-mismatch)\n" +
+				bad + ":2:1: info: key \"k\" does not need quotes (unnecessary-quotes)\n",
+		},
+		{"fail on error", []string{"lint", "-fail-on", "error", bad}, "", exitOK, bad + ":1:2: warning:"},
+		{"fail on none", []string{"lint", "-fail-on", "none"}, "a: \"x", exitOK, "<standard input>:1:4: error:"},
+		{"disable", []string{"lint", "-disable", "length-mismatch", "-fail-on", "warning", bad}, "", exitOK, bad + ":2:1: info:"},
+		{"syntax error", []string{"lint"}, "a: \"x", exitError, ""},
+		{"invalid format", []string{"lint", "-format", "xml"}, "", exitUsage, ""},
+		{"invalid severity", []string{"lint", "-fail-on", "fatal"}, "", exitUsage, ""},
+		{"missing file", []string{"lint", filepath.Join(dir, "missing.toon")}, "", exitError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			if !strings.HasPrefix(stdout.String(), tt.wantOut) {
+				t.Errorf("run() output =\n%q\nwant prefix\n%q", stdout.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestRunLintJSON(t *testing.T) {
+	var stdout, stderr strings.Builder
+	run([]string{"lint", "-format", "json"}, strings.NewReader("a[3]: 1,2"), &stdout, &stderr)
+
+	var got []jsonDiagnostic
+	if err := json.Unmarshal([]byte(stdout.String()), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+	}
+	want := jsonDiagnostic{
+		File: "<standard input>", Line: 1, Column: 2, EndLine: 1, EndColumn: 6,
+		Severity: "warning", Rule: "length-mismatch", Message: "array declares 3 items but has 2",
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("diagnostics = %+v, want %+v", got, want)
+	}
+
+	stdout.Reset()
+	run([]string{"lint", "-format", "json"}, strings.NewReader("a: 1"), &stdout, &stderr)
+	if strings.TrimSpace(stdout.String()) != "[]" {
+		t.Errorf("output without diagnostics = %q, want []", stdout.String())
+	}
+}
+
+func TestRunLintSARIF(t *testing.T) {
+	var stdout, stderr strings.Builder
+	run([]string{"lint", "-format", "sarif"}, strings.NewReader("\"k\": 1\nb: \"x"), &stdout, &stderr)
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(stdout.String()), &log); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = %+v", log)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "toon lint" || len(run.Tool.Driver.Rules) == 0 {
+		t.Errorf("driver = %+v", run.Tool.Driver)
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("results = %+v, want one", run.Results)
+	}
+	res := run.Results[0]
+	region := res.Locations[0].PhysicalLocation.Region
+	if res.RuleID != "syntax" || res.Level != "error" || region == nil || region.StartLine != 2 {
+		t.Errorf("result = %+v, region %+v", res, region)
+	}
+}
@@ -0,0 +1,283 @@
+// Command toon converts data between JSON and TOON (Token-Oriented Object Notation).
+//
+// Usage:
+//
+//	toon <command> [flags] [file]
+//	toon [flags] file
+//
+// Commands:
+//
+//	encode   Convert JSON to TOON
+//	decode   Convert TOON to JSON
+//	convert  Detect the input format and convert to the other one
+//	fmt      Format TOON files
+//	lint     Report problems in TOON files
+//	jsonl    Encode JSON Lines records as one TOON array
+//	stats    Compare bytes and tokens of JSON and TOON
+//	heatmap  Show the bytes and tokens of every value
+//
+// Input is read from file, or from standard input when no file is given or
+// file is "-". Output is written to standard output, or to the file named by
+// -o. Without a command, a file argument is converted as with "toon convert".
+//
+// Exit codes:
+//
+//	0  success
+//	1  error, e.g. unreadable file or value that cannot be encoded, or
+//	   an unformatted file with "toon fmt -check", or diagnostics with
+//	   "toon lint"
+//	2  invalid command line
+//	3  invalid input (toon.DecodeError)
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// Exit codes.
+const (
+	exitOK      = 0
+	exitError   = 1
+	exitUsage   = 2
+	exitInvalid = 3
+)
+
+// command is a toon subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{"encode", "Convert JSON to TOON", runEncode},
+	{"decode", "Convert TOON to JSON", runDecode},
+	{"convert", "Detect the input format and convert to the other one", runConvert},
+	{"fmt", "Format TOON files", runFmt},
+	{"lint", "Report problems in TOON files", runLint},
+	{"jsonl", "Encode JSON Lines records as one TOON array", runJSONLines},
+	{"stats", "Compare bytes and tokens of JSON and TOON", runStats},
+	{"heatmap", "Show the bytes and tokens of every value", runHeatmap},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run dispatches args to a subcommand and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage(stdout)
+		return exitOK
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+
+	// Flags or a file without a command
+	if strings.HasPrefix(name, "-") || isFile(name) {
+		return runConvert(args, stdin, stdout, stderr)
+	}
+
+	fmt.Fprintf(stderr, "toon: unknown command %q\n", name)
+	usage(stderr)
+	return exitUsage
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: toon <command> [flags] [file]")
+	fmt.Fprintln(w, "       toon [flags] file")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'toon <command> -h' for the flags of a command.")
+}
+
+// newFlagSet returns a flag set that prints usage and errors to stderr.
+func newFlagSet(name, args, summary string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: toon %s %s\n\n%s\n\n", name, args, summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and returns the exit code to stop with, or -1.
+func parseFlags(fs *flag.FlagSet, args []string) int {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	return -1
+}
+
+// fail reports err for the named command and returns its exit code.
+func fail(stderr io.Writer, name string, err error) int {
+	fmt.Fprintf(stderr, "toon %s: %v\n", name, err)
+
+	var usageErr *usageError
+	var decErr *toon.DecodeError
+	switch {
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.As(err, &decErr):
+		return exitInvalid
+	default:
+		return exitError
+	}
+}
+
+// usageError is an invalid flag value or argument.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+// isFile reports whether name is "-" or an existing regular file.
+func isFile(name string) bool {
+	if name == "-" {
+		return true
+	}
+	info, err := os.Stat(name)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// forEachFile calls fn for every file named in paths, searching directories
+// for .toon files, and returns the highest exit code.
+func forEachFile(paths []string, name string, stderr io.Writer, fn func(path string) int) int {
+	worst := exitOK
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (p != path && filepath.Ext(p) != ".toon") {
+				return nil
+			}
+			worst = max(worst, fn(p))
+			return nil
+		})
+		if err != nil {
+			worst = max(worst, fail(stderr, name, err))
+		}
+	}
+	return worst
+}
+
+// openInput returns the file named by args, or stdin when args is empty.
+func openInput(args []string, stdin io.Reader) (io.ReadCloser, error) {
+	switch len(args) {
+	case 0:
+		return io.NopCloser(stdin), nil
+	case 1:
+		if args[0] == "-" {
+			return io.NopCloser(stdin), nil
+		}
+		return os.Open(args[0])
+	default:
+		return nil, &usageError{fmt.Sprintf("expected at most one input file, got %d", len(args))}
+	}
+}
+
+// output is the destination of a command. It ends non-empty output with
+// a newline.
+type output struct {
+	w    *bufio.Writer
+	file *os.File
+	last byte
+}
+
+// openOutput returns an output writing to the file at path, or to stdout
+// when path is empty or "-".
+func openOutput(path string, stdout io.Writer) (*output, error) {
+	if path == "" || path == "-" {
+		return &output{w: bufio.NewWriter(stdout)}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &output{w: bufio.NewWriter(f), file: f}, nil
+}
+
+// Write implements io.Writer.
+func (o *output) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		o.last = p[len(p)-1]
+	}
+	return o.w.Write(p)
+}
+
+// Close ends the output with a newline, flushes it and closes the file.
+func (o *output) Close() error {
+	if o.last != 0 && o.last != '\n' {
+		o.w.WriteByte('\n')
+	}
+	err := o.w.Flush()
+	if o.file != nil {
+		if closeErr := o.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// optionalBool is a boolean flag that records whether it was set.
+type optionalBool struct {
+	value bool
+	set   bool
+}
+
+func (b *optionalBool) String() string {
+	if b == nil {
+		return "false"
+	}
+	return fmt.Sprint(b.value)
+}
+
+func (b *optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.value, b.set = v, true
+	return nil
+}
+
+func (b *optionalBool) IsBoolFlag() bool { return true }
+
+// get returns the flag value, or def when it was not set.
+func (b *optionalBool) get(def bool) bool {
+	if b.set {
+		return b.value
+	}
+	return def
+}
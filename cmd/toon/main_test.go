@@ -0,0 +1,178 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "in.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"b": 1, "a": [1, 2]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantCode int
+		wantOut  string
+	}{
+		{"no command", nil, "", exitUsage, ""},
+		{"unknown command", []string{"nope"}, "", exitUsage, ""},
+		{"help", []string{"help"}, "", exitOK, "Usage: toon <command>"},
+		{"command help", []string{"encode", "-h"}, "", exitOK, ""},
+		{"unknown flag", []string{"encode", "-nope"}, "", exitUsage, ""},
+
+		// encode
+		{
+			name:     "encode",
+			args:     []string{"encode"},
+			stdin:    `{"users": [{"id": 1, "name": "Ada"}, {"id": 2, "name": "Bob"}], "price": 1.50}`,
+			wantCode: exitOK,
+			wantOut:  "users[2]{id,name}:\n  1,Ada\n  2,Bob\nprice: 1.50\n",
+		},
+		{
+			name:     "encode options",
+			args:     []string{"encode", "-delimiter", "tab", "-length-marker", "#", "-indent", "4"},
+			stdin:    `{"rows": [{"a": 1, "b": 2}], "t": ["x", "y"]}`,
+			wantCode: exitOK,
+			wantOut:  "rows[#1\t]{a\tb}:\n    1\t2\nt[#2\t]: x\ty\n",
+		},
+		{
+			name:     "encode flatten",
+			args:     []string{"encode", "-flatten"},
+			stdin:    `{"z": {"y": {"x": 1}}, "a": 2}`,
+			wantCode: exitOK,
+			wantOut:  "a: 2\nz.y.x: 1\n",
+		},
+		{
+			name:     "encode flatten depth",
+			args:     []string{"encode", "-flatten", "-flatten-depth", "2"},
+			stdin:    `{"z": {"y": {"x": 1}}}`,
+			wantCode: exitOK,
+			wantOut:  "z.y:\n  x: 1\n",
+		},
+		{"encode flatten depth zero", []string{"encode", "-flatten", "-flatten-depth", "0"}, `{"z": {"y": 1}}`, exitOK, "z:\n  y: 1\n"},
+		{"encode invalid flatten depth", []string{"encode", "-flatten-depth", "-2"}, `{}`, exitUsage, ""},
+		{
+			name:     "encode sort keys",
+			args:     []string{"encode", "-sort-keys"},
+			stdin:    `{"z": 1, "a": {"d": 1, "c": 2}}`,
+			wantCode: exitOK,
+			wantOut:  "a:\n  c: 2\n  d: 1\nz: 1\n",
+		},
+		{
+			name:     "encode sort keys keeps large integers",
+			args:     []string{"encode", "-sort-keys"},
+			stdin:    `{"b": 12345678901234567890, "a": 1.50}`,
+			wantCode: exitOK,
+			wantOut:  "a: 1.5\nb: 12345678901234567890\n",
+		},
+		{
+			name:     "encode flatten keeps number text",
+			args:     []string{"encode", "-flatten"},
+			stdin:    `{"z": {"n": 12345678901234567890}, "a": 1.50}`,
+			wantCode: exitOK,
+			wantOut:  "a: 1.50\nz.n: 12345678901234567890\n",
+		},
+		{"encode sort keys invalid JSON", []string{"encode", "-sort-keys"}, `{"a": 1} 2`, exitInvalid, ""},
+		{"encode strict collision", []string{"encode", "-flatten", "-strict"}, `{"a.b": 1, "a": {"b": 2}}`, exitError, ""},
+		{"encode invalid JSON", []string{"encode"}, `{"a": `, exitInvalid, ""},
+		{"encode auto delimiter", []string{"encode", "-delimiter", "auto"}, `{"a": ["x, y", "z"]}`, exitOK, "a[2|]: x, y|z\n"},
+		{"encode invalid delimiter", []string{"encode", "-delimiter", ";"}, `{}`, exitUsage, ""},
+		{"encode file", []string{"encode", jsonFile}, "", exitOK, "b: 1\na[2]: 1,2\n"},
+		{"encode missing file", []string{"encode", filepath.Join(dir, "missing.json")}, "", exitError, ""},
+		{"encode two files", []string{"encode", jsonFile, jsonFile}, "", exitUsage, ""},
+
+		// decode
+		{
+			name:     "decode",
+			args:     []string{"decode"},
+			stdin:    "users[2]{id,name}:\n  1,Ada\n  2,Bob\nprice: 1.50",
+			wantCode: exitOK,
+			wantOut:  `{"users":[{"id":1,"name":"Ada"},{"id":2,"name":"Bob"}],"price":1.50}` + "\n",
+		},
+		{
+			name:     "decode json indent",
+			args:     []string{"decode", "-json-indent", "  "},
+			stdin:    "a[1]: x",
+			wantCode: exitOK,
+			wantOut:  "{\n  \"a\": [\n    \"x\"\n  ]\n}\n",
+		},
+		{
+			name:     "decode expand paths",
+			args:     []string{"decode", "-expand-paths", "safe"},
+			stdin:    "a.b: 1",
+			wantCode: exitOK,
+			wantOut:  `{"a":{"b":1}}` + "\n",
+		},
+		{
+			name:     "decode expand paths keeps order and numbers",
+			args:     []string{"decode", "-expand-paths", "safe"},
+			stdin:    "z.b: 12345678901234567890\na: 1.50\nz.a: 2",
+			wantCode: exitOK,
+			wantOut:  `{"z":{"b":12345678901234567890,"a":2},"a":1.50}` + "\n",
+		},
+		{"decode strict", []string{"decode"}, "a[2]: 1", exitInvalid, ""},
+		{"decode not strict", []string{"decode", "-strict=false"}, "a[2]: 1", exitOK, `{"a":[1]}`},
+		{"decode indent size", []string{"decode", "-indent-size", "4"}, "a:\n    b: 1", exitOK, `{"a":{"b":1}}`},
+		{"decode invalid mode", []string{"decode", "-expand-paths", "all"}, "a: 1", exitUsage, ""},
+
+		// convert
+		{"convert JSON", []string{"convert"}, `{"a": [1, 2]}`, exitOK, "a[2]: 1,2\n"},
+		{"convert TOON", []string{"convert"}, "a[2]: 1,2", exitOK, `{"a":[1,2]}` + "\n"},
+		{"convert invalid TOON", []string{"convert"}, "a[3]: 1,2", exitInvalid, ""},
+		{"file without command", []string{jsonFile}, "", exitOK, "b: 1\na[2]: 1,2\n"},
+		{"flags without command", []string{"-delimiter", "|", "-"}, `{"a": [1, 2]}`, exitOK, "a[2|]: 1|2\n"},
+
+		// jsonl
+		{
+			name:     "jsonl",
+			args:     []string{"jsonl", "-key", "events", "-columns", "id,level"},
+			stdin:    "{\"id\": 1, \"level\": \"info\", \"x\": [1]}\n{\"level\": \"warn\", \"id\": 2}\n",
+			wantCode: exitOK,
+			wantOut:  "events[2]{id,level}:\n  1,info\n  2,warn\n",
+		},
+		{
+			name:     "jsonl root array with limit",
+			args:     []string{"jsonl", "-key", "", "-limit", "1", "-delimiter", "tab"},
+			stdin:    "{\"a\": 1, \"b\": 2}\n{\"a\": 3, \"b\": 4}\n",
+			wantCode: exitOK,
+			wantOut:  "[1\t]{a\tb}:\n  1\t2\n",
+		},
+		{"jsonl invalid input", []string{"jsonl"}, "{\"a\": \n", exitInvalid, ""},
+		{"jsonl invalid delimiter", []string{"jsonl", "-delimiter", ";"}, "", exitUsage, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			if tt.wantOut != "" && !strings.HasPrefix(stdout.String(), tt.wantOut) {
+				t.Errorf("run() output =\n%q\nwant prefix\n%q", stdout.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestRunOutputFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.toon")
+	var stdout, stderr strings.Builder
+	if code := run([]string{"encode", "-o", out}, strings.NewReader(`{"a": 1}`), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a: 1\n" || stdout.Len() != 0 {
+		t.Errorf("output file = %q, stdout = %q", data, stdout.String())
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// encodeFlags holds the flags that map onto toon.EncodeOptions.
+type encodeFlags struct {
+	indent       int
+	delimiter    string
+	lengthMarker string
+	flatten      bool
+	flattenDepth int
+	sortKeys     bool
+	strict       *optionalBool
+}
+
+// addEncodeFlags registers the encoding flags on fs. strict is registered
+// by the caller, since decoding has a -strict flag as well.
+func addEncodeFlags(fs *flag.FlagSet, strict *optionalBool) *encodeFlags {
+	f := &encodeFlags{strict: strict}
+	fs.IntVar(&f.indent, "indent", 2, "indentation in spaces")
+	fs.StringVar(&f.delimiter, "delimiter", ",", `array delimiter: "," "|" "tab" or "auto" (chosen per array)`)
+	fs.StringVar(&f.lengthMarker, "length-marker", "", `array length prefix, e.g. "#"`)
+	fs.BoolVar(&f.flatten, "flatten", false, "fold single-key object chains into dotted keys")
+	fs.IntVar(&f.flattenDepth, "flatten-depth", -1, "maximum number of folded segments (-1 = unlimited, 0 = no folding)")
+	fs.BoolVar(&f.sortKeys, "sort-keys", false, "sort object keys")
+	return f
+}
+
+// options returns the encode options selected by the flags.
+func (f *encodeFlags) options() ([]toon.EncodeOption, error) {
+	delimiter := f.delimiter
+	if delimiter == "tab" {
+		delimiter = "\t"
+	}
+	if delimiter != "," && delimiter != "|" && delimiter != "\t" && delimiter != toon.DelimiterAuto {
+		return nil, &usageError{fmt.Sprintf("invalid delimiter %q", f.delimiter)}
+	}
+	if f.indent < 1 {
+		return nil, &usageError{fmt.Sprintf("invalid indent %d", f.indent)}
+	}
+	if f.flattenDepth < -1 {
+		return nil, &usageError{fmt.Sprintf("invalid flatten depth %d", f.flattenDepth)}
+	}
+
+	opts := []toon.EncodeOption{
+		toon.WithIndent(f.indent),
+		toon.WithDelimiter(delimiter),
+		toon.WithLengthMarker(f.lengthMarker),
+		toon.WithFlattenPaths(f.flatten),
+		toon.WithStrict(f.strict.get(false)),
+		toon.WithSortKeys(f.sortKeys),
+	}
+	// Without a depth the library folds every level
+	if f.flattenDepth >= 0 {
+		opts = append(opts, toon.WithFlattenDepth(f.flattenDepth))
+	}
+	return opts, nil
+}
+
+// decodeFlags holds the flags that map onto toon.DecodeOptions, plus the
+// indent of the JSON output.
+type decodeFlags struct {
+	indentSize  int
+	expandPaths string
+	jsonIndent  string
+	strict      *optionalBool
+}
+
+// addDecodeFlags registers the decoding flags on fs. strict is registered
+// by the caller, since encoding has a -strict flag as well.
+func addDecodeFlags(fs *flag.FlagSet, strict *optionalBool) *decodeFlags {
+	f := &decodeFlags{strict: strict}
+	fs.IntVar(&f.indentSize, "indent-size", 2, "expected indentation of the input in spaces")
+	fs.StringVar(&f.expandPaths, "expand-paths", "off", `expand dotted keys: "off" or "safe"`)
+	fs.StringVar(&f.jsonIndent, "json-indent", "", "indent string of the JSON output (empty = compact)")
+	return f
+}
+
+// options returns the decode options selected by the flags.
+func (f *decodeFlags) options() ([]toon.DecodeOption, error) {
+	if f.expandPaths != "off" && f.expandPaths != "safe" {
+		return nil, &usageError{fmt.Sprintf("invalid expand-paths mode %q", f.expandPaths)}
+	}
+	if f.indentSize < 1 {
+		return nil, &usageError{fmt.Sprintf("invalid indent size %d", f.indentSize)}
+	}
+
+	return []toon.DecodeOption{
+		toon.WithStrictDecoding(f.strict.get(true)),
+		toon.WithIndentSize(f.indentSize),
+		toon.WithExpandPaths(f.expandPaths),
+	}, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/sstraus/toon_go/toon"
+	"github.com/sstraus/toon_go/toon/tokens"
+)
+
+// size is the cost of one encoding.
+type size struct {
+	bytes  int
+	tokens int
+}
+
+// statsRow is the cost of a value as pretty JSON, minified JSON and TOON.
+type statsRow struct {
+	key                  string
+	json, minified, toon size
+}
+
+// runStats implements "toon stats".
+func runStats(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("stats", "[flags] [file]",
+		"Reports bytes and tokens of the input as JSON, minified JSON and TOON,\n"+
+			"in total and for each top-level key. The input may be JSON or TOON.", stderr)
+	vocab := flags.String("vocab", "", "count with the tiktoken vocabulary file at this path instead of estimating")
+	strict := &optionalBool{}
+	flags.Var(strict, "strict", "fail on key collisions when flattening paths (default false)")
+	enc := addEncodeFlags(flags, strict)
+	if code := parseFlags(flags, args); code >= 0 {
+		return code
+	}
+
+	tok, err := loadTokenizer(*vocab)
+	if err != nil {
+		return fail(stderr, "stats", err)
+	}
+
+	return convertFile("stats", flags.Args(), "", stdin, stdout, stderr, func(in io.Reader, out io.Writer) error {
+		opts, err := enc.options()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		v, err := statsInput(data)
+		if err != nil {
+			return err
+		}
+
+		var rows []statsRow
+		if obj, ok := v.(*toon.OrderedMap); ok {
+			for _, k := range obj.Keys() {
+				field := toon.NewOrderedMap()
+				value, _ := obj.Get(k)
+				field.Set(k, value)
+				row, err := measure(k, field, tok, opts)
+				if err != nil {
+					return err
+				}
+				rows = append(rows, row)
+			}
+		}
+		total, err := measure("total", v, tok, opts)
+		if err != nil {
+			return err
+		}
+		return writeStats(out, append(rows, total))
+	})
+}
+
+// loadTokenizer returns the BPE tokenizer for the vocabulary file at path,
+// or the heuristic estimate when path is empty.
+func loadTokenizer(path string) (tokens.Tokenizer, error) {
+	if path == "" {
+		return tokens.Heuristic{}, nil
+	}
+	return tokens.LoadBPEFile(path)
+}
+
+// statsInput decodes JSON or TOON input, keeping object key order.
+func statsInput(data []byte) (toon.Value, error) {
+	if json.Valid(data) {
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		if _, ok := v.(map[string]interface{}); ok {
+			obj := toon.NewOrderedMap()
+			if err := json.Unmarshal(data, obj); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		}
+		return v, nil
+	}
+
+	doc, err := toon.ParseTree(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return doc.Node().Value(), nil
+}
+
+// measure encodes v in each format and counts its bytes and tokens.
+func measure(key string, v toon.Value, tok tokens.Tokenizer, opts []toon.EncodeOption) (statsRow, error) {
+	pretty, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return statsRow{}, err
+	}
+	minified, err := json.Marshal(v)
+	if err != nil {
+		return statsRow{}, err
+	}
+	encoded, err := toon.MarshalToString(v, opts...)
+	if err != nil {
+		return statsRow{}, err
+	}
+
+	count := func(s string) size { return size{len(s), tok.Count(s)} }
+	return statsRow{
+		key:      key,
+		json:     count(string(pretty)),
+		minified: count(string(minified)),
+		toon:     count(encoded),
+	}, nil
+}
+
+// writeStats writes the rows as an aligned table.
+func writeStats(w io.Writer, rows []statsRow) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tJSON BYTES\tJSON TOKENS\tMIN BYTES\tMIN TOKENS\tTOON BYTES\tTOON TOKENS\tVS JSON\tVS MIN")
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n", r.key,
+			r.json.bytes, r.json.tokens, r.minified.bytes, r.minified.tokens, r.toon.bytes, r.toon.tokens,
+			saving(r.toon.tokens, r.json.tokens), saving(r.toon.tokens, r.minified.tokens))
+	}
+	return tw.Flush()
+}
+
+// saving formats the token reduction from base to n as a percentage.
+func saving(n, base int) string {
+	if base == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(base-n)/float64(base))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunStats(t *testing.T) {
+	input := `{"users": [{"id": 1, "name": "Ada"}, {"id": 2, "name": "Bob"}], "page": 1}`
+	toonInput := "users[2]{id,name}:\n  1,Ada\n  2,Bob\npage: 1"
+
+	for _, stdin := range []string{input, toonInput} {
+		var stdout, stderr strings.Builder
+		if code := run([]string{"stats"}, strings.NewReader(stdin), &stdout, &stderr); code != exitOK {
+			t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+		}
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("run() output =\n%s\nwant header, users, page and total", stdout.String())
+		}
+		for i, key := range []string{"KEY", "users", "page", "total"} {
+			if fields := strings.Fields(lines[i]); fields[0] != key {
+				t.Errorf("line %d = %q, want key %s", i, lines[i], key)
+			}
+		}
+		// users: 115 bytes of pretty JSON, 55 minified, 34 as TOON
+		if fields := strings.Fields(lines[1]); fields[1] != "115" || fields[3] != "55" || fields[5] != "34" {
+			t.Errorf("users row = %q", lines[1])
+		}
+	}
+}
+
+func TestRunStatsVocabulary(t *testing.T) {
+	var vocab strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&vocab, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	path := filepath.Join(t.TempDir(), "bytes.tiktoken")
+	writeTestFile(t, path, vocab.String())
+
+	// With a vocabulary of single bytes, tokens equal bytes
+	var stdout, stderr strings.Builder
+	if code := run([]string{"stats", "-vocab", path}, strings.NewReader(`[1, 2]`), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(lines) != 2 || fields[0] != "total" || fields[1] != fields[2] || fields[5] != fields[6] {
+		t.Errorf("run() output =\n%s", stdout.String())
+	}
+
+	if code := run([]string{"stats", "-vocab", path + ".missing"}, strings.NewReader(`[1]`), &stdout, &stderr); code != exitError {
+		t.Errorf("run(missing vocabulary) = %d, want %d", code, exitError)
+	}
+	if code := run([]string{"stats"}, strings.NewReader("a[2]: 1"), &stdout, &stderr); code != exitInvalid {
+		t.Errorf("run(invalid input) = %d, want %d", code, exitInvalid)
+	}
+}
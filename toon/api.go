@@ -29,26 +29,62 @@ const Version = "1.1.0"
 //
 //	err := toon.Marshal(data, &buf, WithIndent(4), WithDelimiter("\t"))
 func Marshal(v interface{}, w io.Writer, opts ...EncodeOption) error {
+	normalized, encOpts, rounded, err := prepareEncode(v, opts...)
+	if err != nil {
+		return err
+	}
+
+	// Encode, within the token budget if one is set
+	var result string
+	report := &EncodeReport{}
+	if encOpts.TokenBudget > 0 {
+		result, report, err = encodeWithinBudget(normalized, encOpts)
+	} else {
+		result, err = encode(normalized, encOpts)
+	}
+	if err != nil {
+		return err
+	}
+	if encOpts.Report != nil {
+		report.Conversions = append(rounded, report.Conversions...)
+		*encOpts.Report = *report
+	}
+
+	// Write to io.Writer
+	_, err = w.Write([]byte(result))
+	return err
+}
+
+// prepareEncode applies and validates the options and normalizes v for
+// encoding, with its floats formatted. It also returns the rounded floats.
+func prepareEncode(v interface{}, opts ...EncodeOption) (Value, *EncodeOptions, []Conversion, error) {
 	// Apply functional options
 	encOpts := applyEncodeOptions(opts...)
 
+	// A reused report must not keep the numbers of an earlier call
+	if encOpts.Report != nil {
+		*encOpts.Report = EncodeReport{}
+	}
+
 	// Validate options
 	if err := validateEncodeOptions(encOpts); err != nil {
-		return err
+		return nil, nil, nil, err
 	}
+	resolveAutoDelimiter(encOpts)
 
 	// Normalize the value
 	normalized := normalize(v)
-
-	// Encode
-	result, err := encode(normalized, encOpts)
+	if encOpts.SortKeys {
+		var err error
+		if normalized, err = unorderedValue(normalized); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	normalized, rounded, err := formatFloats(normalized, encOpts)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
-
-	// Write to io.Writer
-	_, err = w.Write([]byte(result))
-	return err
+	return normalized, encOpts, rounded, nil
 }
 
 // MarshalToString encodes a Go value to TOON format and returns it as a string.
@@ -93,6 +129,31 @@ func MarshalToString(v interface{}, opts ...EncodeOption) (string, error) {
 // With options:
 //
 //	err := toon.Unmarshal(input, &result, WithStrictDecoding(false))
+//
+// Decoding into *map[string]RawValue splits the top-level fields without
+// parsing their values:
+//
+//	var fields map[string]toon.RawValue
+//	err := toon.Unmarshal(input, &fields)
+//	// fields["name"]: RawValue("Alice")
+//
+// Decoding into a pointer to a struct matches top-level fields by json tag.
+// RawValue fields keep their text, nested structs are split the same way and
+// other fields are decoded through encoding/json:
+//
+//	var doc struct {
+//	    Name    string        `json:"name"`
+//	    Payload toon.RawValue `json:"payload"`
+//	}
+//	err := toon.Unmarshal(input, &doc)
+//
+// Structs without RawValue fields are decoded whole through encoding/json.
+// Path expansion is not supported for targets with RawValue fields.
+//
+// In strict mode, indentation and the top-level structure are checked
+// before raw text is stored.
+//
+// Decoding into *Node keeps object fields in source order.
 func Unmarshal(r io.Reader, v interface{}, opts ...DecodeOption) error {
 	// Read from io.Reader
 	data, err := io.ReadAll(r)
@@ -108,6 +169,16 @@ func Unmarshal(r io.Reader, v interface{}, opts ...DecodeOption) error {
 		return err
 	}
 
+	// Raw targets capture source text without parsing it
+	if isRawTarget(v) {
+		return decodeRaw(string(data), decOpts, v)
+	}
+
+	// Node targets keep fields in source order
+	if target, ok := v.(*Node); ok {
+		return decodeNode(string(data), decOpts, target)
+	}
+
 	// Decode
 	result, err := decode(string(data), decOpts)
 	if err != nil {
@@ -138,14 +209,23 @@ func UnmarshalFromString(s string, v interface{}, opts ...DecodeOption) error {
 
 // applyEncodeOptions applies functional options to create EncodeOptions.
 func applyEncodeOptions(opts ...EncodeOption) *EncodeOptions {
-	// Start with defaults
+	// Start with defaults; -1 marks FlattenDepth as not set
 	encOpts := getEncodeOptions(nil)
+	encOpts.FlattenDepth = -1
 
 	// Apply functional options
 	for _, opt := range opts {
 		opt(encOpts)
 	}
 
+	// Not set - infinite folding when flattening is enabled
+	if encOpts.FlattenDepth == -1 {
+		encOpts.FlattenDepth = 0
+		if encOpts.FlattenPaths {
+			encOpts.FlattenDepth = 9999
+		}
+	}
+
 	return encOpts
 }
 
@@ -162,6 +242,30 @@ func applyDecodeOptions(opts ...DecodeOption) *DecodeOptions {
 	return decOpts
 }
 
+// decodeNode decodes input into target, keeping fields in source order.
+// Path expansion builds new objects, so it goes through the map decoder.
+func decodeNode(input string, opts *DecodeOptions, target *Node) error {
+	if opts.ExpandPaths == "safe" {
+		result, err := decode(input, opts)
+		if err != nil {
+			return err
+		}
+		n, err := NewNode(result)
+		if err != nil {
+			return err
+		}
+		*target = *n
+		return nil
+	}
+
+	doc, err := parseDocument(input, opts)
+	if err != nil {
+		return err
+	}
+	*target = *doc.Node()
+	return nil
+}
+
 // assignResult assigns the decoded result to the target variable.
 func assignResult(result Value, v interface{}) error {
 	switch target := v.(type) {
@@ -350,7 +350,7 @@ func TestEncodeListItemEdgeCases(t *testing.T) {
 					},
 				},
 			},
-			expected: "items[1]:\n  - a[1]: 1\n      b[2]: 2,3\n      c[3]: 4,5,6",
+			expected: "items[1]:\n  - a[1]: 1\n    b[2]: 2,3\n    c[3]: 4,5,6",
 		},
 		{
 			name: "nested array with boolean and string primitives",
@@ -370,6 +370,17 @@ func TestEncodeListItemEdgeCases(t *testing.T) {
 			},
 			expected: "numbers[1]:\n  - [3]: 1,2.5,3",
 		},
+		{
+			name: "empty objects as bare list markers",
+			input: map[string]interface{}{
+				"items": []interface{}{
+					"first",
+					map[string]interface{}{},
+					[]interface{}{map[string]interface{}{}, 1},
+				},
+			},
+			expected: "items[3]:\n  - first\n  -\n  - [2]:\n    -\n    - 1",
+		},
 	}
 
 	for _, tt := range tests {
@@ -509,3 +520,95 @@ func TestEncodeListItemWithCustomOptions(t *testing.T) {
 		})
 	}
 }
+
+// TestListItemNestedFirstFieldRoundTrip checks that an object under the first
+// field of a list item sits two levels below the hyphen, and that fields
+// following a nested first field decode as siblings.
+func TestListItemNestedFirstFieldRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{
+			name: "object as first field",
+			input: map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"data": "string", "id": 1},
+					map[string]interface{}{"data": map[string]interface{}{"nested": true}, "id": 2},
+				},
+			},
+			expected: "items[2]:\n  - data: string\n    id: 1\n  - data:\n      nested: true\n    id: 2",
+		},
+		{
+			name: "empty object as first field",
+			input: map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"data": map[string]interface{}{}, "id": 2},
+				},
+			},
+			expected: "items[1]:\n  - data:\n    id: 2",
+		},
+		{
+			name: "list array as first field",
+			input: map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{
+						"users":  []interface{}{map[string]interface{}{"id": 1, "name": "Ada"}, map[string]interface{}{"id": 2}},
+						"status": "active",
+					},
+				},
+			},
+			expected: "items[1]:\n  - users[2]:\n    - id: 1\n      name: Ada\n    - id: 2\n    status: active",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := MarshalToString(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Marshal() =\n%s\nwant\n%s", result, tt.expected)
+			}
+
+			var decoded interface{}
+			if err := UnmarshalFromString(result, &decoded); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !Equal(decoded, tt.input) {
+				t.Errorf("Unmarshal() = %v, want %v", decoded, tt.input)
+			}
+		})
+	}
+}
+
+// TestListItemLaterArrayFieldRoundTrip checks that arrays in the fields after
+// the first field of a list item sit one level below the hyphen, aligned with
+// the other fields.
+func TestListItemLaterArrayFieldRoundTrip(t *testing.T) {
+	item := NewOrderedMap()
+	item.Set("id", 1)
+	item.Set("tags", []interface{}{"a", "b"})
+	item.Set("scores", []interface{}{1, 2, 3})
+	item.Set("name", "Ada")
+	input := map[string]interface{}{"items": []interface{}{item}}
+	expected := "items[1]:\n  - id: 1\n    tags[2]: a,b\n    scores[3]: 1,2,3\n    name: Ada"
+
+	result, err := MarshalToString(input)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if result != expected {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", result, expected)
+	}
+
+	var decoded interface{}
+	if err := UnmarshalFromString(result, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !Equal(decoded, input) {
+		t.Errorf("Unmarshal() = %v, want %v", decoded, input)
+	}
+}
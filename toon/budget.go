@@ -0,0 +1,435 @@
+package toon
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sstraus/toon_go/toon/tokens"
+)
+
+// ConversionKind identifies the kind of a Conversion.
+type ConversionKind string
+
+const (
+	// ConversionArrayTruncated is an array whose middle elements were
+	// replaced by an elision marker. Old and New hold the original and the
+	// kept number of elements.
+	ConversionArrayTruncated ConversionKind = "array-truncated"
+	// ConversionStringShortened is a string cut to a prefix followed by an
+	// elision marker. Old and New hold the original and the written string.
+	ConversionStringShortened ConversionKind = "string-shortened"
+	// ConversionSubtreeDropped is an object or array replaced by an elision
+	// marker. Old holds the dropped value.
+	ConversionSubtreeDropped ConversionKind = "subtree-dropped"
+	// ConversionFloatRounded is a float that lost digits to its
+	// FloatFormat. Old holds the original number and New the json.Number
+	// written.
+	ConversionFloatRounded ConversionKind = "float-rounded"
+)
+
+// Conversion is a lossy change made to a value while encoding it.
+type Conversion struct {
+	// Kind is the kind of conversion.
+	Kind ConversionKind
+	// Path locates the converted value using Get path syntax; "" is the root.
+	Path string
+	// Old is the value before the conversion.
+	Old Value
+	// New is the value after the conversion; nil for dropped subtrees.
+	New Value
+}
+
+// EncodeReport describes what Marshal changed to satisfy its options.
+// It is filled in when the WithReport option is given, and left empty when
+// Marshal fails.
+type EncodeReport struct {
+	// Conversions lists the lossy conversions: rounded floats, then the
+	// changes made for the token budget, each in document order.
+	Conversions []Conversion
+	// Tokens is the token count of the output when a token budget is set.
+	Tokens int
+}
+
+// minStringRunes is the shortest length strings are cut to.
+const minStringRunes = 8
+
+// budgetLimits are the truncation levels tried to fit a token budget.
+// Zero fields do not limit.
+type budgetLimits struct {
+	keep  int // array elements kept at each end
+	runes int // string length in runes
+	depth int // containers at this depth or deeper are dropped
+}
+
+// budgetEncoder encodes a value under increasingly strict limits until
+// the output fits the token budget.
+type budgetEncoder struct {
+	opts      *EncodeOptions
+	tokenizer tokens.Tokenizer
+
+	// result of the last fitting attempt
+	out         string
+	tokens      int
+	conversions []Conversion
+}
+
+// encodeWithinBudget encodes v in at most opts.TokenBudget tokens.
+// Arrays are truncated first, then strings are shortened, then deep
+// subtrees are dropped; each step uses the loosest limit that fits.
+func encodeWithinBudget(v Value, opts *EncodeOptions) (string, *EncodeReport, error) {
+	b := &budgetEncoder{opts: opts, tokenizer: opts.Tokenizer}
+	if b.tokenizer == nil {
+		b.tokenizer = tokens.Heuristic{}
+	}
+
+	longest, maxRunes, depth := valueExtent(v, 0)
+	limits := budgetLimits{}
+	fits, err := b.fits(v, limits)
+	if err != nil || fits {
+		return b.result(err)
+	}
+
+	if longest > 3 {
+		k, err := b.largest(1, (longest-2)/2, func(n int) (bool, error) {
+			return b.fits(v, budgetLimits{keep: n})
+		})
+		if err != nil || k > 0 {
+			return b.result(err)
+		}
+		limits.keep = 1
+	}
+
+	if maxRunes > minStringRunes {
+		n, err := b.largest(minStringRunes, maxRunes-1, func(n int) (bool, error) {
+			return b.fits(v, budgetLimits{keep: limits.keep, runes: n})
+		})
+		if err != nil || n > 0 {
+			return b.result(err)
+		}
+		limits.runes = minStringRunes
+	}
+
+	if depth > 0 {
+		d, err := b.largest(1, depth, func(n int) (bool, error) {
+			return b.fits(v, budgetLimits{keep: limits.keep, runes: limits.runes, depth: n})
+		})
+		if err != nil || d > 0 {
+			return b.result(err)
+		}
+		limits.depth = 1
+	}
+
+	if fits, err := b.fits(v, limits); err != nil || fits {
+		return b.result(err)
+	}
+	return "", nil, &EncodeError{
+		Message: fmt.Sprintf("token budget of %d exceeded: shortest output has %d tokens",
+			opts.TokenBudget, b.tokens),
+	}
+}
+
+// result returns the last fitting attempt.
+func (b *budgetEncoder) result(err error) (string, *EncodeReport, error) {
+	if err != nil {
+		return "", nil, err
+	}
+	return b.out, &EncodeReport{Conversions: b.conversions, Tokens: b.tokens}, nil
+}
+
+// fits encodes v under limits and reports whether the output is within
+// the budget. The attempt is kept when it fits.
+func (b *budgetEncoder) fits(v Value, limits budgetLimits) (bool, error) {
+	t := &truncator{opts: b.opts, limits: limits}
+	limited, err := t.limit(v, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	out, err := encode(limited, b.opts)
+	if err != nil {
+		return false, err
+	}
+
+	b.tokens = b.tokenizer.Count(out)
+	if b.tokens > b.opts.TokenBudget {
+		return false, nil
+	}
+	b.out, b.conversions = out, t.conversions
+	return true, nil
+}
+
+// largest returns the largest n in [lo, hi] for which f is true, or 0,
+// assuming f is true up to some n and false above it. The fitting attempt
+// for the returned n is left in b.
+func (b *budgetEncoder) largest(lo, hi int, f func(int) (bool, error)) (int, error) {
+	best, last := 0, 0
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		ok, err := f(mid)
+		if err != nil {
+			return 0, err
+		}
+		last = mid
+		if ok {
+			best, lo = mid, mid+1
+		} else {
+			hi = mid - 1
+		}
+	}
+	if best > 0 && last != best {
+		// Restore the token count of the fitting attempt
+		if _, err := f(best); err != nil {
+			return 0, err
+		}
+	}
+	return best, nil
+}
+
+// valueExtent returns the length of the longest array, the length in runes
+// of the longest string and the depth of the deepest non-empty container
+// in v, which is at the given depth.
+func valueExtent(v Value, depth int) (longest, runes, maxDepth int) {
+	visit := func(child Value) {
+		l, r, d := valueExtent(child, depth+1)
+		longest, runes, maxDepth = max(longest, l), max(runes, r), max(maxDepth, d)
+	}
+
+	switch val := v.(type) {
+	case string:
+		return 0, utf8.RuneCountInString(val), 0
+	case []Value:
+		if len(val) > 0 {
+			longest, maxDepth = len(val), depth
+		}
+		for _, item := range val {
+			visit(item)
+		}
+	default:
+		if keys, values, ok := objectFields(v); ok {
+			if len(keys) > 0 {
+				maxDepth = depth
+			}
+			for _, field := range values {
+				visit(field)
+			}
+		}
+	}
+	return longest, runes, maxDepth
+}
+
+// objectFields returns the keys of a normalized object in encoding order
+// and their values.
+func objectFields(v Value) ([]string, []Value, bool) {
+	var keys []string
+	var get func(string) Value
+	switch val := v.(type) {
+	case OrderedMap:
+		keys, get = val.Keys(), func(k string) Value { f, _ := val.Get(k); return f }
+	case *OrderedMap:
+		keys, get = val.Keys(), func(k string) Value { f, _ := val.Get(k); return f }
+	case map[string]Value:
+		keys = make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sortStrings(keys)
+		get = func(k string) Value { return val[k] }
+	default:
+		return nil, nil, false
+	}
+
+	values := make([]Value, len(keys))
+	for i, k := range keys {
+		values[i] = get(k)
+	}
+	return keys, values, true
+}
+
+// truncator applies budgetLimits to a normalized value, recording each
+// conversion.
+type truncator struct {
+	opts        *EncodeOptions
+	limits      budgetLimits
+	conversions []Conversion
+}
+
+// limit returns v, at path and depth, with the limits applied. Truncated
+// arrays and dropped subtrees become RawValue elision markers.
+func (t *truncator) limit(v Value, path []pathSegment, depth int) (Value, error) {
+	switch val := v.(type) {
+	case string:
+		return t.limitString(val, path), nil
+	case []Value:
+		if len(val) == 0 {
+			return val, nil
+		}
+		if t.limits.depth > 0 && depth >= t.limits.depth {
+			return t.drop(val, path, formatArrayHeader("", len(val), nil, t.opts)+space+elision(len(val))), nil
+		}
+		return t.limitArray(val, path, depth)
+	}
+
+	keys, values, ok := objectFields(v)
+	if !ok || len(keys) == 0 {
+		return v, nil
+	}
+	if t.limits.depth > 0 && depth >= t.limits.depth {
+		return t.drop(v, path, fmt.Sprintf("{... %d %s}", len(keys), plural(len(keys), "field"))), nil
+	}
+
+	limited := make([]Value, len(keys))
+	for i, k := range keys {
+		field, err := t.limit(values[i], appendPath(path, pathSegment{key: k}), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		limited[i] = field
+	}
+
+	// Plain maps keep the encoder's own key order
+	if _, plain := v.(map[string]Value); plain {
+		m := make(map[string]Value, len(keys))
+		for i, k := range keys {
+			m[k] = limited[i]
+		}
+		return m, nil
+	}
+	obj := NewOrderedMap()
+	for i, k := range keys {
+		obj.Set(k, limited[i])
+	}
+	return obj, nil
+}
+
+// limitString shortens s to the rune limit with a marker naming the
+// number of runes cut.
+func (t *truncator) limitString(s string, path []pathSegment) Value {
+	n := utf8.RuneCountInString(s)
+	if t.limits.runes == 0 || n <= t.limits.runes {
+		return s
+	}
+
+	cut := 0
+	for i := range s {
+		if cut == t.limits.runes {
+			cut = i
+			break
+		}
+		cut++
+	}
+	short := fmt.Sprintf("%s... (%d more chars)", s[:cut], n-t.limits.runes)
+	t.record(ConversionStringShortened, path, s, short)
+	return short
+}
+
+// limitArray keeps the first and last limits.keep elements of arr and
+// writes the omitted count in their place.
+func (t *truncator) limitArray(arr []Value, path []pathSegment, depth int) (Value, error) {
+	keep := t.limits.keep
+	truncated := keep > 0 && len(arr) > 2*keep+1
+
+	var items []Value
+	for i, item := range arr {
+		if truncated && i >= keep && i < len(arr)-keep {
+			continue
+		}
+		limited, err := t.limit(item, appendPath(path, pathSegment{index: i, isIndex: true}), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, limited)
+	}
+	if !truncated {
+		return items, nil
+	}
+
+	t.record(ConversionArrayTruncated, path, int64(len(arr)), int64(len(items)))
+	return encodeElidedArray(items, len(arr), keep, t.opts)
+}
+
+// drop replaces v with a marker.
+func (t *truncator) drop(v Value, path []pathSegment, marker string) Value {
+	t.record(ConversionSubtreeDropped, path, v, nil)
+	return RawValue(marker)
+}
+
+// record appends a conversion.
+func (t *truncator) record(kind ConversionKind, path []pathSegment, old, new Value) {
+	t.conversions = append(t.conversions, Conversion{Kind: kind, Path: formatPath(path), Old: old, New: new})
+}
+
+// plural returns word with an "s" unless n is 1.
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// elision is the marker standing for n omitted elements.
+func elision(n int) string {
+	return fmt.Sprintf("... %d more", n)
+}
+
+// encodeElidedArray encodes the kept items of an array of the given
+// length as a standalone array with an elision marker after the first
+// at items. The header reports the original length.
+func encodeElidedArray(items []Value, length, at int, opts *EncodeOptions) (RawValue, error) {
+	w := newWriter(opts.Indent)
+	marker := elision(length - len(items))
+
+	format := detectArrayFormat(items)
+	if format == arrayFormatInline || format == arrayFormatTabular {
+		var err error
+		if opts, err = withArrayDelimiter(opts, arrayCells(items, format)); err != nil {
+			return nil, err
+		}
+	}
+
+	switch format {
+	case arrayFormatInline:
+		values := make([]string, 0, len(items)+1)
+		for i, item := range items {
+			if i == at {
+				values = append(values, marker)
+			}
+			encoded, err := encodePrimitive(item, opts.Delimiter)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, encoded)
+		}
+		w.push(formatArrayHeader("", length, nil, opts)+space+strings.Join(values, opts.Delimiter), 0)
+
+	case arrayFormatTabular:
+		keys := tabularKeys(items[0])
+		w.push(formatArrayHeader("", length, keys, opts), 0)
+		for i, item := range items {
+			if i == at {
+				w.push(marker, 1)
+			}
+			values := make([]string, len(keys))
+			for j, k := range keys {
+				encoded, err := encodePrimitive(tabularCell(item, k), opts.Delimiter)
+				if err != nil {
+					return nil, err
+				}
+				values[j] = encoded
+			}
+			w.push(strings.Join(values, opts.Delimiter), 1)
+		}
+
+	default:
+		w.push(formatArrayHeader("", length, nil, opts), 0)
+		for i, item := range items {
+			if i == at {
+				w.push(listItemPrefix+marker, 1)
+			}
+			if err := encodeListItem(w, item, 1, opts, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return RawValue(w.String()), nil
+}
@@ -0,0 +1,208 @@
+package toon
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sstraus/toon_go/toon/tokens"
+)
+
+// lineTokenizer counts one token per line, so budgets are easy to follow.
+type lineTokenizer struct{}
+
+func (lineTokenizer) Count(text string) int {
+	return strings.Count(text, "\n") + 1
+}
+
+// byteTokenizer counts one token per byte.
+type byteTokenizer struct{}
+
+func (byteTokenizer) Count(text string) int {
+	return len(text)
+}
+
+// budgetUsers returns n objects with an id and a name.
+func budgetUsers(n int) []interface{} {
+	users := make([]interface{}, n)
+	for i := range users {
+		users[i] = map[string]interface{}{"id": i + 1, "name": "u" + string(rune('a'+i%26))}
+	}
+	return users
+}
+
+func TestWithTokenBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  interface{}
+		budget int
+		tok    tokens.Tokenizer
+		want   string
+		kinds  []ConversionKind
+	}{
+		{
+			name:   "fits",
+			input:  map[string]interface{}{"users": budgetUsers(3)},
+			budget: 4,
+			want:   "users[3]{id,name}:\n  1,ua\n  2,ub\n  3,uc",
+		},
+		{
+			name:   "tabular rows",
+			input:  map[string]interface{}{"users": budgetUsers(10)},
+			budget: 6,
+			want:   "users[10]{id,name}:\n  1,ua\n  2,ub\n  ... 6 more\n  9,ui\n  10,uj",
+			kinds:  []ConversionKind{ConversionArrayTruncated},
+		},
+		{
+			name: "list items",
+			input: map[string]interface{}{"items": []interface{}{
+				"a", map[string]interface{}{"x": 1}, "c", "d", "e", []interface{}{1, 2},
+			}},
+			budget: 5,
+			want:   "items[6]:\n  - a\n  - ... 4 more\n  - [2]: 1,2",
+			kinds:  []ConversionKind{ConversionArrayTruncated},
+		},
+		{
+			name: "nested array in list item",
+			input: []interface{}{
+				map[string]interface{}{"tags": []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+				map[string]interface{}{"tags": []interface{}{map[string]interface{}{"a": 1}}},
+				[]interface{}{1},
+			},
+			budget: 67,
+			tok:    byteTokenizer{},
+			want:   "[3]:\n  - tags[10]: 1,... 8 more,10\n  - tags[1]{a}:\n    1\n  - [1]: 1",
+			kinds:  []ConversionKind{ConversionArrayTruncated},
+		},
+		{
+			name: "deep subtrees",
+			input: map[string]interface{}{
+				"id":   1,
+				"meta": map[string]interface{}{"a": map[string]interface{}{"b": 1}, "c": 2},
+				"tags": []interface{}{"x", "y"},
+			},
+			budget: 5,
+			want:   "id: 1\nmeta:\n  a: {... 1 field}\n  c: 2\ntags[2]: x,y",
+			kinds:  []ConversionKind{ConversionSubtreeDropped},
+		},
+		{
+			name: "shallow subtrees",
+			input: map[string]interface{}{
+				"id":   1,
+				"meta": map[string]interface{}{"a": map[string]interface{}{"b": 1}, "c": 2},
+				"tags": []interface{}{"x", "y"},
+			},
+			budget: 3,
+			want:   "id: 1\nmeta: {... 2 fields}\ntags[2]: ... 2 more",
+			kinds:  []ConversionKind{ConversionSubtreeDropped, ConversionSubtreeDropped},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok := tt.tok
+			if tok == nil {
+				tok = lineTokenizer{}
+			}
+			var report EncodeReport
+			got, err := MarshalToString(tt.input, WithTokenBudget(tt.budget, tok), WithReport(&report))
+			if err != nil {
+				t.Fatalf("MarshalToString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MarshalToString() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if n := tok.Count(got); report.Tokens != n {
+				t.Errorf("report.Tokens = %d, want %d", report.Tokens, n)
+			}
+			var kinds []ConversionKind
+			for _, c := range report.Conversions {
+				kinds = append(kinds, c.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.kinds) {
+				t.Errorf("conversion kinds = %v, want %v", kinds, tt.kinds)
+			}
+		})
+	}
+}
+
+func TestWithTokenBudgetStrings(t *testing.T) {
+	input := map[string]interface{}{
+		"id":   1,
+		"text": "The quick brown fox jumps over the lazy dog, again and again and again.",
+	}
+
+	var report EncodeReport
+	got, err := MarshalToString(input, WithTokenBudget(20, tokens.Heuristic{}), WithReport(&report))
+	if err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+	if report.Tokens > 20 || report.Tokens != (tokens.Heuristic{}).Count(got) {
+		t.Errorf("report.Tokens = %d for %q, want the count of the output within 20", report.Tokens, got)
+	}
+	if len(report.Conversions) != 1 {
+		t.Fatalf("report.Conversions = %+v, want one conversion", report.Conversions)
+	}
+	c := report.Conversions[0]
+	if c.Kind != ConversionStringShortened || c.Path != "text" || c.Old != input["text"] {
+		t.Errorf("conversion = %+v, want the shortened text", c)
+	}
+	short, _ := c.New.(string)
+	if !strings.HasPrefix(short, "The quick") || !strings.Contains(short, "more chars)") {
+		t.Errorf("shortened string = %q", short)
+	}
+	if !strings.Contains(got, short) {
+		t.Errorf("output %q does not contain %q", got, short)
+	}
+}
+
+func TestWithTokenBudgetReport(t *testing.T) {
+	input := map[string]interface{}{"users": budgetUsers(10)}
+
+	var report EncodeReport
+	if _, err := MarshalToString(input, WithTokenBudget(6, lineTokenizer{}), WithReport(&report)); err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+	want := []Conversion{{Kind: ConversionArrayTruncated, Path: "users", Old: int64(10), New: int64(4)}}
+	if !reflect.DeepEqual(report.Conversions, want) {
+		t.Errorf("report.Conversions = %+v, want %+v", report.Conversions, want)
+	}
+
+	// Without a budget the report is reset and nothing is counted
+	if _, err := MarshalToString(input, WithReport(&report)); err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+	if len(report.Conversions) != 0 || report.Tokens != 0 {
+		t.Errorf("report = %+v, want empty", report)
+	}
+
+	// A failed call does not leave the numbers of the previous one
+	if _, err := MarshalToString(input, WithTokenBudget(6, lineTokenizer{}), WithReport(&report)); err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+	tooLong := map[string]interface{}{"a": 1, "b": 2, "c": 3}
+	if _, err := MarshalToString(tooLong, WithTokenBudget(2, lineTokenizer{}), WithReport(&report)); err == nil {
+		t.Fatal("MarshalToString() error = nil, want error")
+	}
+	if len(report.Conversions) != 0 || report.Tokens != 0 {
+		t.Errorf("report after error = %+v, want empty", report)
+	}
+}
+
+func TestWithTokenBudgetErrors(t *testing.T) {
+	input := map[string]interface{}{"a": 1, "b": 2, "c": 3}
+
+	_, err := MarshalToString(input, WithTokenBudget(2, lineTokenizer{}))
+	var encErr *EncodeError
+	if !errors.As(err, &encErr) {
+		t.Fatalf("MarshalToString() error = %v, want *EncodeError", err)
+	}
+	if !strings.Contains(err.Error(), "shortest output has 3 tokens") {
+		t.Errorf("error = %q", err)
+	}
+
+	if _, err := MarshalToString(input, WithTokenBudget(-1, nil)); err == nil {
+		t.Error("MarshalToString() with negative budget error = nil, want error")
+	}
+}
@@ -0,0 +1,134 @@
+package toon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// Canonical returns an encode option that selects the canonical profile:
+// 2-space indentation, comma delimiter, no length marker, no path
+// flattening, default float formatting, no token budget and sorted keys for
+// every object, including OrderedMap values.
+//
+// Numbers are written in the shortest decimal form, without an exponent,
+// with whole floats as integers and -0 as 0; under sorted keys this also
+// applies to json.Number values. With these rules and the fixed quoting
+// rules, equivalent values always produce the same bytes, which makes the
+// output suitable for hashing and signing. Options given after Canonical
+// override parts of the profile.
+//
+// Example:
+//
+//	a, _ := toon.MarshalToString(orderedConfig, toon.Canonical())
+//	b, _ := toon.MarshalToString(mapConfig, toon.Canonical())
+//	// a == b
+func Canonical() EncodeOption {
+	return func(opts *EncodeOptions) {
+		opts.Indent = defaultIndent
+		opts.Delimiter = comma
+		opts.LengthMarker = ""
+		opts.FlattenPaths = false
+		opts.FloatFormat = FloatFormat{}
+		opts.FloatFormats = nil
+		opts.TokenBudget = 0
+		opts.SortKeys = true
+	}
+}
+
+// CanonicalHash returns the hex-encoded SHA-256 digest of the canonical
+// TOON encoding of v.
+//
+// Example:
+//
+//	key, err := toon.CanonicalHash(prompt)
+//	if cached, ok := cache[key]; ok {
+//	    return cached
+//	}
+func CanonicalHash(v Value) (string, error) {
+	s, err := MarshalToString(v, Canonical())
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// unorderedValue converts OrderedMap values inside a normalized value into
+// plain maps, whose keys the encoder sorts. RawValue subtrees are parsed so
+// their keys are sorted too, and json.Number values are rewritten through the
+// regular integer and float formatting.
+func unorderedValue(v Value) (Value, error) {
+	switch val := v.(type) {
+	case OrderedMap:
+		return unorderedMap(val.Keys(), val.Get)
+	case *OrderedMap:
+		return unorderedMap(val.Keys(), val.Get)
+	case map[string]Value:
+		result := make(map[string]Value, len(val))
+		for k, item := range val {
+			converted, err := unorderedValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = converted
+		}
+		return result, nil
+	case []Value:
+		result := make([]Value, len(val))
+		for i, item := range val {
+			converted, err := unorderedValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = converted
+		}
+		return result, nil
+	case RawValue:
+		n, err := NewNode(val)
+		if err != nil {
+			return nil, err
+		}
+		return unorderedValue(normalize(n.Value()))
+	case json.Number:
+		return canonicalNumber(val)
+	default:
+		return v, nil
+	}
+}
+
+// unorderedMap builds a plain map from ordered keys and a lookup function.
+func unorderedMap(keys []string, get func(string) (interface{}, bool)) (Value, error) {
+	result := make(map[string]Value, len(keys))
+	for _, k := range keys {
+		item, _ := get(k)
+		converted, err := unorderedValue(item)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = converted
+	}
+	return result, nil
+}
+
+// canonicalNumber converts a json.Number into the int64 or float64 it
+// denotes, so 1.50 and 1e-7 encode like 1.5 and 0.0000001. Integers beyond
+// int64 keep their digits.
+func canonicalNumber(n json.Number) (Value, error) {
+	s := string(n)
+	if !isJSONNumber(s) {
+		return nil, &EncodeError{Message: "invalid number", Value: n}
+	}
+	if i, err := n.Int64(); err == nil {
+		return i, nil
+	}
+	if !strings.ContainsAny(s, ".eE") {
+		return n, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, &EncodeError{Message: "number out of range", Value: n, Cause: err}
+	}
+	return normalizeFloat(f), nil
+}
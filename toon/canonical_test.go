@@ -0,0 +1,203 @@
+package toon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalKeyOrder(t *testing.T) {
+	om := NewOrderedMap()
+	om.Set("name", "app")
+	om.Set("b", 1.0)
+	inner := NewOrderedMap()
+	inner.Set("z", true)
+	inner.Set("a", "x,y")
+	om.Set("items", []interface{}{inner, inner})
+
+	m := map[string]interface{}{
+		"b":     int64(1),
+		"items": []interface{}{map[string]interface{}{"a": "x,y", "z": true}, map[string]Value{"z": true, "a": "x,y"}},
+		"name":  "app",
+	}
+
+	a, err := MarshalToString(om, Canonical())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	b, err := MarshalToString(m, Canonical())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := "b: 1\nitems[2]{a,z}:\n  \"x,y\",true\n  \"x,y\",true\nname: app"
+	if a != want {
+		t.Errorf("Marshal(OrderedMap) =\n%s\nwant\n%s", a, want)
+	}
+	if b != want {
+		t.Errorf("Marshal(map) =\n%s\nwant\n%s", b, want)
+	}
+}
+
+func TestCanonicalOverridesOptions(t *testing.T) {
+	v := map[string]interface{}{"tags": []interface{}{"a", "b"}, "n": map[string]interface{}{"x": 1}}
+
+	got, err := MarshalToString(v, WithIndent(4), WithDelimiter(pipe), WithLengthMarker("#"), WithFlattenPaths(true), Canonical())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := "n:\n  x: 1\ntags[2]: a,b"
+	if got != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, want)
+	}
+
+	f := map[string]interface{}{"a": 1.25, "b": []interface{}{"x", "y", "z"}, "c": 2.5}
+	got, err = MarshalToString(f, WithFloatFormat(FloatFormat{Digits: 1}), WithFloatFormatAt("c", FloatFormat{Digits: 1}), WithTokenBudget(3, nil), Canonical())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want, err = MarshalToString(f, Canonical())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCanonicalRawValue(t *testing.T) {
+	raw := map[string]interface{}{"cfg": RawValue("z: 1\na: 2")}
+	plain := map[string]interface{}{"cfg": map[string]interface{}{"a": 2, "z": 1}}
+
+	a, err := CanonicalHash(raw)
+	if err != nil {
+		t.Fatalf("CanonicalHash() error = %v", err)
+	}
+	b, err := CanonicalHash(plain)
+	if err != nil {
+		t.Fatalf("CanonicalHash() error = %v", err)
+	}
+	if a != b {
+		t.Errorf("CanonicalHash(raw) = %s, want %s", a, b)
+	}
+}
+
+func TestCanonicalJSONNumber(t *testing.T) {
+	tests := []struct {
+		input    json.Number
+		expected string
+	}{
+		{"1.50", "x: 1.5"},
+		{"1e-7", "x: 0.0000001"},
+		{"2E+3", "x: 2000"},
+		{"-0.0", "x: 0"},
+		{"12345678901234567890", "x: 12345678901234567890"},
+	}
+
+	for _, tt := range tests {
+		got, err := MarshalToString(map[string]interface{}{"x": tt.input}, Canonical())
+		if err != nil {
+			t.Fatalf("Marshal(%s) error = %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Marshal(%s) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+
+	a, err := CanonicalHash(map[string]interface{}{"x": json.Number("1.50")})
+	if err != nil {
+		t.Fatalf("CanonicalHash() error = %v", err)
+	}
+	b, err := CanonicalHash(map[string]interface{}{"x": 1.5})
+	if err != nil {
+		t.Fatalf("CanonicalHash() error = %v", err)
+	}
+	if a != b {
+		t.Errorf("CanonicalHash(1.50) = %s, want %s", a, b)
+	}
+}
+
+func TestCanonicalHash(t *testing.T) {
+	// Fixed digest: changing it breaks every cache keyed by CanonicalHash
+	v := map[string]interface{}{"users": []interface{}{
+		map[string]interface{}{"id": 1, "name": "Ada"},
+		map[string]interface{}{"id": 2.0, "name": "Bob"},
+	}}
+
+	got, err := CanonicalHash(v)
+	if err != nil {
+		t.Fatalf("CanonicalHash() error = %v", err)
+	}
+	const want = "070c6e11378d7439809e26db0d393991e3262046c36cc1ecd10f55e6468b1e96"
+	if got != want {
+		t.Errorf("CanonicalHash() = %s, want %s", got, want)
+	}
+}
+
+func TestCanonicalFixtures(t *testing.T) {
+	files, err := filepath.Glob("../testdata/fixtures/encode/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no encode fixtures found: %v", err)
+	}
+
+	// Digest of every canonical output, pinned so output stays stable
+	// across runs and releases
+	digest := sha256.New()
+
+	for _, file := range files {
+		fixture, err := loadFixture(file)
+		if err != nil {
+			t.Fatalf("loadFixture(%s) error = %v", file, err)
+		}
+
+		for _, tt := range fixture.Tests {
+			if tt.ShouldError {
+				continue
+			}
+			first, err := MarshalToString(tt.Input, Canonical())
+			if err != nil {
+				continue
+			}
+			digest.Write([]byte(first + "\n\n"))
+
+			t.Run(filepath.Base(file)+"/"+tt.Name, func(t *testing.T) {
+				// Same bytes on every run
+				for i := 0; i < 5; i++ {
+					again, err := MarshalToString(tt.Input, Canonical())
+					if err != nil || again != first {
+						t.Fatalf("run %d = %q, %v; want %q", i, again, err, first)
+					}
+				}
+
+				// Same bytes without the fixture's key order
+				data, err := json.Marshal(tt.Input)
+				if err != nil {
+					t.Fatalf("json.Marshal() error = %v", err)
+				}
+				var plain interface{}
+				if err := json.Unmarshal(data, &plain); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v", err)
+				}
+				if got, err := MarshalToString(plain, Canonical()); err != nil || got != first {
+					t.Errorf("unordered input = %q, %v; want %q", got, err, first)
+				}
+
+				// Canonical output is a fixed point of decode and re-encode
+				var decoded interface{}
+				if err := UnmarshalFromString(first, &decoded); err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if got, err := MarshalToString(decoded, Canonical()); err != nil || got != first {
+					t.Errorf("re-encoded = %q, %v; want %q", got, err, first)
+				}
+			})
+		}
+	}
+
+	const want = "1cdf97dbefa3872502c2c5fdf9c580b4e78c0e4bc7fe4be12e054f7da7b93d58"
+	if got := hex.EncodeToString(digest.Sum(nil)); got != want {
+		t.Errorf("canonical fixture digest = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,269 @@
+package toon
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/sstraus/toon_go/toon/tokens"
+)
+
+// ChunkOptions configures Chunk.
+type ChunkOptions struct {
+	// Context lists paths of values repeated in every chunk, such as a
+	// "metadata" sibling of the array (default: none).
+	Context []string
+
+	// Tokenizer counts the tokens of each chunk (default: tokens.Heuristic).
+	Tokenizer tokens.Tokenizer
+
+	// Encode holds the options used to encode each chunk.
+	Encode []EncodeOption
+}
+
+// ChunkOption is a functional option for configuring Chunk.
+type ChunkOption func(*ChunkOptions)
+
+// WithChunkContext repeats the values at paths in every chunk.
+func WithChunkContext(paths ...string) ChunkOption {
+	return func(o *ChunkOptions) {
+		o.Context = append(o.Context, paths...)
+	}
+}
+
+// WithChunkTokenizer sets the tokenizer that measures chunks
+// (default: tokens.Heuristic).
+func WithChunkTokenizer(tokenizer tokens.Tokenizer) ChunkOption {
+	return func(o *ChunkOptions) {
+		o.Tokenizer = tokenizer
+	}
+}
+
+// WithChunkEncodeOptions sets the options used to encode each chunk.
+func WithChunkEncodeOptions(opts ...EncodeOption) ChunkOption {
+	return func(o *ChunkOptions) {
+		o.Encode = append(o.Encode, opts...)
+	}
+}
+
+// applyChunkOptions applies functional options to create ChunkOptions.
+func applyChunkOptions(opts ...ChunkOption) *ChunkOptions {
+	chunkOpts := &ChunkOptions{Tokenizer: tokens.Heuristic{}}
+	for _, opt := range opts {
+		opt(chunkOpts)
+	}
+	if chunkOpts.Tokenizer == nil {
+		chunkOpts.Tokenizer = tokens.Heuristic{}
+	}
+	return chunkOpts
+}
+
+// Chunk splits the array at path inside v into TOON documents of at most
+// maxTokens tokens each, for spreading a large dataset over several model
+// calls.
+//
+// Every chunk is a complete document: the array's enclosing objects with
+// only the array and the context paths kept, and a consecutive run of the
+// array's elements whose [N] header counts the elements in that chunk.
+// Arrays along a path keep only the selected elements. Elements are never
+// split; an element that does not fit within maxTokens on its own is an
+// error.
+//
+// Example:
+//
+//	chunks, err := toon.Chunk(data, "users", 2000,
+//		toon.WithChunkContext("metadata"))
+//	// chunks[0]:
+//	// metadata:
+//	//   source: crm
+//	// users[120]{id,name}:
+//	//   1,Ada
+//	//   ...
+func Chunk(v Value, path string, maxTokens int, opts ...ChunkOption) ([][]byte, error) {
+	chunkOpts := applyChunkOptions(opts...)
+	if maxTokens <= 0 {
+		return nil, &EncodeError{Message: "chunk token limit must be positive", Value: maxTokens}
+	}
+
+	normalized := normalize(v)
+	items, err := GetSlice(normalized, path)
+	if err != nil {
+		return nil, err
+	}
+	target, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &chunker{root: normalized, opts: chunkOpts, maxTokens: maxTokens}
+	c.selections = append(c.selections, chunkSelection{path: target, items: true})
+	for _, p := range chunkOpts.Context {
+		if _, err := Get(normalized, p); err != nil {
+			return nil, err
+		}
+		segments, err := parsePath(p)
+		if err != nil {
+			return nil, err
+		}
+		c.selections = append(c.selections, chunkSelection{path: segments})
+	}
+
+	if len(items) == 0 {
+		doc, _, err := c.encode(items)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{doc}, nil
+	}
+
+	var chunks [][]byte
+	for start := 0; start < len(items); {
+		doc, n, err := c.next(items, start)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, &EncodeError{
+				Message: fmt.Sprintf("element %s does not fit in %d tokens",
+					formatPath(appendPath(target, pathSegment{index: start, isIndex: true})), maxTokens),
+			}
+		}
+		chunks = append(chunks, doc)
+		start += n
+	}
+	return chunks, nil
+}
+
+// chunkSelection is a path kept in every chunk.
+type chunkSelection struct {
+	path  []pathSegment
+	items bool // the chunked array, replaced by the chunk's elements
+}
+
+// chunker builds and measures chunk documents.
+type chunker struct {
+	root       Value
+	opts       *ChunkOptions
+	maxTokens  int
+	selections []chunkSelection
+}
+
+// next returns the largest chunk of items starting at start that fits and
+// its number of elements, or 0 when even one element does not fit.
+func (c *chunker) next(items []Value, start int) ([]byte, int, error) {
+	rest := len(items) - start
+	fits := func(n int) (bool, error) {
+		_, tokens, err := c.encode(items[start : start+n])
+		return err == nil && tokens <= c.maxTokens, err
+	}
+
+	// Grow the chunk exponentially, then binary search the last step;
+	// lo elements fit and hi elements do not, unless lo is all of them
+	lo, hi := 0, 1
+	for {
+		ok, err := fits(hi)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			break
+		}
+		lo = hi
+		if hi == rest {
+			break
+		}
+		hi = min(2*hi, rest)
+	}
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		ok, err := fits(mid)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if lo == 0 {
+		return nil, 0, nil
+	}
+
+	doc, _, err := c.encode(items[start : start+lo])
+	return doc, lo, err
+}
+
+// encode encodes the document holding items and returns it with its token
+// count.
+func (c *chunker) encode(items []Value) ([]byte, int, error) {
+	var buf bytes.Buffer
+	if err := Marshal(chunkDocument(c.root, c.selections, items), &buf, c.opts.Encode...); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), c.opts.Tokenizer.Count(buf.String()), nil
+}
+
+// chunkDocument returns the part of v covered by sels, with the chunked
+// array replaced by items. Objects keep their field order.
+func chunkDocument(v Value, sels []chunkSelection, items []Value) Value {
+	keepAll := false
+	for _, s := range sels {
+		if len(s.path) == 0 {
+			if s.items {
+				return items
+			}
+			keepAll = true
+		}
+	}
+
+	// below returns the selections continuing under seg
+	below := func(seg pathSegment) []chunkSelection {
+		var next []chunkSelection
+		for _, s := range sels {
+			if len(s.path) > 0 && s.path[0] == seg {
+				next = append(next, chunkSelection{path: s.path[1:], items: s.items})
+			}
+		}
+		return next
+	}
+
+	if arr, ok := v.([]Value); ok {
+		var result []Value
+		for i, item := range arr {
+			if next := below(pathSegment{index: i, isIndex: true}); len(next) > 0 {
+				result = append(result, chunkDocument(item, next, items))
+			} else if keepAll {
+				result = append(result, item)
+			}
+		}
+		return result
+	}
+
+	keys, values, ok := objectFields(v)
+	if !ok {
+		return v
+	}
+	var kept []string
+	var fields []Value
+	for i, k := range keys {
+		if next := below(pathSegment{key: k}); len(next) > 0 {
+			kept, fields = append(kept, k), append(fields, chunkDocument(values[i], next, items))
+		} else if keepAll {
+			kept, fields = append(kept, k), append(fields, values[i])
+		}
+	}
+
+	// Plain maps keep the encoder's own key order
+	if _, plain := v.(map[string]Value); plain {
+		m := make(map[string]Value, len(kept))
+		for i, k := range kept {
+			m[k] = fields[i]
+		}
+		return m
+	}
+	obj := NewOrderedMap()
+	for i, k := range kept {
+		obj.Set(k, fields[i])
+	}
+	return obj
+}
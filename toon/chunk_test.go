@@ -0,0 +1,139 @@
+package toon
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChunk(t *testing.T) {
+	data := NewOrderedMap()
+	meta := NewOrderedMap()
+	meta.Set("source", "crm")
+	data.Set("metadata", meta)
+	data.Set("note", "not repeated")
+	data.Set("users", budgetUsers(5))
+
+	chunks, err := Chunk(data, "users", 5, WithChunkContext("metadata"), WithChunkTokenizer(lineTokenizer{}))
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	want := []string{
+		"metadata:\n  source: crm\nusers[2]{id,name}:\n  1,ua\n  2,ub",
+		"metadata:\n  source: crm\nusers[2]{id,name}:\n  3,uc\n  4,ud",
+		"metadata:\n  source: crm\nusers[1]{id,name}:\n  5,ue",
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Chunk() returned %d chunks, want %d", len(chunks), len(want))
+	}
+
+	var ids []int64
+	for i, chunk := range chunks {
+		if string(chunk) != want[i] {
+			t.Errorf("chunk %d =\n%s\nwant\n%s", i, chunk, want[i])
+		}
+		var doc map[string]interface{}
+		if err := UnmarshalFromString(string(chunk), &doc); err != nil {
+			t.Fatalf("chunk %d does not decode: %v", i, err)
+		}
+		users, err := GetSlice(doc, "users")
+		if err != nil {
+			t.Fatalf("chunk %d: %v", i, err)
+		}
+		for _, u := range users {
+			id, _ := GetInt64(u, "id")
+			ids = append(ids, id)
+		}
+	}
+	if wantIDs := []int64{1, 2, 3, 4, 5}; !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("chunk ids = %v, want %v", ids, wantIDs)
+	}
+}
+
+func TestChunkNestedPath(t *testing.T) {
+	data := map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{"name": "a", "items": []interface{}{1, 2}},
+			map[string]interface{}{"name": "b", "items": []interface{}{
+				map[string]interface{}{"x": 1}, "two", map[string]interface{}{"x": 3},
+			}},
+		},
+		"title": "report",
+	}
+
+	chunks, err := Chunk(data, "groups[1].items", 6,
+		WithChunkContext("title", "groups[1].name"),
+		WithChunkTokenizer(lineTokenizer{}),
+		WithChunkEncodeOptions(WithIndent(4)))
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	want := []string{
+		"groups[1]:\n    - items[2]:\n        - x: 1\n        - two\n      name: b\ntitle: report",
+		"groups[1]:\n    - items[1]{x}:\n        3\n      name: b\ntitle: report",
+	}
+	got := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		got[i] = string(chunk)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Chunk() =\n%s\nwant\n%s", strings.Join(got, "\n--\n"), strings.Join(want, "\n--\n"))
+	}
+}
+
+func TestChunkEdgeCases(t *testing.T) {
+	// An empty array is a single chunk
+	chunks, err := Chunk(map[string]interface{}{"rows": []interface{}{}}, "rows", 10)
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	if len(chunks) != 1 || string(chunks[0]) != "rows[0]:" {
+		t.Errorf("Chunk() = %q, want one empty array", chunks)
+	}
+
+	// A root array
+	chunks, err = Chunk([]interface{}{1, 2, 3}, "", 1, WithChunkTokenizer(lineTokenizer{}))
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	if len(chunks) != 1 || string(chunks[0]) != "[3]: 1,2,3" {
+		t.Errorf("Chunk() = %q, want one inline array", chunks)
+	}
+}
+
+func TestChunkErrors(t *testing.T) {
+	data := map[string]interface{}{
+		"name":  "x",
+		"users": budgetUsers(3),
+		"posts": []interface{}{map[string]interface{}{"body": []interface{}{1, 2}}},
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		maxTokens int
+		opts      []ChunkOption
+		wantErr   string
+	}{
+		{"not an array", "name", 10, nil, "array"},
+		{"missing path", "missing", 10, nil, "not found"},
+		{"missing context", "users", 10, []ChunkOption{WithChunkContext("meta")}, "not found"},
+		{"no limit", "users", 0, nil, "must be positive"},
+		{"element too large", "posts", 1, []ChunkOption{WithChunkTokenizer(lineTokenizer{})}, "element posts[0] does not fit in 1 tokens"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Chunk(data, tt.path, tt.maxTokens, tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Chunk() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	_, err := Chunk(data, "missing", 10)
+	if !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("Chunk() error = %v, want ErrPathNotFound", err)
+	}
+}
@@ -0,0 +1,179 @@
+package toon
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CompareOptions configures Equal and Diff.
+type CompareOptions struct {
+	// FieldOrder makes the order of object fields significant (default: false).
+	// Array order is always significant.
+	FieldOrder bool
+}
+
+// CompareOption is a functional option for configuring Equal and Diff.
+type CompareOption func(*CompareOptions)
+
+// WithFieldOrder makes object field order significant when comparing.
+func WithFieldOrder(enabled bool) CompareOption {
+	return func(o *CompareOptions) {
+		o.FieldOrder = enabled
+	}
+}
+
+// applyCompareOptions applies functional options to create CompareOptions.
+func applyCompareOptions(opts ...CompareOption) *CompareOptions {
+	cmpOpts := &CompareOptions{}
+	for _, opt := range opts {
+		opt(cmpOpts)
+	}
+	return cmpOpts
+}
+
+// Equal reports whether a and b hold the same TOON value.
+//
+// Unlike reflect.DeepEqual, numbers compare by value (int64(1) equals
+// float64(1)), OrderedMap and plain maps compare by their fields, and
+// values are compared regardless of how they were represented in TOON
+// (tabular or list arrays). Values that cannot be converted with NewNode
+// are never equal.
+//
+// Example:
+//
+//	toon.Equal(map[string]interface{}{"n": 1}, map[string]Value{"n": 1.0}) // true
+func Equal(a, b Value, opts ...CompareOption) bool {
+	na, err := NewNode(a)
+	if err != nil {
+		return false
+	}
+	nb, err := NewNode(b)
+	if err != nil {
+		return false
+	}
+	return nodesEqual(na, nb, applyCompareOptions(opts...))
+}
+
+// nodesEqual compares two nodes.
+func nodesEqual(a, b *Node, opts *CompareOptions) bool {
+	if isNumberKind(a.Kind()) && isNumberKind(b.Kind()) {
+		return numbersEqual(a, b)
+	}
+	if a.Kind() != b.Kind() {
+		return false
+	}
+
+	switch a.Kind() {
+	case KindBool:
+		return a.b == b.b
+	case KindString:
+		return a.s == b.s
+	case KindArray:
+		if len(a.items) != len(b.items) {
+			return false
+		}
+		for i := range a.items {
+			if !nodesEqual(a.items[i], b.items[i], opts) {
+				return false
+			}
+		}
+		return true
+	case KindObject:
+		if len(a.fields) != len(b.fields) {
+			return false
+		}
+		for i, f := range a.fields {
+			other := b.Get(f.Key)
+			if other == nil || !nodesEqual(f.Value, other, opts) {
+				return false
+			}
+			if opts.FieldOrder && b.fields[i].Key != f.Key {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// isNumberKind reports whether k is KindInt or KindFloat.
+func isNumberKind(k Kind) bool {
+	return k == KindInt || k == KindFloat
+}
+
+// numbersEqual compares two numeric nodes by value, exactly for integers.
+func numbersEqual(a, b *Node) bool {
+	if a.Kind() == KindInt && b.Kind() == KindInt {
+		return a.i == b.i
+	}
+	if a.Kind() == KindInt {
+		a, b = b, a
+	}
+	if b.Kind() == KindInt {
+		i, ok := a.IntValue()
+		return ok && i == b.i
+	}
+	return a.f == b.f
+}
+
+// nodeKey returns a string that is equal for nodes that compare equal.
+func nodeKey(n *Node, opts *CompareOptions) string {
+	var b strings.Builder
+	writeNodeKey(&b, n, opts)
+	return b.String()
+}
+
+// writeNodeKey writes the comparison key of n to b.
+func writeNodeKey(b *strings.Builder, n *Node, opts *CompareOptions) {
+	switch n.Kind() {
+	case KindNull:
+		b.WriteString(nullLiteral)
+	case KindBool:
+		b.WriteString(strconv.FormatBool(n.b))
+	case KindInt:
+		b.WriteString(strconv.FormatInt(n.i, 10))
+	case KindFloat:
+		if i, ok := n.IntValue(); ok {
+			b.WriteString(strconv.FormatInt(i, 10))
+		} else if math.IsNaN(n.f) {
+			b.WriteString("NaN")
+		} else {
+			b.WriteString(strconv.FormatFloat(n.f, 'g', -1, 64))
+		}
+	case KindString:
+		b.WriteString(strconv.Quote(n.s))
+	case KindArray:
+		b.WriteString(openBracket)
+		for i, item := range n.items {
+			if i > 0 {
+				b.WriteString(comma)
+			}
+			writeNodeKey(b, item, opts)
+		}
+		b.WriteString(closeBracket)
+	case KindObject:
+		fields := n.fields
+		if !opts.FieldOrder {
+			fields = append([]NodeField(nil), fields...)
+			sortFields(fields)
+		}
+		b.WriteString(openBrace)
+		for i, f := range fields {
+			if i > 0 {
+				b.WriteString(comma)
+			}
+			b.WriteString(strconv.Quote(f.Key))
+			b.WriteString(colon)
+			writeNodeKey(b, f.Value, opts)
+		}
+		b.WriteString(closeBrace)
+	}
+}
+
+// sortFields sorts fields by key.
+func sortFields(fields []NodeField) {
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+}
@@ -0,0 +1,73 @@
+package toon
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	om := NewOrderedMap()
+	om.Set("b", int64(2))
+	om.Set("a", 1.0)
+
+	tests := []struct {
+		name string
+		a, b Value
+		want bool
+	}{
+		{"int and float", int64(1), float64(1), true},
+		{"int and fraction", int64(1), 1.5, false},
+		{"int sizes", int8(3), uint32(3), true},
+		{"string and number", "1", int64(1), false},
+		{"null", nil, nil, true},
+		{"null and false", nil, false, false},
+		{"ordered map and map", om, map[string]interface{}{"a": 1, "b": 2}, true},
+		{"missing field", map[string]Value{"a": 1}, map[string]Value{"a": 1, "b": nil}, false},
+		{"array order", []Value{1, 2}, []Value{2, 1}, false},
+		{"array length", []Value{1, 2}, []Value{1, 2, 3}, false},
+		{"nested", map[string]Value{"x": []Value{map[string]Value{"y": 1}}}, map[string]Value{"x": []interface{}{map[string]interface{}{"y": 1.0}}}, true},
+		{"unsupported", make(chan int), make(chan int), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+			if got := Equal(tt.b, tt.a); got != tt.want {
+				t.Errorf("Equal() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualFieldOrder(t *testing.T) {
+	var a, b Node
+	if err := UnmarshalFromString("x: 1\ny: 2", &a); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := UnmarshalFromString("y: 2\nx: 1", &b); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !Equal(&a, &b) {
+		t.Error("Equal() = false, want true without field order")
+	}
+	if Equal(&a, &b, WithFieldOrder(true)) {
+		t.Error("Equal(WithFieldOrder) = true, want false")
+	}
+	if !Equal(&a, &a, WithFieldOrder(true)) {
+		t.Error("Equal(WithFieldOrder) on same value = false, want true")
+	}
+}
+
+func TestEqualTabularAndList(t *testing.T) {
+	var tabular, list interface{}
+	if err := UnmarshalFromString("users[2]{id,name}:\n  1,Ada\n  2,Bob", &tabular); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := UnmarshalFromString("users[2]:\n  - id: 1\n    name: Ada\n  - name: Bob\n    id: 2.0", &list); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !Equal(tabular, list) {
+		t.Error("Equal() = false, want true for tabular and list forms")
+	}
+}
@@ -0,0 +1,253 @@
+package toon
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// CSVOptions configures FromCSV and ToCSV.
+type CSVOptions struct {
+	// Comma is the field delimiter (default: ',').
+	Comma rune
+
+	// NullText is the cell text that stands for null (default: "", empty cells).
+	NullText string
+
+	// InferTypes converts columns of numbers or booleans (default: true).
+	// When false every cell other than NullText is a string.
+	InferTypes bool
+}
+
+// CSVOption is a functional option for configuring FromCSV and ToCSV.
+type CSVOption func(*CSVOptions)
+
+// WithCSVComma sets the CSV field delimiter (default: ',').
+func WithCSVComma(comma rune) CSVOption {
+	return func(o *CSVOptions) {
+		o.Comma = comma
+	}
+}
+
+// WithCSVNullText sets the cell text that stands for null (default: "").
+func WithCSVNullText(text string) CSVOption {
+	return func(o *CSVOptions) {
+		o.NullText = text
+	}
+}
+
+// WithCSVInferTypes enables per-column type inference in FromCSV (default: true).
+func WithCSVInferTypes(enabled bool) CSVOption {
+	return func(o *CSVOptions) {
+		o.InferTypes = enabled
+	}
+}
+
+// applyCSVOptions applies functional options to create CSVOptions.
+func applyCSVOptions(opts ...CSVOption) *CSVOptions {
+	csvOpts := &CSVOptions{Comma: ',', InferTypes: true}
+	for _, opt := range opts {
+		opt(csvOpts)
+	}
+	return csvOpts
+}
+
+// FromCSV reads CSV with a header row from r and returns its records as an
+// array of objects, which Marshal encodes as a tabular array.
+//
+// The result is the array itself when key is empty, otherwise an *OrderedMap
+// holding the array under key. Rows keep the header's column order.
+//
+// Types are inferred per column with the rules of TOON primitives: a column
+// whose cells are all numbers becomes numbers, a column of true/false becomes
+// booleans, and any other column keeps every cell as a string, so a zip code
+// column with "02134" and "10001" stays strings. Cells equal to NullText and
+// "null" cells are null in every column.
+//
+// Example:
+//
+//	v, err := toon.FromCSV(file, "users")
+//	out, err := toon.MarshalToString(v)
+//	// users[2]{id,name}:
+//	//   1,Ada
+//	//   2,Bob
+func FromCSV(r io.Reader, key string, opts ...CSVOption) (Value, error) {
+	csvOpts := applyCSVOptions(opts...)
+
+	reader := csv.NewReader(r)
+	reader.Comma = csvOpts.Comma
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, csvDecodeError(err)
+	}
+	if len(records) == 0 {
+		return nil, &DecodeError{Message: "invalid CSV: missing header row"}
+	}
+
+	header, records := records[0], records[1:]
+	seen := make(map[string]bool, len(header))
+	for _, h := range header {
+		if seen[h] {
+			return nil, &DecodeError{Message: fmt.Sprintf("invalid CSV: duplicate column %q", h), Line: 1}
+		}
+		seen[h] = true
+	}
+
+	columns := make([][]Value, len(header))
+	for col := range header {
+		columns[col] = csvColumn(records, col, csvOpts)
+	}
+
+	rows := make([]Value, len(records))
+	for i := range records {
+		row := NewOrderedMap()
+		for col, h := range header {
+			row.Set(h, columns[col][i])
+		}
+		rows[i] = row
+	}
+
+	if key == "" {
+		return rows, nil
+	}
+	result := NewOrderedMap()
+	result.Set(key, rows)
+	return result, nil
+}
+
+// csvColumn converts the cells of one column, inferring a single type for
+// the whole column.
+func csvColumn(records [][]string, col int, opts *CSVOptions) []Value {
+	values := make([]Value, len(records))
+	numbers, bools := opts.InferTypes, opts.InferTypes
+
+	for i, record := range records {
+		cell := record[col]
+		if cell == opts.NullText {
+			continue
+		}
+		v, err := parseValue(cell)
+		if err != nil {
+			v = cell
+		}
+		switch val := v.(type) {
+		case nil:
+			if !opts.InferTypes {
+				v = cell
+			}
+		case int64:
+			bools = false
+		case float64:
+			// parseValue accepts NaN and Inf, which TOON cannot represent
+			if math.IsNaN(val) || math.IsInf(val, 0) {
+				numbers = false
+			}
+			bools = false
+		case bool:
+			numbers = false
+		default:
+			numbers, bools = false, false
+		}
+		values[i] = v
+	}
+
+	if numbers || bools {
+		return values
+	}
+
+	// Mixed column: keep the cell text
+	for i, record := range records {
+		if values[i] != nil {
+			values[i] = record[col]
+		}
+	}
+	return values
+}
+
+// csvDecodeError converts an encoding/csv error into a DecodeError.
+func csvDecodeError(err error) error {
+	decErr := &DecodeError{Message: fmt.Sprintf("invalid CSV: %v", err), Cause: err}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		decErr.Message = fmt.Sprintf("invalid CSV: %v", parseErr.Err)
+		decErr.Line = parseErr.Line
+		decErr.Column = parseErr.Column
+	}
+	return decErr
+}
+
+// ToCSV writes the tabular array at path inside doc to w as CSV with a
+// header row.
+//
+// doc is anything Get accepts. Columns follow the field order of the first
+// object (sorted for plain maps, source order for a *Document or *Node).
+// Nulls are written as NullText and numbers as Marshal writes them; cells
+// containing the delimiter, quotes or newlines are quoted by encoding/csv.
+// The array must be tabular: objects with the same keys and primitive
+// values only.
+//
+// Example:
+//
+//	doc, err := toon.ParseTreeFromString(input)
+//	err = toon.ToCSV(os.Stdout, doc, "report.rows")
+func ToCSV(w io.Writer, doc Value, path string, opts ...CSVOption) error {
+	csvOpts := applyCSVOptions(opts...)
+
+	if d, ok := doc.(*Document); ok {
+		doc = d.Node()
+	}
+	val, err := Get(doc, path)
+	if err != nil {
+		return err
+	}
+
+	items, ok := normalize(val).([]Value)
+	if !ok {
+		return &EncodeError{Message: fmt.Sprintf("value at %q is not an array", path), Value: val}
+	}
+	format := detectArrayFormat(items)
+	if format == arrayFormatEmpty {
+		return nil
+	}
+	if format != arrayFormatTabular {
+		return &EncodeError{Message: fmt.Sprintf("array at %q is not tabular", path), Value: val}
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = csvOpts.Comma
+
+	keys := tabularKeys(items[0])
+	if err := writer.Write(keys); err != nil {
+		return err
+	}
+	record := make([]string, len(keys))
+	for _, item := range items {
+		for i, k := range keys {
+			cell, err := csvCell(tabularCell(item, k), csvOpts)
+			if err != nil {
+				return err
+			}
+			record[i] = cell
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvCell formats a primitive as a CSV cell.
+func csvCell(v Value, opts *CSVOptions) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return opts.NullText, nil
+	case string:
+		return val, nil
+	default:
+		return encodePrimitive(val, string(opts.Comma))
+	}
+}
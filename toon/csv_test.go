@@ -0,0 +1,166 @@
+package toon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFromCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		key   string
+		opts  []CSVOption
+		want  string
+	}{
+		{
+			name:  "column types",
+			input: "id,name,score,active,zip\n1,Ada,9.5,true,02134\n2,\"Bob, Jr.\",10,false,10001\n",
+			key:   "users",
+			want:  "users[2]{id,name,score,active,zip}:\n  1,Ada,9.5,true,\"02134\"\n  2,\"Bob, Jr.\",10,false,\"10001\"",
+		},
+		{
+			name:  "nulls",
+			input: "a,b\n,x\n2,null\n",
+			key:   "rows",
+			want:  "rows[2]{a,b}:\n  null,x\n  2,null",
+		},
+		{
+			name:  "null text",
+			input: "a,b\nNA,\n3,y\n",
+			key:   "rows",
+			opts:  []CSVOption{WithCSVNullText("NA")},
+			want:  "rows[2]{a,b}:\n  null,\"\"\n  3,y",
+		},
+		{
+			name:  "no inference",
+			input: "a;b\n1;true\n",
+			opts:  []CSVOption{WithCSVComma(';'), WithCSVInferTypes(false)},
+			want:  "[1]{a,b}:\n  \"1\",\"true\"",
+		},
+		{
+			name:  "mixed column keeps text",
+			input: "v\n1\nNaN\n1.50\n",
+			key:   "v",
+			want:  "v[3]{v}:\n  \"1\"\n  \"NaN\"\n  \"1.50\"",
+		},
+		{
+			name:  "header only",
+			input: "a,b\n",
+			key:   "rows",
+			want:  "rows[0]:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := FromCSV(strings.NewReader(tt.input), tt.key, tt.opts...)
+			if err != nil {
+				t.Fatalf("FromCSV() error = %v", err)
+			}
+			got, err := MarshalToString(v)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FromCSV() encodes as\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromCSVErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{"empty", "", 0},
+		{"duplicate column", "a,a\n1,2\n", 1},
+		{"ragged row", "a,b\n1,2\n3\n", 3},
+		{"bare quote", "a\nx\"y\n", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromCSV(strings.NewReader(tt.input), "rows")
+			var decErr *DecodeError
+			if !errors.As(err, &decErr) {
+				t.Fatalf("FromCSV() error = %v, want DecodeError", err)
+			}
+			if decErr.Line != tt.line {
+				t.Errorf("FromCSV() error line = %d, want %d", decErr.Line, tt.line)
+			}
+		})
+	}
+}
+
+func TestToCSV(t *testing.T) {
+	input := "report:\n  rows[3]{name,note,n}:\n    Ada,\"a,b\",1.5\n    \"Bob \\\"B\\\"\",null,2\n    \"\",\"line\\nbreak\",true\n  tags[2]: a,b"
+	doc, err := ParseTreeFromString(input)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+
+	var out strings.Builder
+	if err := ToCSV(&out, doc, "report.rows"); err != nil {
+		t.Fatalf("ToCSV() error = %v", err)
+	}
+	want := "name,note,n\nAda,\"a,b\",1.5\n\"Bob \"\"B\"\"\",,2\n,\"line\nbreak\",true\n"
+	if out.String() != want {
+		t.Errorf("ToCSV() =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	// Plain maps sort their columns; options apply
+	rows := []interface{}{
+		map[string]interface{}{"b": nil, "a": 1},
+		map[string]interface{}{"b": "x;y", "a": 2},
+	}
+	out.Reset()
+	if err := ToCSV(&out, rows, "", WithCSVComma(';'), WithCSVNullText("NULL")); err != nil {
+		t.Fatalf("ToCSV() error = %v", err)
+	}
+	if want := "a;b\n1;NULL\n2;\"x;y\"\n"; out.String() != want {
+		t.Errorf("ToCSV() =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	// Empty arrays write nothing
+	out.Reset()
+	if err := ToCSV(&out, map[string]interface{}{"rows": []interface{}{}}, "rows"); err != nil || out.Len() != 0 {
+		t.Errorf("ToCSV(empty) = %q, %v", out.String(), err)
+	}
+}
+
+func TestToCSVErrors(t *testing.T) {
+	doc := map[string]interface{}{
+		"n":     1,
+		"list":  []interface{}{map[string]interface{}{"a": 1}, map[string]interface{}{"b": 2}},
+		"plain": []interface{}{1, 2},
+	}
+
+	for _, path := range []string{"n", "list", "plain"} {
+		var encErr *EncodeError
+		if err := ToCSV(&strings.Builder{}, doc, path); !errors.As(err, &encErr) {
+			t.Errorf("ToCSV(%q) error = %v, want EncodeError", path, err)
+		}
+	}
+	if err := ToCSV(&strings.Builder{}, doc, "missing"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("ToCSV(missing) error = %v, want ErrPathNotFound", err)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	input := "id,name,price,note\n1,Widget,9.99,\n2,\"Gadget, large\",12,\"say \"\"hi\"\"\"\n"
+	v, err := FromCSV(strings.NewReader(input), "items")
+	if err != nil {
+		t.Fatalf("FromCSV() error = %v", err)
+	}
+	var out strings.Builder
+	if err := ToCSV(&out, v, "items"); err != nil {
+		t.Fatalf("ToCSV() error = %v", err)
+	}
+	if out.String() != input {
+		t.Errorf("round trip =\n%s\nwant\n%s", out.String(), input)
+	}
+}
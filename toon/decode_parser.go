@@ -11,6 +11,7 @@ type lineInfo struct {
 	content    string
 	indent     int
 	lineNumber int
+	offset     int
 	original   string
 	isBlank    bool
 }
@@ -36,6 +37,7 @@ func newStructuralParser(input string, opts *DecodeOptions) *structuralParser {
 func preprocessLines(input string) []lineInfo {
 	rawLines := strings.Split(input, "\n")
 	lines := make([]lineInfo, 0, len(rawLines))
+	offset := 0
 
 	for i, line := range rawLines {
 		indent := calculateIndent(line)
@@ -46,9 +48,11 @@ func preprocessLines(input string) []lineInfo {
 			content:    content,
 			indent:     indent,
 			lineNumber: i + 1,
+			offset:     offset,
 			original:   line,
 			isBlank:    isBlank,
 		})
+		offset += len(line) + 1
 	}
 
 	// Remove trailing blank lines
@@ -109,7 +113,13 @@ func (sp *structuralParser) parse() (Value, error) {
 
 // validateIndentation checks indentation rules in strict mode.
 func (sp *structuralParser) validateIndentation() error {
-	for _, line := range sp.lines {
+	return validateLineIndentation(sp.lines, sp.opts.IndentSize)
+}
+
+// validateLineIndentation rejects tab indentation and indents that are not a
+// multiple of indentSize.
+func validateLineIndentation(lines []lineInfo, indentSize int) error {
+	for _, line := range lines {
 		if line.isBlank {
 			continue
 		}
@@ -129,7 +139,7 @@ func (sp *structuralParser) validateIndentation() error {
 		}
 
 		// Check if indent is multiple of indent_size
-		if line.indent > 0 && line.indent%sp.opts.IndentSize != 0 {
+		if line.indent > 0 && line.indent%indentSize != 0 {
 			return &DecodeError{
 				Message: "indentation must be multiple of indent size (strict mode)",
 				Line:    line.lineNumber,
@@ -142,24 +152,29 @@ func (sp *structuralParser) validateIndentation() error {
 
 // detectRootType determines the type of the root value.
 func (sp *structuralParser) detectRootType() (rootType, error) {
-	if len(sp.lines) == 0 {
-		return rootTypeObject, nil
+	return detectLinesRootType(sp.lines), nil
+}
+
+// detectLinesRootType determines the type of the root value of preprocessed lines.
+func detectLinesRootType(lines []lineInfo) rootType {
+	if len(lines) == 0 {
+		return rootTypeObject
 	}
 
-	firstLine := sp.lines[0]
+	firstLine := lines[0]
 
 	// Check for root array patterns
 	if strings.HasPrefix(firstLine.content, "[") {
-		return rootTypeArray, nil
+		return rootTypeArray
 	}
 
 	// Check if single line (primitive or object)
-	if len(sp.lines) == 1 {
-		return detectSingleLineType(firstLine.content), nil
+	if len(lines) == 1 {
+		return detectSingleLineType(firstLine.content)
 	}
 
 	// Multiple lines = object
-	return rootTypeObject, nil
+	return rootTypeObject
 }
 
 // detectSingleLineType determines if a single line is a primitive or object.
@@ -516,6 +531,13 @@ func getValueCategory(v Value) valueCategory {
 	return categoryPrimitive
 }
 
+// IsExpandablePath reports whether WithExpandPaths("safe") splits the
+// unquoted key into nested keys: the key contains a dot and every segment
+// between dots is an identifier. Quoted keys are never expanded.
+func IsExpandablePath(key string) bool {
+	return strings.Contains(key, ".") && isExpandablePath(key)
+}
+
 // isExpandablePath checks if a dotted path can be safely expanded.
 // Returns false if any segment would need quoting (contains special chars, hyphens, etc.)
 func isExpandablePath(path string) bool {
@@ -1054,6 +1076,12 @@ func (sp *structuralParser) splitRowByDelimiter(content string, delimiter string
 // hasUnquotedColon checks if a line contains an unquoted colon,
 // which typically indicates an object field (key: value) rather than data.
 func (sp *structuralParser) hasUnquotedColon(content string) bool {
+	return hasUnquotedColon(content)
+}
+
+// hasUnquotedColon reports whether content contains a colon outside quotes
+// that is followed by a space or the end of the line.
+func hasUnquotedColon(content string) bool {
 	inQuotes := false
 	escaped := false
 
@@ -1176,7 +1204,8 @@ func (sp *structuralParser) parseListArrayItem(line lineInfo, _ int, delimiter s
 
 	// Nested array
 	if strings.HasPrefix(content, "[") {
-		value, err := sp.parseArrayFromLine(line, line.indent)
+		// Rows belong to the item only while indented past its hyphen
+		value, err := sp.parseArrayFromLine(line, line.indent+1)
 		if err != nil {
 			return nil, err
 		}
@@ -1240,8 +1269,8 @@ func shouldStopCollectingLines(nextLine lineInfo, itemIndent int, itemLines []li
 		return true
 	}
 
-	// Same indent with hyphen = new sibling item
-	if nextLine.indent == itemIndent && strings.HasPrefix(nextLine.content, "-") {
+	// Same indent = new sibling item, or a field of the enclosing object
+	if nextLine.indent == itemIndent {
 		return true
 	}
 
@@ -1516,22 +1545,34 @@ func (sp *structuralParser) parseFirstLineOfListItem(content string, lines []lin
 	return key, 1
 }
 
-// parseNestedUnderFirstKey parses all nested lines under the first key.
+// parseNestedUnderFirstKey parses the object nested under the first key.
+// Its fields sit two levels below the hyphen; lines one level below are
+// sibling fields of the list item.
 func (sp *structuralParser) parseNestedUnderFirstKey(firstKey string, lines []lineInfo, result map[string]Value) (Value, error) {
-	nestedLines := lines[1:]
-	if len(nestedLines) == 0 {
-		return result, nil
+	siblingIndent := lines[0].indent + sp.opts.IndentSize
+	end := 1
+	for end < len(lines) && (lines[end].isBlank || lines[end].indent > siblingIndent) {
+		end++
 	}
 
-	tempSP := newStructuralParser("", sp.opts)
-	tempSP.lines = nestedLines
-	tempSP.pos = 0
+	nestedLines := lines[1:end]
+	if len(nestedLines) == 0 {
+		result[firstKey] = map[string]Value{}
+	} else {
+		tempSP := newStructuralParser("", sp.opts)
+		tempSP.lines = nestedLines
+		tempSP.pos = 0
 
-	nestedObj, err := tempSP.parseObject(nestedLines[0].indent, 0)
-	if err != nil {
+		nestedObj, err := tempSP.parseObject(nestedLines[0].indent, 0)
+		if err != nil {
+			return nil, err
+		}
+		result[firstKey] = nestedObj
+	}
+
+	if err := sp.parseRemainingListItemLines(lines, end, lines[0].indent, firstKey, result); err != nil {
 		return nil, err
 	}
-	result[firstKey] = nestedObj
 	return result, nil
 }
 
@@ -0,0 +1,354 @@
+package toon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	rawValueType        = reflect.TypeOf(RawValue(nil))
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// decodeRaw captures input into a *RawValue, *map[string]RawValue or struct
+// target without parsing the raw values. Strict mode checks indentation and
+// the top-level structure first.
+func decodeRaw(input string, opts *DecodeOptions, v interface{}) error {
+	if opts.Strict {
+		if err := validateRaw(input, opts); err != nil {
+			return err
+		}
+	}
+
+	if target, ok := v.(*RawValue); ok {
+		*target = RawValue(input)
+		return nil
+	}
+
+	// Structs without RawValue fields keep no text, so the whole document is
+	// decoded with the caller's options
+	rv := reflect.ValueOf(v).Elem()
+	if rv.Kind() == reflect.Struct && !hasRawFields(rv.Type(), map[reflect.Type]bool{}) {
+		return assignDecoded(input, opts, rv)
+	}
+
+	// Fields are split by their source keys, so dotted keys are not expanded
+	if opts.ExpandPaths == "safe" {
+		return &DecodeError{Message: "path expansion is not supported for RawValue fields"}
+	}
+
+	if target, ok := v.(*map[string]RawValue); ok {
+		fields, err := splitRawFields(input)
+		if err != nil {
+			return err
+		}
+		*target = fields
+		return nil
+	}
+	return decodeRawStruct(input, opts, rv)
+}
+
+// isRawTarget reports whether v is a target handled by decodeRaw.
+func isRawTarget(v interface{}) bool {
+	switch v.(type) {
+	case *RawValue, *map[string]RawValue:
+		return true
+	case *Node:
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct
+}
+
+// hasRawFields reports whether struct t has a RawValue field, directly or in
+// a nested struct that decodeRawStruct splits.
+func hasRawFields(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] || implements(t, jsonUnmarshalerType) {
+		return false
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		if ft == rawValueType {
+			return true
+		}
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && hasRawFields(ft, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// implements reports whether t or *t implements iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// validateRaw checks the indentation of input and, for a root object, that
+// every top-level line is a field.
+func validateRaw(input string, opts *DecodeOptions) error {
+	lines := preprocessLines(input)
+	if err := validateLineIndentation(lines, opts.IndentSize); err != nil {
+		return err
+	}
+	if detectLinesRootType(skipLeadingBlankLines(lines)) != rootTypeObject {
+		return nil
+	}
+	_, err := splitRawFields(input)
+	return err
+}
+
+// decodeRawStruct assigns the top-level fields of input to the fields of
+// struct rv, matched by their json tags. RawValue fields keep the field's
+// text, nested structs are split the same way, and other fields are decoded
+// and assigned through encoding/json.
+func decodeRawStruct(input string, opts *DecodeOptions, rv reflect.Value) error {
+	fields, err := splitRawFields(input)
+	if err != nil {
+		return err
+	}
+
+	for key, raw := range fields {
+		field, ok := rawStructField(rv, key)
+		if !ok {
+			continue
+		}
+		if err := assignRawField(field, raw, opts); err != nil {
+			return &DecodeError{Message: fmt.Sprintf("cannot decode field %q", key), Cause: err}
+		}
+	}
+	return nil
+}
+
+// assignRawField stores the text of a field into target.
+func assignRawField(target reflect.Value, raw RawValue, opts *DecodeOptions) error {
+	if target.Type() == rawValueType {
+		target.SetBytes(raw)
+		return nil
+	}
+
+	t := target.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	lines := skipLeadingBlankLines(preprocessLines(string(raw)))
+	if t.Kind() == reflect.Struct && !implements(t, jsonUnmarshalerType) && detectLinesRootType(lines) == rootTypeObject {
+		if target.Kind() == reflect.Pointer {
+			if target.IsNil() {
+				target.Set(reflect.New(t))
+			}
+			target = target.Elem()
+		}
+		return decodeRawStruct(string(raw), opts, target)
+	}
+	return assignDecoded(string(raw), opts, target)
+}
+
+// assignDecoded decodes input and assigns the result to target through
+// encoding/json.
+func assignDecoded(input string, opts *DecodeOptions, target reflect.Value) error {
+	value, err := decode(input, opts)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target.Addr().Interface())
+}
+
+// rawStructField returns the settable field of struct rv named key by its
+// json tag or, failing an exact match, its name in any case, as
+// encoding/json does. Fields of untagged embedded structs are included.
+func rawStructField(rv reflect.Value, key string) (reflect.Value, bool) {
+	var fold []int
+	index, ok := findRawStructField(rv.Type(), key, nil, &fold)
+	if !ok {
+		if fold == nil {
+			return reflect.Value{}, false
+		}
+		index = fold
+	}
+
+	for i, n := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(n)
+	}
+	return rv, true
+}
+
+// findRawStructField returns the index path of the field of t named key,
+// recording the first case-insensitive match in fold.
+func findRawStructField(t reflect.Type, key string, prefix []int, fold *[]int) ([]int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		index := append(append([]int(nil), prefix...), i)
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				if !f.IsExported() {
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if found, ok := findRawStructField(ft, key, index, fold); ok {
+					return found, true
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		if name == key {
+			return index, true
+		}
+		if *fold == nil && strings.EqualFold(name, key) {
+			*fold = index
+		}
+	}
+	return nil, false
+}
+
+// splitRawFields splits a root object into its top-level fields.
+// Only field keys and indentation are inspected; values are kept as text.
+func splitRawFields(input string) (map[string]RawValue, error) {
+	lines := preprocessLines(input)
+	result := make(map[string]RawValue)
+
+	if detectLinesRootType(skipLeadingBlankLines(lines)) != rootTypeObject {
+		return nil, &DecodeError{Message: "cannot assign non-map to map target"}
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if line.isBlank {
+			i++
+			continue
+		}
+		if line.indent > 0 {
+			return nil, &DecodeError{Message: "unexpected indentation", Line: line.lineNumber, Context: line.original}
+		}
+
+		// Collect the indented lines belonging to this field
+		end := i + 1
+		for end < len(lines) && (lines[end].isBlank || lines[end].indent > 0) {
+			end++
+		}
+		children := lines[i+1 : end]
+		for len(children) > 0 && children[len(children)-1].isBlank {
+			children = children[:len(children)-1]
+		}
+
+		key, raw, err := splitRawField(line, children)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = raw
+		i = end
+	}
+
+	return result, nil
+}
+
+// splitRawField returns the key of a top-level field line and the field's
+// value as a standalone document.
+func splitRawField(line lineInfo, children []lineInfo) (string, RawValue, error) {
+	key, rest, err := splitRawFieldKey(line.content)
+	if err != nil {
+		return "", nil, &DecodeError{Message: err.Error(), Line: line.lineNumber, Context: line.original}
+	}
+
+	// Arrays keep their header and rows as a root array
+	if strings.HasPrefix(rest, openBracket) {
+		parts := []string{rest}
+		for _, child := range children {
+			parts = append(parts, child.original)
+		}
+		return key, RawValue(strings.Join(parts, newline)), nil
+	}
+
+	if !strings.HasPrefix(rest, colon) {
+		return "", nil, &DecodeError{Message: "missing colon after key", Line: line.lineNumber, Context: line.original}
+	}
+
+	if value := strings.TrimSpace(rest[1:]); value != "" {
+		if len(children) > 0 {
+			return "", nil, &DecodeError{Message: "unexpected indentation", Line: children[0].lineNumber, Context: children[0].original}
+		}
+		return key, RawValue(value), nil
+	}
+
+	// Nested objects are dedented to column 1
+	parts := make([]string, 0, len(children))
+	for _, child := range children {
+		if child.isBlank {
+			parts = append(parts, "")
+			continue
+		}
+		dedent := children[0].indent
+		if child.indent < dedent {
+			dedent = child.indent
+		}
+		parts = append(parts, child.original[dedent:])
+	}
+	return key, RawValue(strings.Join(parts, newline)), nil
+}
+
+// splitRawFieldKey splits a field line into its decoded key and the text
+// starting at the array bracket or colon.
+func splitRawFieldKey(content string) (string, string, error) {
+	if !strings.HasPrefix(content, doubleQuote) {
+		end := strings.IndexAny(content, openBracket+colon)
+		if end < 0 {
+			return "", "", errors.New("missing colon after key")
+		}
+		return strings.TrimSpace(content[:end]), content[end:], nil
+	}
+
+	for i := 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '"':
+			key, err := validateAndUnescape(content[1:i])
+			if err != nil {
+				return "", "", err
+			}
+			return key, strings.TrimLeft(content[i+1:], space), nil
+		}
+	}
+	return "", "", errors.New("unterminated quoted key")
+}
+
+// skipLeadingBlankLines returns lines without leading blank lines.
+func skipLeadingBlankLines(lines []lineInfo) []lineInfo {
+	for len(lines) > 0 && lines[0].isBlank {
+		lines = lines[1:]
+	}
+	return lines
+}
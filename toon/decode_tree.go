@@ -0,0 +1,564 @@
+package toon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// treeParser builds a Document from preprocessed lines.
+type treeParser struct {
+	lines []lineInfo
+	pos   int
+	opts  *DecodeOptions
+	unit  int
+}
+
+// parseDocument parses input into a Document.
+func parseDocument(input string, opts *DecodeOptions) (*Document, error) {
+	opts = getDecodeOptions(opts)
+	tp := &treeParser{
+		lines: preprocessLines(input),
+		opts:  opts,
+		unit:  opts.IndentSize,
+	}
+
+	if opts.Strict {
+		if err := validateLineIndentation(tp.lines, opts.IndentSize); err != nil {
+			return nil, err
+		}
+	} else {
+		tp.unit = detectIndentUnit(tp.lines, opts.IndentSize)
+	}
+
+	root, err := tp.parseRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{Root: root, source: input, opts: opts, indent: tp.unit}, nil
+}
+
+// detectIndentUnit returns the indentation of the first indented line,
+// falling back to def when every line is at column 1.
+func detectIndentUnit(lines []lineInfo, def int) int {
+	for _, line := range lines {
+		if !line.isBlank && line.indent > 0 {
+			return line.indent
+		}
+	}
+	return def
+}
+
+// parseRoot parses the root value and rejects trailing content.
+func (tp *treeParser) parseRoot() (SyntaxNode, error) {
+	tp.skipBlank()
+	if tp.pos >= len(tp.lines) {
+		return nil, nil
+	}
+
+	first := tp.lines[tp.pos]
+	var root SyntaxNode
+	var err error
+
+	switch {
+	case strings.HasPrefix(first.content, openBracket):
+		root, err = tp.parseArray(first, contentStart(first), first.indent)
+	case tp.nonBlankCount() == 1 && detectSingleLineType(first.content) == rootTypePrimitive:
+		root, err = newScalarNode(first, contentStart(first), contentEnd(first))
+		tp.pos++
+	default:
+		root, err = tp.parseObject(first.indent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tp.skipBlank()
+	if tp.pos < len(tp.lines) {
+		return nil, lineError(tp.lines[tp.pos], "unexpected content after root value")
+	}
+	return root, nil
+}
+
+// skipBlank advances past blank lines.
+func (tp *treeParser) skipBlank() {
+	for tp.pos < len(tp.lines) && tp.lines[tp.pos].isBlank {
+		tp.pos++
+	}
+}
+
+// nonBlankCount counts the remaining non-blank lines.
+func (tp *treeParser) nonBlankCount() int {
+	count := 0
+	for _, line := range tp.lines[tp.pos:] {
+		if !line.isBlank {
+			count++
+		}
+	}
+	return count
+}
+
+// peekLine returns the next non-blank line without consuming it.
+func (tp *treeParser) peekLine() (lineInfo, bool) {
+	for i := tp.pos; i < len(tp.lines); i++ {
+		if !tp.lines[i].isBlank {
+			return tp.lines[i], true
+		}
+	}
+	return lineInfo{}, false
+}
+
+// parseObject parses consecutive fields indented exactly by indent.
+func (tp *treeParser) parseObject(indent int) (*ObjectNode, error) {
+	obj := &ObjectNode{}
+
+	for tp.pos < len(tp.lines) {
+		line := tp.lines[tp.pos]
+		if line.isBlank {
+			tp.pos++
+			continue
+		}
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, lineError(line, "unexpected indentation")
+		}
+
+		field, err := tp.parseField(line, contentStart(line), line.indent, line.indent)
+		if err != nil {
+			return nil, err
+		}
+		obj.Fields = append(obj.Fields, field)
+	}
+
+	if len(obj.Fields) > 0 {
+		obj.span = Span{Start: obj.Fields[0].span.Start, End: obj.Fields[len(obj.Fields)-1].span.End}
+	}
+	return obj, nil
+}
+
+// parseField parses a field starting at byte start of line. Nested arrays
+// own lines indented deeper than arrayOwner; nested objects own lines
+// indented deeper than objectOwner.
+func (tp *treeParser) parseField(line lineInfo, start, arrayOwner, objectOwner int) (*FieldNode, error) {
+	p := newParser(line.original[start:])
+	key, quoted, err := p.parseKeyWithQuoteInfo()
+	if err != nil {
+		return nil, lineErrorAt(line, start+p.pos, errorMessage(err))
+	}
+	keyEnd := start + p.pos
+
+	field := &FieldNode{
+		Key:     key,
+		RawKey:  line.original[start:keyEnd],
+		Quoted:  quoted,
+		KeySpan: lineSpan(line, start, keyEnd),
+	}
+
+	if p.peek() == '[' {
+		arr, err := tp.parseArray(line, keyEnd, arrayOwner)
+		if err != nil {
+			return nil, err
+		}
+		field.Value = arr
+		field.span = Span{Start: field.KeySpan.Start, End: arr.span.End}
+		return field, nil
+	}
+
+	p.skipWhitespace()
+	if p.peek() != ':' {
+		return nil, lineErrorAt(line, start+p.pos, "expected ':' after key")
+	}
+	colonEnd := start + p.pos + 1
+	valueStart := skipSpaces(line.original, colonEnd)
+	valueEnd := contentEnd(line)
+	tp.pos++
+
+	if valueStart < valueEnd {
+		scalar, err := newScalarNode(line, valueStart, valueEnd)
+		if err != nil {
+			return nil, err
+		}
+		field.Value = scalar
+		field.span = Span{Start: field.KeySpan.Start, End: scalar.span.End}
+		return field, nil
+	}
+
+	obj := &ObjectNode{span: lineSpan(line, colonEnd, colonEnd)}
+	if next, ok := tp.peekLine(); ok && next.indent > objectOwner {
+		obj, err = tp.parseObject(next.indent)
+		if err != nil {
+			return nil, err
+		}
+	}
+	field.Value = obj
+	field.span = Span{Start: field.KeySpan.Start, End: obj.span.End}
+	return field, nil
+}
+
+// parseArray parses an array whose header starts at byte start of line.
+// Rows and list items must be indented deeper than owner.
+func (tp *treeParser) parseArray(line lineInfo, start, owner int) (*ArrayNode, error) {
+	header, headerEnd, err := parseTreeArrayHeader(line, start)
+	if err != nil {
+		return nil, err
+	}
+	tp.pos++
+
+	arr := &ArrayNode{Header: header}
+	valueStart := skipSpaces(line.original, headerEnd)
+	valueEnd := contentEnd(line)
+
+	switch {
+	case header.Fields != nil:
+		if valueStart < valueEnd {
+			return nil, lineErrorAt(line, valueStart, "tabular array rows must start on the next line")
+		}
+		arr.Layout = ArrayTabular
+		err = tp.parseRows(arr, owner)
+	case valueStart < valueEnd:
+		arr.Layout = ArrayInline
+		for _, cell := range splitCells(line, valueStart, valueEnd, header.Delimiter) {
+			scalar, err := newScalarNode(line, cell[0], cell[1])
+			if err != nil {
+				return nil, err
+			}
+			arr.Items = append(arr.Items, scalar)
+		}
+	default:
+		arr.Layout = ArrayList
+		err = tp.parseListItems(arr, owner)
+		if err == nil && len(arr.Items) == 0 {
+			arr.Layout = ArrayInline
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	arr.span = Span{Start: header.span.Start, End: header.span.End}
+	if len(arr.Items) > 0 {
+		arr.span.End = arr.Items[len(arr.Items)-1].Span().End
+	}
+
+	if tp.opts.Strict && len(arr.Items) != header.Length {
+		return nil, &DecodeError{
+			Message: fmt.Sprintf("array length mismatch: expected %d, got %d", header.Length, len(arr.Items)),
+			Line:    line.lineNumber,
+			Context: line.original,
+		}
+	}
+	return arr, nil
+}
+
+// parseRows parses the data rows of a tabular array.
+func (tp *treeParser) parseRows(arr *ArrayNode, owner int) error {
+	isRow := func(line lineInfo) bool {
+		return line.indent > owner && !hasUnquotedColon(line.content)
+	}
+
+	for tp.pos < len(tp.lines) {
+		line := tp.lines[tp.pos]
+		if line.isBlank {
+			if err := tp.checkBlankInArray(line, isRow); err != nil {
+				return err
+			}
+			tp.pos++
+			continue
+		}
+		if !isRow(line) {
+			break
+		}
+
+		row := &RowNode{span: lineSpan(line, contentStart(line), contentEnd(line))}
+		for _, cell := range splitCells(line, contentStart(line), contentEnd(line), arr.Header.Delimiter) {
+			scalar, err := newScalarNode(line, cell[0], cell[1])
+			if err != nil {
+				return err
+			}
+			row.Cells = append(row.Cells, scalar)
+		}
+
+		if tp.opts.Strict && len(row.Cells) != len(arr.Header.Fields) {
+			return &DecodeError{
+				Message: fmt.Sprintf("tabular array row has wrong number of values: expected %d, got %d", len(arr.Header.Fields), len(row.Cells)),
+				Line:    line.lineNumber,
+				Context: line.original,
+			}
+		}
+
+		arr.Items = append(arr.Items, row)
+		tp.pos++
+	}
+	return nil
+}
+
+// parseListItems parses the "- " items of a list array.
+func (tp *treeParser) parseListItems(arr *ArrayNode, owner int) error {
+	isItem := func(line lineInfo) bool {
+		return line.indent > owner && isListItemLine(line.content)
+	}
+
+	for tp.pos < len(tp.lines) {
+		line := tp.lines[tp.pos]
+		if line.isBlank {
+			if err := tp.checkBlankInArray(line, isItem); err != nil {
+				return err
+			}
+			tp.pos++
+			continue
+		}
+		if !isItem(line) {
+			break
+		}
+
+		item, err := tp.parseListItem(line)
+		if err != nil {
+			return err
+		}
+		arr.Items = append(arr.Items, item)
+	}
+	return nil
+}
+
+// checkBlankInArray rejects, in strict mode, a blank line followed by more
+// elements of the same array.
+func (tp *treeParser) checkBlankInArray(line lineInfo, continues func(lineInfo) bool) error {
+	if !tp.opts.Strict {
+		return nil
+	}
+	saved := tp.pos
+	tp.pos++
+	next, ok := tp.peekLine()
+	tp.pos = saved
+	if ok && continues(next) {
+		return &DecodeError{
+			Message: "blank lines not allowed within arrays in strict mode",
+			Line:    line.lineNumber,
+			Context: line.original,
+		}
+	}
+	return nil
+}
+
+// parseListItem parses a single list item and everything nested under it.
+func (tp *treeParser) parseListItem(line lineInfo) (*ListItemNode, error) {
+	start := contentStart(line)
+	end := contentEnd(line)
+	valueStart := skipSpaces(line.original, start+len(listItemMarker))
+	item := &ListItemNode{}
+
+	switch {
+	case valueStart >= end:
+		item.Value = &ObjectNode{span: lineSpan(line, end, end)}
+		tp.pos++
+	case line.original[valueStart] == '[':
+		arr, err := tp.parseArray(line, valueStart, line.indent)
+		if err != nil {
+			return nil, err
+		}
+		item.Value = arr
+	case isFieldStart(line.original[valueStart:end]):
+		obj, err := tp.parseListItemObject(line, valueStart)
+		if err != nil {
+			return nil, err
+		}
+		item.Value = obj
+	default:
+		scalar, err := newScalarNode(line, valueStart, end)
+		if err != nil {
+			return nil, err
+		}
+		item.Value = scalar
+		tp.pos++
+	}
+
+	item.span = Span{Start: linePos(line, start), End: item.Value.Span().End}
+	return item, nil
+}
+
+// parseListItemObject parses an object whose first field sits on the hyphen line.
+func (tp *treeParser) parseListItemObject(line lineInfo, start int) (*ObjectNode, error) {
+	first, err := tp.parseField(line, start, line.indent, line.indent+tp.unit)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &ObjectNode{Fields: []*FieldNode{first}}
+	if next, ok := tp.peekLine(); ok && next.indent > line.indent && !isListItemLine(next.content) {
+		rest, err := tp.parseObject(next.indent)
+		if err != nil {
+			return nil, err
+		}
+		obj.Fields = append(obj.Fields, rest.Fields...)
+	}
+
+	obj.span = Span{Start: first.span.Start, End: obj.Fields[len(obj.Fields)-1].span.End}
+	return obj, nil
+}
+
+// parseTreeArrayHeader parses "[#N<delim>]{fields}:" starting at byte start
+// of line and returns the header and the byte index just past the colon.
+func parseTreeArrayHeader(line lineInfo, start int) (*ArrayHeader, int, error) {
+	src := line.original
+	i := start + 1
+	header := &ArrayHeader{Delimiter: comma}
+
+	if i < len(src) && !isDigit(rune(src[i])) && src[i] != ']' && src[i] != '\t' && src[i] != '|' {
+		markerStart := i
+		for i < len(src) && !isDigit(rune(src[i])) && src[i] != ']' {
+			i++
+		}
+		header.LengthMarker = src[markerStart:i]
+	}
+
+	digitsStart := i
+	for i < len(src) && isDigit(rune(src[i])) {
+		i++
+	}
+	if digitsStart == i {
+		return nil, 0, lineErrorAt(line, i, "expected array length")
+	}
+	header.Length, _ = strconv.Atoi(src[digitsStart:i])
+
+	if i < len(src) && (src[i] == '\t' || src[i] == '|') {
+		header.Delimiter = string(src[i])
+		i++
+	}
+	if i >= len(src) || src[i] != ']' {
+		return nil, 0, lineErrorAt(line, i, "expected ']' in array header")
+	}
+	i++
+
+	if i < len(src) && src[i] == '{' {
+		p := newParser(src[i+1:])
+		header.Fields = parseHeaderKeys(p, header.Delimiter)
+		i += 1 + p.pos
+		if i >= len(src) || src[i] != '}' {
+			return nil, 0, lineErrorAt(line, i, "expected '}' in array header")
+		}
+		i++
+	}
+
+	if i >= len(src) || src[i] != ':' {
+		return nil, 0, lineErrorAt(line, i, "expected ':' after array header")
+	}
+	i++
+
+	header.span = lineSpan(line, start, i)
+	return header, i, nil
+}
+
+// splitCells splits line[start:end] on delimiter outside quotes and returns
+// the trimmed [start, end) byte range of every cell.
+func splitCells(line lineInfo, start, end int, delimiter string) [][2]int {
+	src := line.original
+	var cells [][2]int
+	cellStart := start
+	inQuotes := false
+
+	addCell := func(from, to int) {
+		from = skipSpaces(src, from)
+		for to > from && (src[to-1] == ' ' || src[to-1] == '\t' && delimiter != tab) {
+			to--
+		}
+		cells = append(cells, [2]int{from, to})
+	}
+
+	for i := start; i < end; i++ {
+		ch := src[i]
+		switch {
+		case ch == '\\' && inQuotes:
+			i++
+		case ch == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && strings.HasPrefix(src[i:end], delimiter):
+			addCell(cellStart, i)
+			cellStart = i + len(delimiter)
+		}
+	}
+	addCell(cellStart, end)
+	return cells
+}
+
+// newScalarNode parses the primitive at line[start:end].
+func newScalarNode(line lineInfo, start, end int) (*ScalarNode, error) {
+	raw := line.original[start:end]
+	value, err := parseValue(raw)
+	if err != nil {
+		return nil, lineErrorAt(line, start, errorMessage(err))
+	}
+	return &ScalarNode{
+		Raw:    raw,
+		Value:  value,
+		Quoted: strings.HasPrefix(raw, doubleQuote),
+		span:   lineSpan(line, start, end),
+	}, nil
+}
+
+// isFieldStart reports whether s begins with a key followed by ':' or '['.
+func isFieldStart(s string) bool {
+	p := newParser(s)
+	if _, _, err := p.parseKeyWithQuoteInfo(); err != nil {
+		return false
+	}
+	return p.peek() == ':' || p.peek() == '['
+}
+
+// isListItemLine reports whether trimmed line content is a list item.
+func isListItemLine(content string) bool {
+	return content == listItemMarker || strings.HasPrefix(content, listItemPrefix)
+}
+
+// contentStart returns the byte index of the first non-indent character.
+func contentStart(line lineInfo) int {
+	return len(line.original) - len(line.content)
+}
+
+// contentEnd returns the byte index just past the last non-space character.
+func contentEnd(line lineInfo) int {
+	return len(strings.TrimRight(line.original, " \t\r"))
+}
+
+// skipSpaces returns the index of the first non-space byte at or after i.
+func skipSpaces(s string, i int) int {
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	return i
+}
+
+// linePos returns the position of byte col of line.
+func linePos(line lineInfo, col int) Pos {
+	return Pos{Offset: line.offset + col, Line: line.lineNumber, Column: col + 1}
+}
+
+// lineSpan returns the span of line[start:end].
+func lineSpan(line lineInfo, start, end int) Span {
+	return Span{Start: linePos(line, start), End: linePos(line, end)}
+}
+
+// lineError creates a DecodeError pointing at the start of a line's content.
+func lineError(line lineInfo, msg string) error {
+	return lineErrorAt(line, contentStart(line), msg)
+}
+
+// lineErrorAt creates a DecodeError pointing at byte col of line.
+func lineErrorAt(line lineInfo, col int, msg string) error {
+	return &DecodeError{
+		Message: msg,
+		Line:    line.lineNumber,
+		Column:  col + 1,
+		Context: line.original,
+	}
+}
+
+// errorMessage returns the bare message of a DecodeError, or err.Error().
+func errorMessage(err error) string {
+	if de, ok := err.(*DecodeError); ok {
+		return de.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,76 @@
+package toon
+
+import "reflect"
+
+// DelimiterAuto is a Delimiter value that lets each inline and tabular
+// array pick the delimiter among ",", "|" and "\t" that gives the shortest
+// encoding, which usually means the fewest quoted cells. A delimiter other
+// than "," is recorded in the array header, as in "[3|]:". Ties prefer ",",
+// then "|", then "\t". Object fields and list arrays use ",".
+const DelimiterAuto = "auto"
+
+// autoDelimiterOrder lists the candidate delimiters in order of preference.
+var autoDelimiterOrder = []string{comma, pipe, tab}
+
+// resolveAutoDelimiter replaces DelimiterAuto in opts by the document
+// delimiter "," and marks the options for per-array selection.
+func resolveAutoDelimiter(opts *EncodeOptions) {
+	if opts.Delimiter == DelimiterAuto {
+		opts.Delimiter = comma
+		opts.autoDelimiter = true
+	}
+}
+
+// withArrayDelimiter returns opts with the delimiter chosen for an array of
+// the given primitive cells when the delimiter is automatic, and opts
+// itself otherwise.
+func withArrayDelimiter(opts *EncodeOptions, cells []Value) (*EncodeOptions, error) {
+	if !opts.autoDelimiter {
+		return opts, nil
+	}
+
+	best, bestSize := comma, -1
+	for _, d := range autoDelimiterOrder {
+		size := 0
+		if d != comma {
+			size++ // header marker
+		}
+		for _, cell := range cells {
+			encoded, err := encodePrimitive(cell, d)
+			if err != nil {
+				return nil, err
+			}
+			size += len(encoded)
+		}
+		if bestSize < 0 || size < bestSize {
+			best, bestSize = d, size
+		}
+	}
+
+	arrOpts := *opts
+	arrOpts.Delimiter = best
+	return &arrOpts, nil
+}
+
+// arrayCells returns the primitive cells of an inline or tabular array:
+// its elements, or the fields of its rows.
+func arrayCells(v Value, format arrayFormat) []Value {
+	rv := reflect.ValueOf(v)
+	var cells []Value
+	var keys []string
+	if format == arrayFormatTabular && rv.Len() > 0 {
+		keys = tabularKeys(rv.Index(0).Interface())
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i).Interface()
+		if format != arrayFormatTabular {
+			cells = append(cells, item)
+			continue
+		}
+		for _, k := range keys {
+			cells = append(cells, tabularCell(item, k))
+		}
+	}
+	return cells
+}
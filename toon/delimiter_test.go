@@ -0,0 +1,129 @@
+package toon
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDelimiterAuto(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{
+			name:  "comma when nothing is quoted",
+			input: map[string]interface{}{"tags": []interface{}{"a", "b c", 1}},
+			want:  "tags[3]: a,b c,1",
+		},
+		{
+			name:  "pipe for commas",
+			input: map[string]interface{}{"notes": []interface{}{"a, b", "c, d"}},
+			want:  "notes[2|]: a, b|c, d",
+		},
+		{
+			name:  "tab for commas and pipes",
+			input: map[string]interface{}{"notes": []interface{}{"a, b", "c|d"}},
+			want:  "notes[2\t]: a, b\tc|d",
+		},
+		{
+			name:  "comma when quoting is unavoidable",
+			input: map[string]interface{}{"notes": []interface{}{"a\tb"}},
+			want:  `notes[1]: "a\tb"`,
+		},
+		{
+			name: "per array",
+			input: map[string]interface{}{
+				"ids": []interface{}{1, 2},
+				"users": []interface{}{
+					map[string]interface{}{"id": 1, "bio": "Hi, I'm Ada"},
+					map[string]interface{}{"id": 2, "bio": "Bob"},
+				},
+			},
+			want: "ids[2]: 1,2\nusers[2|]{bio|id}:\n  Hi, I'm Ada|1\n  Bob|2",
+		},
+		{
+			name: "list items",
+			input: []interface{}{
+				[]interface{}{"x, y", "z"},
+				map[string]interface{}{"tags": []interface{}{"p, q"}, "name": "a, b"},
+			},
+			want: "[2]:\n  - [2|]: x, y|z\n  - tags[1|]: p, q\n    name: \"a, b\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalToString(tt.input, WithDelimiter(DelimiterAuto))
+			if err != nil {
+				t.Fatalf("MarshalToString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MarshalToString() =\n%q\nwant\n%q", got, tt.want)
+			}
+
+			// The choice is recorded in the header, so the output round-trips
+			var decoded interface{}
+			if err := UnmarshalFromString(got, &decoded); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !Equal(decoded, tt.input) {
+				t.Errorf("Unmarshal() = %v, want %v", decoded, tt.input)
+			}
+		})
+	}
+}
+
+func TestDelimiterAutoTranscode(t *testing.T) {
+	input := `{"notes": ["a, b", "c"], "rows": [{"id": 1, "text": "x|y, z"}, {"id": 2, "text": "w, v"}], "list": [1, {"a": "p, q"}]}`
+
+	var out strings.Builder
+	if err := TranscodeJSON(&out, strings.NewReader(input), WithDelimiter(DelimiterAuto)); err != nil {
+		t.Fatalf("TranscodeJSON() error = %v", err)
+	}
+
+	v := NewOrderedMap()
+	if err := json.Unmarshal([]byte(input), v); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want, err := MarshalToString(v, WithDelimiter(DelimiterAuto))
+	if err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+	if out.String() != want {
+		t.Errorf("TranscodeJSON() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestDelimiterAutoBudget(t *testing.T) {
+	input := map[string]interface{}{"notes": []interface{}{"a, b", "c, d", "e, f", "g, h", "i, j", "k, l"}}
+
+	got, err := MarshalToString(input, WithDelimiter(DelimiterAuto), WithTokenBudget(len("notes[6|]: a, b|... 4 more|k, l"), byteTokenizer{}))
+	if err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+	if want := "notes[6|]: a, b|... 4 more|k, l"; got != want {
+		t.Errorf("MarshalToString() = %q, want %q", got, want)
+	}
+}
+
+func TestDelimiterAutoOptions(t *testing.T) {
+	if err := validateEncodeOptions(&EncodeOptions{Delimiter: DelimiterAuto}); err != nil {
+		t.Errorf("validateEncodeOptions(auto) error = %v", err)
+	}
+	err := validateEncodeOptions(&EncodeOptions{Delimiter: ";"})
+	if err == nil || !strings.Contains(err.Error(), `"auto"`) {
+		t.Errorf("validateEncodeOptions(;) error = %v, want the valid choices", err)
+	}
+
+	// Without DelimiterAuto the configured delimiter is kept
+	opts := &EncodeOptions{Delimiter: pipe}
+	if got, _ := withArrayDelimiter(opts, []Value{"a|b"}); got != opts {
+		t.Errorf("withArrayDelimiter() = %+v, want the options unchanged", got)
+	}
+	if got := arrayCells([]Value{map[string]Value{"b": 2, "a": 1}}, arrayFormatTabular); !reflect.DeepEqual(got, []Value{1, 2}) {
+		t.Errorf("arrayCells() = %v, want [1 2]", got)
+	}
+}
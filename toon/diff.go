@@ -0,0 +1,244 @@
+package toon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeType identifies the kind of a Change.
+type ChangeType string
+
+const (
+	// ChangeAdded is a field or array element present only in the new value.
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved is a field or array element present only in the old value.
+	ChangeRemoved ChangeType = "removed"
+	// ChangeChanged is a value that differs between old and new.
+	ChangeChanged ChangeType = "changed"
+	// ChangeReordered is an object whose fields, or an array whose elements,
+	// are the same but in a different order.
+	ChangeReordered ChangeType = "reordered"
+)
+
+// Change is a single difference reported by Diff.
+type Change struct {
+	// Type is the kind of change.
+	Type ChangeType
+	// Path locates the change using Get path syntax; "" is the root.
+	Path string
+	// Old is the old value; nil for additions. For reordered objects it
+	// holds the old field order as []Value of keys.
+	Old Value
+	// New is the new value; nil for removals. For reordered objects it
+	// holds the new field order as []Value of keys.
+	New Value
+}
+
+// Changes is the result of Diff.
+type Changes []Change
+
+// Diff returns the differences between old and new, addressed by path.
+//
+// Values are compared as with Equal. Arrays are compared element by
+// element; an array whose elements are a permutation of the old ones is
+// reported as a single reordered change. Object field order is only
+// reported when WithFieldOrder(true) is given.
+//
+// Example:
+//
+//	changes, err := toon.Diff(oldDoc, newDoc)
+//	fmt.Println(changes)
+//	// ~ users[1].name: Bob -> Bo
+//	// + users[2]:
+//	//     id: 3
+//	//     name: Cy
+func Diff(old, new Value, opts ...CompareOption) (Changes, error) {
+	oldNode, err := NewNode(old)
+	if err != nil {
+		return nil, err
+	}
+	newNode, err := NewNode(new)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &differ{opts: applyCompareOptions(opts...)}
+	d.diff(nil, oldNode, newNode)
+	return d.changes, nil
+}
+
+// differ accumulates changes while walking two nodes.
+type differ struct {
+	opts    *CompareOptions
+	changes Changes
+}
+
+// diff compares a and b at path.
+func (d *differ) diff(path []pathSegment, a, b *Node) {
+	switch {
+	case a.Kind() == KindObject && b.Kind() == KindObject:
+		d.diffObjects(path, a, b)
+	case a.Kind() == KindArray && b.Kind() == KindArray:
+		d.diffArrays(path, a, b)
+	case !nodesEqual(a, b, d.opts):
+		d.add(ChangeChanged, path, a.Value(), b.Value())
+	}
+}
+
+// diffObjects compares two objects field by field.
+func (d *differ) diffObjects(path []pathSegment, a, b *Node) {
+	if d.opts.FieldOrder {
+		oldOrder, newOrder := commonKeys(a, b), commonKeys(b, a)
+		for i := range oldOrder {
+			if oldOrder[i] != newOrder[i] {
+				d.add(ChangeReordered, path, oldOrder, newOrder)
+				break
+			}
+		}
+	}
+
+	for _, f := range a.fields {
+		child := appendPath(path, pathSegment{key: f.Key})
+		if other := b.Get(f.Key); other != nil {
+			d.diff(child, f.Value, other)
+		} else {
+			d.add(ChangeRemoved, child, f.Value.Value(), nil)
+		}
+	}
+	for _, f := range b.fields {
+		if !a.Has(f.Key) {
+			d.add(ChangeAdded, appendPath(path, pathSegment{key: f.Key}), nil, f.Value.Value())
+		}
+	}
+}
+
+// diffArrays compares two arrays element by element.
+func (d *differ) diffArrays(path []pathSegment, a, b *Node) {
+	if nodesEqual(a, b, d.opts) {
+		return
+	}
+	if len(a.items) == len(b.items) && d.isPermutation(a.items, b.items) {
+		d.add(ChangeReordered, path, a.Value(), b.Value())
+		return
+	}
+
+	for i := 0; i < len(a.items) && i < len(b.items); i++ {
+		d.diff(appendPath(path, pathSegment{index: i, isIndex: true}), a.items[i], b.items[i])
+	}
+	for i := len(b.items); i < len(a.items); i++ {
+		d.add(ChangeRemoved, appendPath(path, pathSegment{index: i, isIndex: true}), a.items[i].Value(), nil)
+	}
+	for i := len(a.items); i < len(b.items); i++ {
+		d.add(ChangeAdded, appendPath(path, pathSegment{index: i, isIndex: true}), nil, b.items[i].Value())
+	}
+}
+
+// isPermutation reports whether b holds the same elements as a in any order.
+func (d *differ) isPermutation(a, b []*Node) bool {
+	counts := make(map[string]int, len(a))
+	for _, item := range a {
+		counts[nodeKey(item, d.opts)]++
+	}
+	for _, item := range b {
+		key := nodeKey(item, d.opts)
+		if counts[key] == 0 {
+			return false
+		}
+		counts[key]--
+	}
+	return true
+}
+
+// add records a change.
+func (d *differ) add(t ChangeType, path []pathSegment, old, new Value) {
+	d.changes = append(d.changes, Change{Type: t, Path: formatPath(path), Old: old, New: new})
+}
+
+// commonKeys returns the keys of a that also exist in b, in a's order.
+func commonKeys(a, b *Node) []Value {
+	keys := make([]Value, 0, len(a.fields))
+	for _, f := range a.fields {
+		if b.Has(f.Key) {
+			keys = append(keys, f.Key)
+		}
+	}
+	return keys
+}
+
+// appendPath returns path extended by seg without sharing storage.
+func appendPath(path []pathSegment, seg pathSegment) []pathSegment {
+	return append(path[:len(path):len(path)], seg)
+}
+
+// String renders the changes as text, one change per line. Lines start
+// with "+" for added, "-" for removed, "~" for changed and "*" for reordered
+// values, e.g. "~ users[1].name: Bob -> Bo".
+//
+// Non-primitive values are written as indented TOON below the line.
+func (c Changes) String() string {
+	var b strings.Builder
+	for i, ch := range c {
+		if i > 0 {
+			b.WriteString(newline)
+		}
+		b.WriteString(ch.String())
+	}
+	return b.String()
+}
+
+// String renders the change as a line of text.
+func (ch Change) String() string {
+	path := ch.Path
+	if path == "" {
+		path = "(root)"
+	}
+
+	switch ch.Type {
+	case ChangeAdded:
+		return "+ " + path + colon + formatChangeValue(ch.New)
+	case ChangeRemoved:
+		return "- " + path + colon + formatChangeValue(ch.Old)
+	case ChangeReordered:
+		return "* " + path + colon + formatChangeValue(ch.Old) + " ->" + formatChangeValue(ch.New)
+	default:
+		return "~ " + path + colon + formatChangeValue(ch.Old) + " ->" + formatChangeValue(ch.New)
+	}
+}
+
+// Value converts the changes into a value for Marshal. Each change becomes
+// an object with fields op, path, old and new, so changes between primitive
+// values encode as a tabular array.
+func (c Changes) Value() Value {
+	result := make([]Value, len(c))
+	for i, ch := range c {
+		row := NewOrderedMap()
+		row.Set("op", string(ch.Type))
+		row.Set("path", ch.Path)
+		row.Set("old", ch.Old)
+		row.Set("new", ch.New)
+		result[i] = row
+	}
+	return result
+}
+
+// formatChangeValue formats a value after "path:" or "->".
+func formatChangeValue(v Value) string {
+	n := normalize(v)
+	if isPrimitive(n) {
+		encoded, err := encodePrimitive(n, comma)
+		if err != nil {
+			return fmt.Sprintf(" %v", v)
+		}
+		return space + encoded
+	}
+
+	// Inline arrays fit on the line; other values go below it
+	encoded, err := encode(n, getEncodeOptions(nil))
+	if err != nil {
+		return fmt.Sprintf(" %v", v)
+	}
+	if !strings.Contains(encoded, newline) && strings.HasPrefix(encoded, openBracket) {
+		return space + encoded
+	}
+	return newline + "    " + strings.ReplaceAll(encoded, newline, newline+"    ")
+}
@@ -0,0 +1,126 @@
+package toon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var a, b Node
+	if err := UnmarshalFromString("name: app\nversion: 1\nusers[2]{id,name}:\n  1,Ada\n  2,Bob\ntags[2]: x,y\nold: true", &a); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := UnmarshalFromString("name: app\nversion: 2\nusers[3]{id,name}:\n  1,Ada\n  2,Bo\n  3,Cy\ntags[2]: y,x\nnew: 1.5", &b); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	changes, err := Diff(&a, &b)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	want := []struct {
+		typ  ChangeType
+		path string
+	}{
+		{ChangeChanged, "version"},
+		{ChangeChanged, "users[1].name"},
+		{ChangeAdded, "users[2]"},
+		{ChangeReordered, "tags"},
+		{ChangeRemoved, "old"},
+		{ChangeAdded, "new"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Diff() = %d changes, want %d:\n%s", len(changes), len(want), changes)
+	}
+	for i, w := range want {
+		if changes[i].Type != w.typ || changes[i].Path != w.path {
+			t.Errorf("change %d = %s %q, want %s %q", i, changes[i].Type, changes[i].Path, w.typ, w.path)
+		}
+	}
+	if changes[1].Old != "Bob" || changes[1].New != "Bo" {
+		t.Errorf("change 1 = %v -> %v, want Bob -> Bo", changes[1].Old, changes[1].New)
+	}
+}
+
+func TestDiffEqual(t *testing.T) {
+	changes, err := Diff(map[string]Value{"n": int64(1)}, map[string]interface{}{"n": 1.0})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Diff() = %v, want no changes", changes)
+	}
+}
+
+func TestDiffFieldOrder(t *testing.T) {
+	var a, b Node
+	if err := UnmarshalFromString("x: 1\ny: 2\nz: 3", &a); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := UnmarshalFromString("y: 2\nx: 1\nw: 4", &b); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	changes, err := Diff(&a, &b)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Diff() = %v, want removed and added only", changes)
+	}
+
+	changes, err = Diff(&a, &b, WithFieldOrder(true))
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(changes) != 3 || changes[0].Type != ChangeReordered || changes[0].Path != "" {
+		t.Fatalf("Diff(WithFieldOrder) = %v, want reordered root first", changes)
+	}
+	if !reflect.DeepEqual(changes[0].Old, []Value{"x", "y"}) || !reflect.DeepEqual(changes[0].New, []Value{"y", "x"}) {
+		t.Errorf("reordered = %v -> %v, want [x y] -> [y x]", changes[0].Old, changes[0].New)
+	}
+}
+
+func TestDiffTypeChange(t *testing.T) {
+	changes, err := Diff(map[string]Value{"a": []Value{1}}, map[string]Value{"a": "1"})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].Type != ChangeChanged || changes[0].Path != "a" {
+		t.Errorf("Diff() = %v, want changed a", changes)
+	}
+}
+
+func TestChangesString(t *testing.T) {
+	changes := Changes{
+		{Type: ChangeChanged, Path: "users[1].name", Old: "Bob", New: "Bo"},
+		{Type: ChangeAdded, Path: "users[2]", New: map[string]Value{"id": 3, "name": "Cy"}},
+		{Type: ChangeRemoved, Path: "tags", Old: []Value{"a", "b"}},
+		{Type: ChangeReordered, Path: "", Old: []Value{"x", "y"}, New: []Value{"y", "x"}},
+	}
+
+	want := "~ users[1].name: Bob -> Bo\n" +
+		"+ users[2]:\n    id: 3\n    name: Cy\n" +
+		"- tags: [2]: a,b\n" +
+		"* (root): [2]: x,y -> [2]: y,x"
+	if got := changes.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestChangesValue(t *testing.T) {
+	changes := Changes{
+		{Type: ChangeChanged, Path: "version", Old: int64(1), New: int64(2)},
+		{Type: ChangeRemoved, Path: "old", Old: true},
+	}
+
+	got, err := MarshalToString(map[string]Value{"changes": changes.Value()})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := "changes[2]{op,path,old,new}:\n  changed,version,1,2\n  removed,old,true,null"
+	if got != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -19,15 +19,50 @@
 //	Unmarshal(r io.Reader, v interface{}, opts ...DecodeOption) error
 //	MarshalToString(v interface{}, opts ...EncodeOption) (string, error)
 //	UnmarshalFromString(s string, v interface{}, opts ...DecodeOption) error
+//	ParseTree(r io.Reader, opts ...DecodeOption) (*Document, error)
+//	IsExpandablePath(key string) bool
+//	Format(src []byte, opts ...EncodeOption) ([]byte, error)
+//	Get(v Value, path string) (Value, error)
+//	Equal(a, b Value, opts ...CompareOption) bool
+//	Diff(old, new Value, opts ...CompareOption) (Changes, error)
+//	ApplyPatch(doc, ops Value) (Value, error)
+//	ApplyMergePatch(doc, patch Value) (Value, error)
+//	CanonicalHash(v Value) (string, error)
+//	TranscodeJSON(dst io.Writer, src io.Reader, opts ...EncodeOption) error
+//	FromJSON(r io.Reader) (Value, error)
+//	TranscodeToJSON(dst io.Writer, src io.Reader, opts ...DecodeOption) error
+//	TranscodeToJSONIndent(dst io.Writer, src io.Reader, indent string, opts ...DecodeOption) error
+//	FromCSV(r io.Reader, key string, opts ...CSVOption) (Value, error)
+//	ToCSV(w io.Writer, doc Value, path string, opts ...CSVOption) error
+//	ToMarkdownTable(v Value, path string) (string, error)
+//	FromMarkdownTable(text string) (Value, error)
+//	FromJSONLines(r io.Reader, key string, opts ...JSONLinesOption) (Value, error)
+//	EncodeRows(w io.Writer, key string, rows *sql.Rows, opts ...EncodeOption) error
+//	Chunk(v Value, path string, maxTokens int, opts ...ChunkOption) ([][]byte, error)
+//	Heatmap(v Value, tokenizer tokens.Tokenizer, opts ...EncodeOption) (*Cost, error)
+//	Template(t interface{}, opts ...EncodeOption) (string, error)
 //
 // Additional exported types:
 //
 //	OrderedMap - Preserves key insertion order
+//	RawValue - Unparsed TOON subtree for lazy decoding
+//	Node - Typed value with ordered fields (see NewNode)
 //	EncodeOptions - Encoding configuration struct (for advanced use)
 //	DecodeOptions - Decoding configuration struct (for advanced use)
 //	EncodeOption - Functional option for encoding
 //	DecodeOption - Functional option for decoding
 //	EncodeError, DecodeError - Error types with detailed messages
+//	Document - Concrete syntax tree with source spans (see ParseTree)
+//	PathError - Error type for path lookups and document edits
+//	Changes - Path-addressed differences returned by Diff
+//	EncodeReport - Lossy conversions made by Marshal (see WithReport)
+//	PatchError - Error type for JSON Patch operations
+//	CSVOption - Functional option for FromCSV and ToCSV
+//	JSONLinesOption - Functional option for FromJSONLines
+//	ChunkOption - Functional option for Chunk
+//	Cost - Byte and token cost of a value, returned by Heatmap
+//	FloatFormat - Float precision, rounding mode and notation
+//	JSONSchema - JSON Schema document for Template
 //
 // # Basic Usage
 //
@@ -103,11 +138,17 @@
 // Available encoding options:
 //
 //	WithIndent(n)            - Set indentation size in spaces (default: 2)
-//	WithDelimiter(s)         - Set array delimiter: "," | "\t" | "|" (default: ",")
+//	WithDelimiter(s)         - Set array delimiter: "," | "\t" | "|" | DelimiterAuto (default: ",")
 //	WithLengthMarker(s)      - Set length marker prefix (default: "")
 //	WithFlattenPaths(bool)   - Enable path flattening (default: false)
-//	WithFlattenDepth(n)      - Limit flattening depth (default: 0 = unlimited)
+//	WithFlattenDepth(n)      - Limit flattening depth (default: unlimited; 0 disables folding)
 //	WithStrict(bool)         - Enable strict collision detection (default: false)
+//	WithSortKeys(bool)       - Sort keys of every object, including OrderedMap (default: false)
+//	WithTokenBudget(n, tok)  - Truncate the output to n tokens (default: 0, no budget)
+//	WithFloatFormat(f)       - Round floats and choose their notation (default: shortest)
+//	WithFloatFormatAt(p, f)  - Float format for the values at or below path p
+//	WithReport(r)            - Record lossy conversions in r (default: nil)
+//	Canonical()              - Deterministic profile for hashing (see CanonicalHash)
 //
 // Available decoding options:
 //
@@ -116,6 +157,23 @@
 //	WithExpandPaths(mode)    - Expand dotted keys: "off" | "safe" (default: "off")
 //	WithKeyMode(mode)        - Key decoding mode (default: StringKeys)
 //
+// Available CSV options:
+//
+//	WithCSVComma(r)          - Field delimiter (default: ',')
+//	WithCSVNullText(s)       - Cell text that stands for null (default: "")
+//	WithCSVInferTypes(bool)  - Per-column number and boolean inference (default: true)
+//
+// Available JSON Lines options:
+//
+//	WithJSONLinesColumns(c...) - Fields to keep, in order (default: all)
+//	WithJSONLinesLimit(n)      - Maximum number of records (default: 0 = unlimited)
+//
+// Available Chunk options:
+//
+//	WithChunkContext(p...)        - Paths repeated in every chunk (default: none)
+//	WithChunkTokenizer(t)         - Tokenizer measuring chunks (default: tokens.Heuristic)
+//	WithChunkEncodeOptions(o...)  - Encode options for each chunk (default: none)
+//
 // # OrderedMap
 //
 // Use OrderedMap to preserve key insertion order during encoding:
@@ -146,7 +204,26 @@
 //
 //   - api.go - Public API entry points
 //   - encode_*.go - Encoding logic for objects, arrays, and primitives
-//   - decode_*.go - Decoding logic with structural and token parsers
+//   - decode_*.go - Decoding logic with structural, token and syntax tree parsers
+//   - tree.go - Syntax tree node types
+//   - format.go - Canonical formatting of TOON documents
+//   - budget.go - Truncation to a token budget
+//   - chunk.go - Splitting arrays into token-bounded documents
+//   - heatmap.go - Per-value cost measured during encoding
+//   - delimiter.go - Automatic per-array delimiter selection
+//   - floats.go - Float rounding and notation
+//   - template.go - Output skeletons from Go types and JSON Schema
+//   - edit.go, path.go - Format-preserving document edits addressed by path
+//   - get.go - Path queries over decoded values and syntax trees
+//   - node.go - Typed document model
+//   - compare.go, diff.go - Semantic equality and structural diff
+//   - patch.go - JSON Patch and JSON Merge Patch
+//   - canonical.go - Canonical encoding profile and hashing
+//   - transcode.go - Streaming transcoding between JSON and TOON
+//   - csv.go - CSV import and export of tabular arrays
+//   - markdown.go - Markdown table import and export
+//   - jsonl.go - JSON Lines ingestion
+//   - sqlrows.go - database/sql result sets as tabular arrays
 //   - orderedmap.go - Ordered map implementation
 //   - types.go, errors.go, options.go - Public type definitions
 //
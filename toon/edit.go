@@ -0,0 +1,546 @@
+package toon
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// textEdit replaces source[start:end] with text.
+type textEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// treeRef locates a value node in a Document together with its owner.
+type treeRef struct {
+	// value is an *ObjectNode, *ArrayNode, *ScalarNode or *RowNode, or nil
+	// for the root of an empty document.
+	value SyntaxNode
+	// owner is the *FieldNode or *ListItemNode holding value, the *RowNode
+	// holding a cell, or nil.
+	owner SyntaxNode
+	// array and index are set when value is an element or a row cell.
+	array *ArrayNode
+	index int
+}
+
+// Bytes returns the current source of the document.
+func (d *Document) Bytes() []byte {
+	return []byte(d.source)
+}
+
+// Set replaces the value at path, or adds a field when the last segment of
+// path names a key missing from an existing object.
+//
+// Only the lines holding the old value are rewritten; all other bytes of the
+// document are left untouched. Primitive values replace scalars in place,
+// keeping the surrounding quoting and delimiters.
+//
+// Example:
+//
+//	doc, _ := toon.ParseTreeFromString("name: Ada\nusers[2]{id,name}:\n  1,Ada\n  2,Bob")
+//	err := doc.Set("users[1].name", "Bo")
+//	// doc.Source(): "name: Ada\nusers[2]{id,name}:\n  1,Ada\n  2,Bo"
+func (d *Document) Set(path string, value interface{}) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	v := normalize(value)
+
+	if len(segments) == 0 {
+		return d.replaceRoot(v)
+	}
+
+	parent, err := d.resolve(segments[:len(segments)-1])
+	if err != nil {
+		return err
+	}
+
+	last := segments[len(segments)-1]
+	target, err := d.step(parent, last, segments)
+	if errors.Is(err, ErrPathNotFound) && !last.isIndex {
+		return d.addField(parent, last.key, v, segments)
+	}
+	if err != nil {
+		return err
+	}
+
+	edit, err := d.replaceEdit(target, v, segments)
+	if err != nil {
+		return err
+	}
+	return d.apply(edit)
+}
+
+// Delete removes the field or array element at path and updates the
+// enclosing array's [N] header.
+func (d *Document) Delete(path string) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return d.apply(textEdit{start: 0, end: len(d.source)})
+	}
+
+	parent, err := d.resolve(segments[:len(segments)-1])
+	if err != nil {
+		return err
+	}
+	target, err := d.step(parent, segments[len(segments)-1], segments)
+	if err != nil {
+		return err
+	}
+
+	switch owner := target.owner.(type) {
+	case *FieldNode:
+		return d.apply(d.deleteFieldEdit(parent.value.(*ObjectNode), owner))
+	case *RowNode:
+		return pathTypeError(segments, "cannot delete a single cell of a tabular row")
+	}
+
+	arr := target.array
+	if arr == nil {
+		return pathTypeError(segments, "cannot delete value")
+	}
+
+	lengthEdit := d.lengthEdit(arr.Header, len(arr.Items)-1)
+	if arr.Layout != ArrayInline {
+		return d.apply(d.removeLines(arr.Items[target.index].Span()), lengthEdit)
+	}
+
+	cells := make([]string, 0, len(arr.Items)-1)
+	for i, item := range arr.Items {
+		if i != target.index {
+			cells = append(cells, item.(*ScalarNode).Raw)
+		}
+	}
+	start := arr.Header.span.End.Offset
+	text := ""
+	if len(cells) > 0 {
+		start = arr.Items[0].Span().Start.Offset
+		text = strings.Join(cells, arr.Header.Delimiter)
+	}
+	return d.apply(textEdit{start: start, end: arr.span.End.Offset, text: text}, lengthEdit)
+}
+
+// InsertRow inserts row before position index of the tabular array at path.
+// The row must be an object with exactly the header's fields and primitive
+// values. An index equal to the number of rows appends.
+func (d *Document) InsertRow(path string, index int, row interface{}) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	ref, err := d.resolve(segments)
+	if err != nil {
+		return err
+	}
+
+	arr, ok := ref.value.(*ArrayNode)
+	if !ok || arr.Layout != ArrayTabular {
+		return pathTypeError(segments, "not a tabular array")
+	}
+	if index < 0 || index > len(arr.Items) {
+		return pathNotFound(segments, fmt.Sprintf("row index %d out of range", index))
+	}
+
+	cells, err := encodeRowCells(arr.Header, normalize(row))
+	if err != nil {
+		return pathTypeError(segments, err.Error())
+	}
+	return d.apply(d.insertElementEdit(arr, index, cells), d.lengthEdit(arr.Header, len(arr.Items)+1))
+}
+
+// AppendItem appends value to the array at path.
+//
+// Values that fit the array's current layout are added as a single new line
+// or cell. Otherwise the array is re-encoded in the layout the encoder would
+// choose for the combined items.
+func (d *Document) AppendItem(path string, value interface{}) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	ref, err := d.resolve(segments)
+	if err != nil {
+		return err
+	}
+	arr, ok := ref.value.(*ArrayNode)
+	if !ok {
+		return pathTypeError(segments, "not an array")
+	}
+
+	v := normalize(value)
+	lengthEdit := d.lengthEdit(arr.Header, len(arr.Items)+1)
+
+	switch {
+	case arr.Layout == ArrayTabular:
+		if cells, err := encodeRowCells(arr.Header, v); err == nil {
+			return d.apply(d.insertElementEdit(arr, len(arr.Items), cells), lengthEdit)
+		}
+	case arr.Layout == ArrayInline && isPrimitive(v):
+		encoded, err := encodePrimitive(v, arr.Header.Delimiter)
+		if err != nil {
+			return err
+		}
+		edit := textEdit{start: arr.span.End.Offset, end: arr.span.End.Offset, text: space + encoded}
+		if len(arr.Items) > 0 {
+			edit.text = arr.Header.Delimiter + encoded
+		}
+		return d.apply(edit, lengthEdit)
+	case arr.Layout == ArrayList:
+		w := newWriter(d.indent)
+		if err := encodeListItem(w, v, 0, d.encodeOptions(), true); err != nil {
+			return err
+		}
+		return d.apply(d.insertElementEdit(arr, len(arr.Items), w.String()), lengthEdit)
+	}
+
+	items := syntaxNodeValue(arr).([]Value)
+	edit, err := d.replaceEdit(ref, append(items, v), segments)
+	if err != nil {
+		return err
+	}
+	return d.apply(edit)
+}
+
+// resolve walks segments from the root of the document.
+func (d *Document) resolve(segments []pathSegment) (treeRef, error) {
+	ref := treeRef{value: d.Root}
+	for i, seg := range segments {
+		next, err := d.step(ref, seg, segments[:i+1])
+		if err != nil {
+			return treeRef{}, err
+		}
+		ref = next
+	}
+	return ref, nil
+}
+
+// step resolves a single segment below ref; segments is used for errors.
+func (d *Document) step(ref treeRef, seg pathSegment, segments []pathSegment) (treeRef, error) {
+	if seg.isIndex {
+		arr, ok := ref.value.(*ArrayNode)
+		if !ok {
+			return treeRef{}, pathTypeError(segments, "not an array")
+		}
+		if seg.index >= len(arr.Items) {
+			return treeRef{}, pathNotFound(segments, fmt.Sprintf("index %d out of range", seg.index))
+		}
+		item := arr.Items[seg.index]
+		if li, ok := item.(*ListItemNode); ok {
+			return treeRef{value: li.Value, owner: li, array: arr, index: seg.index}, nil
+		}
+		return treeRef{value: item, array: arr, index: seg.index}, nil
+	}
+
+	switch node := ref.value.(type) {
+	case *ObjectNode:
+		if f := node.Field(seg.key); f != nil {
+			return treeRef{value: f.Value, owner: f}, nil
+		}
+	case *RowNode:
+		for i, field := range ref.array.Header.Fields {
+			if field == seg.key && i < len(node.Cells) {
+				return treeRef{value: node.Cells[i], owner: node, array: ref.array, index: i}, nil
+			}
+		}
+	case nil:
+	default:
+		return treeRef{}, pathTypeError(segments, "not an object")
+	}
+	return treeRef{}, pathNotFound(segments, fmt.Sprintf("key %q not found", seg.key))
+}
+
+// replaceEdit builds the edit that replaces the value at ref with v.
+func (d *Document) replaceEdit(ref treeRef, v Value, segments []pathSegment) (textEdit, error) {
+	scalar, isScalar := ref.value.(*ScalarNode)
+
+	switch owner := ref.owner.(type) {
+	case *FieldNode:
+		if isScalar && isPrimitive(v) {
+			return d.scalarEdit(scalar, v, comma)
+		}
+		text, err := d.encodeFieldText(owner, owner.RawKey, v)
+		if err != nil {
+			return textEdit{}, err
+		}
+		return spanEdit(owner.span, text), nil
+
+	case *ListItemNode:
+		if isScalar && isPrimitive(v) {
+			return d.scalarEdit(scalar, v, ref.array.Header.Delimiter)
+		}
+		w := newWriter(d.indent)
+		if err := encodeListItem(w, v, 0, d.encodeOptions(), true); err != nil {
+			return textEdit{}, err
+		}
+		return spanEdit(owner.span, indentContinuation(w.String(), d.lineIndent(owner.span.Start.Offset))), nil
+
+	case *RowNode:
+		if !isPrimitive(v) {
+			return textEdit{}, pathTypeError(segments, "tabular cells must be primitive")
+		}
+		return d.scalarEdit(scalar, v, ref.array.Header.Delimiter)
+	}
+
+	if ref.array != nil {
+		if row, ok := ref.value.(*RowNode); ok {
+			cells, err := encodeRowCells(ref.array.Header, v)
+			if err != nil {
+				return textEdit{}, pathTypeError(segments, err.Error())
+			}
+			return spanEdit(row.span, cells), nil
+		}
+		if !isPrimitive(v) {
+			return textEdit{}, pathTypeError(segments, "inline array values must be primitive")
+		}
+		return d.scalarEdit(scalar, v, ref.array.Header.Delimiter)
+	}
+
+	text, err := encode(v, d.encodeOptions())
+	if err != nil {
+		return textEdit{}, err
+	}
+	return textEdit{start: 0, end: len(d.source), text: text}, nil
+}
+
+// replaceRoot replaces the whole document with the encoding of v.
+func (d *Document) replaceRoot(v Value) error {
+	edit, err := d.replaceEdit(treeRef{value: d.Root}, v, nil)
+	if err != nil {
+		return err
+	}
+	return d.apply(edit)
+}
+
+// addField appends a new field to the object at parent.
+func (d *Document) addField(parent treeRef, key string, v Value, segments []pathSegment) error {
+	encodedKey := encodeKey(key)
+
+	if parent.value == nil {
+		text, err := d.encodeFieldText(nil, encodedKey, v)
+		if err != nil {
+			return err
+		}
+		return d.apply(textEdit{start: 0, end: len(d.source), text: text})
+	}
+
+	obj, ok := parent.value.(*ObjectNode)
+	if !ok {
+		return pathTypeError(segments[:len(segments)-1], "not an object")
+	}
+
+	if len(obj.Fields) > 0 {
+		last := obj.Fields[len(obj.Fields)-1]
+		indent := d.fieldIndent(last)
+		text, err := d.encodeFieldTextAt(indent, false, encodedKey, v)
+		if err != nil {
+			return err
+		}
+		at := d.lineEnd(last.span.End.Offset)
+		return d.apply(textEdit{start: at, end: at, text: newline + strings.Repeat(space, indent) + text})
+	}
+
+	switch owner := parent.owner.(type) {
+	case *FieldNode:
+		indent := d.fieldIndent(owner) + d.indent
+		text, err := d.encodeFieldTextAt(indent, false, encodedKey, v)
+		if err != nil {
+			return err
+		}
+		at := d.lineEnd(owner.span.End.Offset)
+		return d.apply(textEdit{start: at, end: at, text: newline + strings.Repeat(space, indent) + text})
+	case *ListItemNode:
+		text, err := d.encodeFieldTextAt(d.lineIndent(owner.span.Start.Offset), true, encodedKey, v)
+		if err != nil {
+			return err
+		}
+		return d.apply(spanEdit(owner.span, listItemPrefix+text))
+	}
+	return pathTypeError(segments[:len(segments)-1], "not an object")
+}
+
+// deleteFieldEdit removes field f from obj.
+func (d *Document) deleteFieldEdit(obj *ObjectNode, f *FieldNode) textEdit {
+	if !d.onHyphenLine(f) {
+		return d.removeLines(f.span)
+	}
+	if len(obj.Fields) > 1 {
+		// Pull the next field up onto the hyphen line.
+		return textEdit{start: f.span.Start.Offset, end: obj.Fields[1].span.Start.Offset}
+	}
+	hyphen := d.lineStart(f.span.Start.Offset) + d.lineIndent(f.span.Start.Offset)
+	return textEdit{start: hyphen, end: f.span.End.Offset, text: listItemMarker}
+}
+
+// insertElementEdit inserts a row or list item before position index of arr.
+func (d *Document) insertElementEdit(arr *ArrayNode, index int, text string) textEdit {
+	indent := d.lineIndent(arr.Header.span.Start.Offset) + d.indent
+	if len(arr.Items) > 0 {
+		indent = d.lineIndent(arr.Items[0].Span().Start.Offset)
+	}
+	text = strings.Repeat(space, indent) + indentContinuation(text, indent)
+
+	if index < len(arr.Items) {
+		at := d.lineStart(arr.Items[index].Span().Start.Offset)
+		return textEdit{start: at, end: at, text: text + newline}
+	}
+	at := d.lineEnd(arr.span.End.Offset)
+	return textEdit{start: at, end: at, text: newline + text}
+}
+
+// lengthEdit rewrites the declared length of header h to n.
+func (d *Document) lengthEdit(h *ArrayHeader, n int) textEdit {
+	start := h.span.Start.Offset + len(openBracket) + len(h.LengthMarker)
+	end := start
+	for end < len(d.source) && isDigit(rune(d.source[end])) {
+		end++
+	}
+	return textEdit{start: start, end: end, text: strconv.Itoa(n)}
+}
+
+// scalarEdit replaces a scalar with the encoding of v.
+func (d *Document) scalarEdit(s *ScalarNode, v Value, delimiter string) (textEdit, error) {
+	encoded, err := encodePrimitive(v, delimiter)
+	if err != nil {
+		return textEdit{}, err
+	}
+	return spanEdit(s.span, encoded), nil
+}
+
+// removeLines removes the full lines covered by span, including one newline.
+func (d *Document) removeLines(span Span) textEdit {
+	start := d.lineStart(span.Start.Offset)
+	end := d.lineEnd(span.End.Offset)
+	if end < len(d.source) {
+		end++
+	} else if start > 0 {
+		start--
+	}
+	return textEdit{start: start, end: end}
+}
+
+// encodeFieldText encodes "key: value" for replacing field f, or for a new
+// root field when f is nil.
+func (d *Document) encodeFieldText(f *FieldNode, key string, v Value) (string, error) {
+	if f == nil {
+		return d.encodeFieldTextAt(0, false, key, v)
+	}
+	return d.encodeFieldTextAt(d.lineIndent(f.span.Start.Offset), d.onHyphenLine(f), key, v)
+}
+
+// encodeFieldTextAt encodes "key: value" for a field on a line indented by
+// indent. Fields sitting on a hyphen line nest objects one level deeper.
+func (d *Document) encodeFieldTextAt(indent int, onHyphen bool, key string, v Value) (string, error) {
+	w := newWriter(d.indent)
+	if err := encodeValue(w, key, v, 0, d.encodeOptions()); err != nil {
+		return "", err
+	}
+	if onHyphen && isMap(v) {
+		indent += d.indent
+	}
+	return indentContinuation(w.String(), indent), nil
+}
+
+// encodeOptions returns the options used to encode new content.
+func (d *Document) encodeOptions() *EncodeOptions {
+	return getEncodeOptions(&EncodeOptions{Indent: d.indent})
+}
+
+// onHyphenLine reports whether f is the first field of a list item.
+func (d *Document) onHyphenLine(f *FieldNode) bool {
+	return f.span.Start.Column-1 != d.lineIndent(f.span.Start.Offset)
+}
+
+// fieldIndent returns the indentation shared by f and its sibling fields.
+func (d *Document) fieldIndent(f *FieldNode) int {
+	indent := d.lineIndent(f.span.Start.Offset)
+	if d.onHyphenLine(f) {
+		indent += d.indent
+	}
+	return indent
+}
+
+// lineStart returns the offset of the start of the line containing off.
+func (d *Document) lineStart(off int) int {
+	return strings.LastIndexByte(d.source[:off], '\n') + 1
+}
+
+// lineEnd returns the offset of the end of the line containing off.
+func (d *Document) lineEnd(off int) int {
+	if i := strings.IndexByte(d.source[off:], '\n'); i >= 0 {
+		return off + i
+	}
+	return len(d.source)
+}
+
+// lineIndent returns the number of leading spaces of the line containing off.
+func (d *Document) lineIndent(off int) int {
+	start := d.lineStart(off)
+	return skipSpaces(d.source, start) - start
+}
+
+// apply applies non-overlapping edits and re-parses the document.
+func (d *Document) apply(edits ...textEdit) error {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+
+	src := d.source
+	for _, e := range edits {
+		src = src[:e.start] + e.text + src[e.end:]
+	}
+
+	doc, err := parseDocument(src, d.opts)
+	if err != nil {
+		return err
+	}
+	*d = *doc
+	return nil
+}
+
+// spanEdit replaces the text covered by span.
+func spanEdit(span Span, text string) textEdit {
+	return textEdit{start: span.Start.Offset, end: span.End.Offset, text: text}
+}
+
+// indentContinuation prefixes every line after the first with indent spaces.
+func indentContinuation(text string, indent int) string {
+	if indent == 0 {
+		return text
+	}
+	return strings.ReplaceAll(text, newline, newline+strings.Repeat(space, indent))
+}
+
+// encodeRowCells encodes v as a tabular row for header h.
+func encodeRowCells(h *ArrayHeader, v Value) (string, error) {
+	if !isMap(v) {
+		return "", fmt.Errorf("row must be an object with fields %q", h.Fields)
+	}
+	m, err := convertToMapValue(v)
+	if err != nil {
+		return "", err
+	}
+	if len(m) != len(h.Fields) {
+		return "", fmt.Errorf("row must have exactly the fields %q", h.Fields)
+	}
+
+	cells := make([]string, len(h.Fields))
+	for i, field := range h.Fields {
+		val, ok := m[field]
+		if !ok || !isPrimitive(val) {
+			return "", fmt.Errorf("row field %q missing or not primitive", field)
+		}
+		encoded, err := encodePrimitive(val, h.Delimiter)
+		if err != nil {
+			return "", err
+		}
+		cells[i] = encoded
+	}
+	return strings.Join(cells, h.Delimiter), nil
+}
@@ -0,0 +1,182 @@
+package toon
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDocumentSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  string
+		value interface{}
+		want  string
+	}{
+		{"scalar", "name: Ada\nage: 3", "name", "Bob", "name: Bob\nage: 3"},
+		{"scalar needs quotes", "name: Ada\nage: 3", "name", "a,b", "name: \"a,b\"\nage: 3"},
+		{"keeps spacing", "a:  1\nb: 2", "b", 3, "a:  1\nb: 3"},
+		{"tabular cell", "users[2]{id,name}:\n  1,Ada\n  2,Bob", "users[1].name", "Bo", "users[2]{id,name}:\n  1,Ada\n  2,Bo"},
+		{"tabular cell pipe", "users[1|]{id|name}:\n  1|Ada", "users[0].name", "a,b", "users[1|]{id|name}:\n  1|a,b"},
+		{"inline cell", "tags[3]: a,b,c", "tags[1]", "x y", "tags[3]: a,x y,c"},
+		{"list item scalar", "items[2]:\n  - 1\n  - 2", "items[1]", true, "items[2]:\n  - 1\n  - true"},
+		{"list item field", "items[2]:\n  - id: 1\n    name: a\n  - x", "items[0].name", "b", "items[2]:\n  - id: 1\n    name: b\n  - x"},
+		{"new field", "a: 1", "b", 2, "a: 1\nb: 2"},
+		{"new nested field", "a:\n  x: 1\nb: 2", "a.y", 2, "a:\n  x: 1\n  y: 2\nb: 2"},
+		{"new field in empty object", "a:\nb: 2", "a.x", 1, "a:\n  x: 1\nb: 2"},
+		{"new field in empty document", "", "a", "x", "a: x"},
+		{"new quoted key", "a: 1", `"b c"`, 2, "a: 1\n\"b c\": 2"},
+		{"scalar to object", "a: 1\nb: 2", "a", map[string]interface{}{"x": 1}, "a:\n  x: 1\nb: 2"},
+		{"nested scalar to array", "o:\n  a: 1\n  b: 2", "o.a", []interface{}{1, 2}, "o:\n  a[2]: 1,2\n  b: 2"},
+		{"object to scalar", "a:\n  x: 1\n  y: 2\nb: 2", "a", "z", "a: z\nb: 2"},
+		{"tabular row", "users[2]{id,name}:\n  1,Ada\n  2,Bob", "users[0]", map[string]interface{}{"id": 9, "name": "Cy"}, "users[2]{id,name}:\n  9,Cy\n  2,Bob"},
+		{"list item to object", "items[2]:\n  - 1\n  - 2", "items[0]", map[string]interface{}{"a": 1}, "items[2]:\n  - a: 1\n  - 2"},
+		{"first list field to object", "items[1]:\n  - a: 1\n    b: 2", "items[0].a", map[string]interface{}{"x": 1}, "items[1]:\n  - a:\n      x: 1\n    b: 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseTreeFromString(tt.input)
+			if err != nil {
+				t.Fatalf("ParseTree() error = %v", err)
+			}
+			if err := doc.Set(tt.path, tt.value); err != nil {
+				t.Fatalf("Set(%q) error = %v", tt.path, err)
+			}
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("Set(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentDelete(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  string
+		want  string
+	}{
+		{"middle field", "a: 1\nb: 2\nc: 3", "b", "a: 1\nc: 3"},
+		{"last field", "a: 1\nb: 2\nc: 3", "c", "a: 1\nb: 2"},
+		{"nested object", "a:\n  x: 1\nb: 2", "a", "b: 2"},
+		{"tabular row", "users[2]{id,name}:\n  1,Ada\n  2,Bob\nn: 1", "users[0]", "users[1]{id,name}:\n  2,Bob\nn: 1"},
+		{"inline cell", "tags[#3|]: a|b|c", "tags[1]", "tags[#2|]: a|c"},
+		{"only inline cell", "tags[1]: a", "tags[0]", "tags[0]:"},
+		{"list item", "items[2]:\n  - a: 1\n    b: 2\n  - 3", "items[0]", "items[1]:\n  - 3"},
+		{"first list field", "items[1]:\n  - id: 1\n    name: a", "items[0].id", "items[1]:\n  - name: a"},
+		{"only list field", "items[1]:\n  - id: 1", "items[0].id", "items[1]:\n  -"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseTreeFromString(tt.input)
+			if err != nil {
+				t.Fatalf("ParseTree() error = %v", err)
+			}
+			if err := doc.Delete(tt.path); err != nil {
+				t.Fatalf("Delete(%q) error = %v", tt.path, err)
+			}
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("Delete(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentInsertRow(t *testing.T) {
+	input := "users[2]{id,name}:\n  1,Ada\n  2,Bob\nn: 1"
+	row := map[string]interface{}{"id": 3, "name": "Cy"}
+
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "users[3]{id,name}:\n  3,Cy\n  1,Ada\n  2,Bob\nn: 1"},
+		{1, "users[3]{id,name}:\n  1,Ada\n  3,Cy\n  2,Bob\nn: 1"},
+		{2, "users[3]{id,name}:\n  1,Ada\n  2,Bob\n  3,Cy\nn: 1"},
+	}
+
+	for _, tt := range tests {
+		doc, err := ParseTreeFromString(input)
+		if err != nil {
+			t.Fatalf("ParseTree() error = %v", err)
+		}
+		if err := doc.InsertRow("users", tt.index, row); err != nil {
+			t.Fatalf("InsertRow(%d) error = %v", tt.index, err)
+		}
+		if got := string(doc.Bytes()); got != tt.want {
+			t.Errorf("InsertRow(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+
+	doc, _ := ParseTreeFromString(input)
+	if err := doc.InsertRow("users", 0, map[string]interface{}{"id": 3}); !errors.Is(err, ErrPathType) {
+		t.Errorf("InsertRow() with missing field error = %v, want ErrPathType", err)
+	}
+	if err := doc.InsertRow("n", 0, row); !errors.Is(err, ErrPathType) {
+		t.Errorf("InsertRow() on scalar error = %v, want ErrPathType", err)
+	}
+}
+
+func TestDocumentAppendItem(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  string
+		value interface{}
+		want  string
+	}{
+		{"inline", "tags[2]: a,b", "tags", "c", "tags[3]: a,b,c"},
+		{"empty inline", "tags[0]:\nn: 1", "tags", "c", "tags[1]: c\nn: 1"},
+		{"tabular", "u[1]{id}:\n  1", "u", map[string]interface{}{"id": 2}, "u[2]{id}:\n  1\n  2"},
+		{"list", "items[1]:\n  - a: 1\nn: 1", "items", map[string]interface{}{"b": 2}, "items[2]:\n  - a: 1\n  - b: 2\nn: 1"},
+		{"inline to list", "o:\n  tags[1]: a", "o.tags", map[string]interface{}{"b": 2}, "o:\n  tags[2]:\n    - a\n    - b: 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseTreeFromString(tt.input)
+			if err != nil {
+				t.Fatalf("ParseTree() error = %v", err)
+			}
+			if err := doc.AppendItem(tt.path, tt.value); err != nil {
+				t.Fatalf("AppendItem(%q) error = %v", tt.path, err)
+			}
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("AppendItem(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentEditErrors(t *testing.T) {
+	doc, err := ParseTreeFromString("a: 1\nt[1]: x\nu[1]{id}:\n  1")
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+
+	if err := doc.Set("missing.x", 1); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("Set() error = %v, want ErrPathNotFound", err)
+	}
+	if err := doc.Set("t[5]", 1); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("Set() error = %v, want ErrPathNotFound", err)
+	}
+	if err := doc.Set("a.b", 1); !errors.Is(err, ErrPathType) {
+		t.Errorf("Set() error = %v, want ErrPathType", err)
+	}
+	if err := doc.Set("u[0].id", []interface{}{1}); !errors.Is(err, ErrPathType) {
+		t.Errorf("Set() error = %v, want ErrPathType", err)
+	}
+	if err := doc.Delete("a["); err == nil {
+		t.Error("Delete() with invalid path expected error")
+	}
+
+	var pathErr *PathError
+	if err := doc.Delete("nope"); !errors.As(err, &pathErr) || pathErr.Path != "nope" {
+		t.Errorf("Delete() error = %v, want PathError for %q", err, "nope")
+	}
+	if got := string(doc.Bytes()); got != "a: 1\nt[1]: x\nu[1]{id}:\n  1" {
+		t.Errorf("failed edits changed the document: %q", got)
+	}
+}
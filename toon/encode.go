@@ -23,6 +23,11 @@ func encodeValue(w *writer, key string, v Value, depth int, opts *EncodeOptions)
 		return encodeValuePrimitive(w, key, v, depth, opts)
 	}
 
+	if raw, ok := v.(RawValue); ok {
+		encodeRawValue(w, key, raw, depth, depth+1)
+		return nil
+	}
+
 	if isMap(v) {
 		return encodeValueMap(w, key, v, depth, opts)
 	}
@@ -15,6 +15,12 @@ func encodeArray(w *writer, key string, v Value, depth int, opts *EncodeOptions)
 
 	// Detect format
 	format := detectArrayFormat(v)
+	if format == arrayFormatInline || format == arrayFormatTabular {
+		var err error
+		if opts, err = withArrayDelimiter(opts, arrayCells(v, format)); err != nil {
+			return err
+		}
+	}
 
 	switch format {
 	case arrayFormatEmpty:
@@ -43,7 +49,8 @@ func detectArrayFormat(v Value) arrayFormat {
 		return arrayFormatInline
 	}
 
-	if allMaps(v) && sameKeys(v) && allMapValuesPrimitive(v) {
+	// Objects without keys have no fields for a header and stay list items
+	if allMaps(v) && sameKeys(v) && allMapValuesPrimitive(v) && len(getMapKeys(rv.Index(0).Interface())) > 0 {
 		return arrayFormatTabular
 	}
 
@@ -123,12 +130,6 @@ func encodeInlineArray(w *writer, key string, v Value, depth int, opts *EncodeOp
 	rv := reflect.ValueOf(v)
 	length := rv.Len()
 
-	lengthMarker := formatLengthMarker(length, opts.LengthMarker)
-	delimiterMarker := ""
-	if opts.Delimiter != comma {
-		delimiterMarker = opts.Delimiter
-	}
-
 	// Encode values
 	values := make([]string, length)
 	for i := 0; i < length; i++ {
@@ -141,14 +142,7 @@ func encodeInlineArray(w *writer, key string, v Value, depth int, opts *EncodeOp
 	}
 
 	joined := strings.Join(values, opts.Delimiter)
-
-	if key != "" {
-		line := key + openBracket + lengthMarker + delimiterMarker + closeBracket + colon + space + joined
-		w.push(line, depth)
-	} else {
-		line := openBracket + lengthMarker + delimiterMarker + closeBracket + colon + space + joined
-		w.push(line, depth)
-	}
+	w.push(formatArrayHeader(key, length, nil, opts)+space+joined, depth)
 
 	return nil
 }
@@ -162,8 +156,34 @@ func encodeTabularArray(w *writer, key string, v Value, depth int, opts *EncodeO
 		return encodeEmptyArray(w, key, depth, opts)
 	}
 
-	// Get keys from first object
-	first := rv.Index(0).Interface()
+	keys := tabularKeys(rv.Index(0).Interface())
+	w.push(formatArrayHeader(key, length, keys, opts), depth)
+
+	// Format data rows
+	for i := 0; i < length; i++ {
+		item := rv.Index(i).Interface()
+
+		values := make([]string, len(keys))
+		for j, k := range keys {
+			encoded, err := encodePrimitive(tabularCell(item, k), opts.Delimiter)
+			if err != nil {
+				return err
+			}
+			values[j] = encoded
+		}
+
+		row := strings.Join(values, opts.Delimiter)
+		w.enter(pathSegment{index: i, isIndex: true}, item)
+		w.push(row, depth+1)
+		w.leave()
+	}
+
+	return nil
+}
+
+// tabularKeys returns the field names of a tabular array from its first
+// object: insertion order for OrderedMap, sorted for plain maps.
+func tabularKeys(first Value) []string {
 	var keys []string
 
 	// Handle OrderedMap vs regular map
@@ -180,61 +200,25 @@ func encodeTabularArray(w *writer, key string, v Value, depth int, opts *EncodeO
 		sortStrings(keys)
 	}
 
-	// Format header
-	lengthMarker := formatLengthMarker(length, opts.LengthMarker)
-	delimiterMarker := ""
-	if opts.Delimiter != comma {
-		delimiterMarker = opts.Delimiter
-	}
-
-	encodedKeys := make([]string, len(keys))
-	for i, k := range keys {
-		encodedKeys[i] = encodeKey(k)
+	// Objects without keys still get an empty field list
+	if keys == nil {
+		keys = []string{}
 	}
-	fields := strings.Join(encodedKeys, opts.Delimiter)
+	return keys
+}
 
-	var header string
-	if key != "" {
-		header = key + openBracket + lengthMarker + delimiterMarker + closeBracket +
-			openBrace + fields + closeBrace + colon
-	} else {
-		header = openBracket + lengthMarker + delimiterMarker + closeBracket +
-			openBrace + fields + closeBrace + colon
+// tabularCell returns the value of field k of a tabular row.
+func tabularCell(item Value, k string) Value {
+	// Handle OrderedMap vs regular map
+	if orderedMap, ok := item.(OrderedMap); ok {
+		val, _ := orderedMap.Get(k)
+		return val
 	}
-
-	w.push(header, depth)
-
-	// Format data rows
-	for i := 0; i < length; i++ {
-		item := rv.Index(i).Interface()
-
-		values := make([]string, len(keys))
-		for j, k := range keys {
-			var val interface{}
-
-			// Handle OrderedMap vs regular map
-			if orderedMap, ok := item.(OrderedMap); ok {
-				val, _ = orderedMap.Get(k)
-			} else if orderedMapPtr, ok := item.(*OrderedMap); ok {
-				val, _ = orderedMapPtr.Get(k)
-			} else {
-				itemRv := reflect.ValueOf(item)
-				mapKey := reflect.ValueOf(k)
-				val = itemRv.MapIndex(mapKey).Interface()
-			}
-
-			encoded, err := encodePrimitive(val, opts.Delimiter)
-			if err != nil {
-				return err
-			}
-			values[j] = encoded
-		}
-
-		row := strings.Join(values, opts.Delimiter)
-		w.push(row, depth+1)
+	if orderedMapPtr, ok := item.(*OrderedMap); ok {
+		val, _ := orderedMapPtr.Get(k)
+		return val
 	}
-
-	return nil
+	return reflect.ValueOf(item).MapIndex(reflect.ValueOf(k)).Interface()
 }
 
 // encodeListArray encodes an array in list format (for mixed or non-uniform arrays).
@@ -242,27 +226,17 @@ func encodeListArray(w *writer, key string, v Value, depth int, opts *EncodeOpti
 	rv := reflect.ValueOf(v)
 	length := rv.Len()
 
-	lengthMarker := formatLengthMarker(length, opts.LengthMarker)
-	delimiterMarker := ""
-	if opts.Delimiter != comma {
-		delimiterMarker = opts.Delimiter
-	}
-
 	// Write header
-	var header string
-	if key != "" {
-		header = key + openBracket + lengthMarker + delimiterMarker + closeBracket + colon
-	} else {
-		header = openBracket + lengthMarker + delimiterMarker + closeBracket + colon
-	}
-	w.push(header, depth)
+	w.push(formatArrayHeader(key, length, nil, opts), depth)
 
 	// Encode each item
 	for i := 0; i < length; i++ {
 		item := rv.Index(i).Interface()
+		w.enter(pathSegment{index: i, isIndex: true}, item)
 		if err := encodeListItem(w, item, depth+1, opts, true); err != nil {
 			return err
 		}
+		w.leave()
 	}
 
 	return nil
@@ -274,6 +248,11 @@ func encodeListItem(w *writer, item Value, depth int, opts *EncodeOptions, _ boo
 		return encodeListItemPrimitive(w, item, depth, opts)
 	}
 
+	if raw, ok := item.(RawValue); ok {
+		encodeListItemRaw(w, raw, depth)
+		return nil
+	}
+
 	if isList(item) {
 		return encodeListItemArray(w, item, depth, opts)
 	}
@@ -300,6 +279,13 @@ func encodeListItemArray(w *writer, item Value, depth int, opts *EncodeOptions)
 	rv := reflect.ValueOf(item)
 	length := rv.Len()
 
+	if length > 0 && allPrimitives(item) {
+		var err error
+		if opts, err = withArrayDelimiter(opts, arrayCells(item, arrayFormatInline)); err != nil {
+			return err
+		}
+	}
+
 	delimiterMarker := ""
 	if opts.Delimiter != comma {
 		delimiterMarker = opts.Delimiter
@@ -350,9 +336,11 @@ func encodeListItemNestedArray(w *writer, rv reflect.Value, length int, delimite
 	// Recursively encode nested items
 	for i := 0; i < length; i++ {
 		nested := rv.Index(i).Interface()
+		w.enter(pathSegment{index: i, isIndex: true}, nested)
 		if err := encodeListItem(w, nested, depth+1, opts, false); err != nil {
 			return err
 		}
+		w.leave()
 	}
 
 	return nil
@@ -362,6 +350,12 @@ func encodeListItemNestedArray(w *writer, rv reflect.Value, length int, delimite
 func encodeListItemMap(w *writer, item Value, depth int, opts *EncodeOptions) error {
 	keys, itemRv := extractMapKeysAndValues(item)
 
+	// Empty object: a bare list marker
+	if len(keys) == 0 {
+		w.push(listItemMarker, depth)
+		return nil
+	}
+
 	// Calculate alignment offset for subsequent keys (list marker "- " is 2 chars)
 	alignmentOffset := 2
 
@@ -370,6 +364,7 @@ func encodeListItemMap(w *writer, item Value, depth int, opts *EncodeOptions) er
 		val := itemRv.MapIndex(mapKey).Interface()
 		encodedKey := encodeKey(k)
 
+		w.enter(pathSegment{key: k}, val)
 		if idx == 0 {
 			if err := encodeListItemMapFirstKey(w, encodedKey, val, depth, opts); err != nil {
 				return err
@@ -379,6 +374,7 @@ func encodeListItemMap(w *writer, item Value, depth int, opts *EncodeOptions) er
 				return err
 			}
 		}
+		w.leave()
 	}
 
 	return nil
@@ -418,13 +414,18 @@ func encodeListItemMapFirstKey(w *writer, encodedKey string, val Value, depth in
 		return nil
 	}
 
+	if raw, ok := val.(RawValue); ok {
+		encodeRawValue(w, listItemPrefix+encodedKey, raw, depth, depth+2)
+		return nil
+	}
+
 	if isList(val) {
 		return encodeArray(w, listItemPrefix+encodedKey, val, depth, opts)
 	}
 
 	// Complex value (object)
 	w.push(listItemPrefix+encodedKey+colon, depth)
-	return encodeValue(w, "", val, depth+1, opts)
+	return encodeValue(w, "", val, depth+2, opts)
 }
 
 // encodeListItemMapSubsequentKey encodes subsequent key-value pairs in a map list item.
@@ -442,8 +443,13 @@ func encodeListItemMapSubsequentKey(w *writer, encodedKey string, val Value, dep
 		return nil
 	}
 
+	if raw, ok := val.(RawValue); ok {
+		encodeRawValue(w, encodedKey, raw, depth+1, depth+2)
+		return nil
+	}
+
 	if isList(val) {
-		return encodeArray(w, alignedLine, val, depth, opts)
+		return encodeArray(w, encodedKey, val, depth+1, opts)
 	}
 
 	// Complex value (object)
@@ -451,6 +457,40 @@ func encodeListItemMapSubsequentKey(w *writer, encodedKey string, val Value, dep
 	return encodeValue(w, "", val, effectiveDepth+1, opts)
 }
 
+// formatArrayHeader formats an array header such as key[3|]{a|b}: with the
+// length marker and delimiter from opts. fields is nil for inline and list
+// arrays.
+func formatArrayHeader(key string, length int, fields []string, opts *EncodeOptions) string {
+	return formatHeader(key, formatLengthMarker(length, opts.LengthMarker), fields, opts)
+}
+
+// formatHeader formats an array header with the given length text, which
+// includes the length marker.
+func formatHeader(key, length string, fields []string, opts *EncodeOptions) string {
+	var b strings.Builder
+	b.WriteString(key)
+	b.WriteString(openBracket)
+	b.WriteString(length)
+	if opts.Delimiter != comma {
+		b.WriteString(opts.Delimiter)
+	}
+	b.WriteString(closeBracket)
+
+	if fields != nil {
+		b.WriteString(openBrace)
+		for i, f := range fields {
+			if i > 0 {
+				b.WriteString(opts.Delimiter)
+			}
+			b.WriteString(encodeKey(f))
+		}
+		b.WriteString(closeBrace)
+	}
+
+	b.WriteString(colon)
+	return b.String()
+}
+
 // formatLengthMarker formats the length marker with optional prefix.
 func formatLengthMarker(length int, marker string) string {
 	if marker == "" {
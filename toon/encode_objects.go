@@ -62,9 +62,11 @@ func encodeObject(w *writer, key string, v Value, depth int, opts *EncodeOptions
 
 		encodedKey := encodeKey(k)
 
+		w.enter(pathSegment{key: k}, mapValue)
 		if err := encodeValue(w, encodedKey, mapValue, depth, opts); err != nil {
 			return err
 		}
+		w.leave()
 	}
 
 	return nil
@@ -92,7 +94,15 @@ func flattenObject(obj map[string]Value, currentPath string, depth int, opts *En
 		return obj, nil
 	}
 
-	for key, value := range obj {
+	// Visit keys in sorted order so collisions resolve the same way every run
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sortStrings(keys)
+
+	for _, key := range keys {
+		value := obj[key]
 		fullPath := buildFullPath(currentPath, key)
 		segmentCount := countPathSegments(currentPath)
 
@@ -112,8 +122,13 @@ func flattenObject(obj map[string]Value, currentPath string, depth int, opts *En
 				return nil, err
 			}
 			// Merge nested results
-			for k, v := range nested {
-				if err := addToResultWithCollisionCheck(result, k, v, opts); err != nil {
+			nestedKeys := make([]string, 0, len(nested))
+			for k := range nested {
+				nestedKeys = append(nestedKeys, k)
+			}
+			sortStrings(nestedKeys)
+			for _, k := range nestedKeys {
+				if err := addToResultWithCollisionCheck(result, k, nested[k], opts); err != nil {
 					return nil, err
 				}
 			}
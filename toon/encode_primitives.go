@@ -1,6 +1,7 @@
 package toon
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"strconv"
@@ -42,6 +43,16 @@ func encodePrimitive(v Value, delimiter string) (string, error) {
 	case float32, float64:
 		return encodeFloat(val)
 
+	case json.Number:
+		// Keep the source text, e.g. 1.50 or 1e-7
+		if !isJSONNumber(string(val)) {
+			return "", &EncodeError{Message: "invalid number", Value: val}
+		}
+		if f, err := val.Float64(); err == nil && f == 0 && val[0] == '-' {
+			return "0", nil
+		}
+		return string(val), nil
+
 	default:
 		return "", &EncodeError{
 			Message: "unsupported primitive type",
@@ -68,7 +79,7 @@ func encodeFloat(v Value) (string, error) {
 	}
 
 	// Check if it's a whole number
-	if f == math.Trunc(f) && f >= math.MinInt64 && f <= math.MaxInt64 {
+	if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
 		return strconv.FormatInt(int64(f), 10), nil
 	}
 
@@ -84,6 +95,49 @@ func encodeFloat(v Value) (string, error) {
 	return str, nil
 }
 
+// isJSONNumber reports whether s is a number in JSON syntax.
+func isJSONNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && s[i] >= '1' && s[i] <= '9':
+		for i < len(s) && isDigit(rune(s[i])) {
+			i++
+		}
+	default:
+		return false
+	}
+
+	if i < len(s) && s[i] == '.' {
+		i++
+		if i == len(s) || !isDigit(rune(s[i])) {
+			return false
+		}
+		for i < len(s) && isDigit(rune(s[i])) {
+			i++
+		}
+	}
+
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if i == len(s) || !isDigit(rune(s[i])) {
+			return false
+		}
+		for i < len(s) && isDigit(rune(s[i])) {
+			i++
+		}
+	}
+
+	return i == len(s)
+}
+
 // encodeString encodes a string value, adding quotes if necessary.
 func encodeString(s string, delimiter string) string {
 	if needsQuoting(s, delimiter) {
@@ -0,0 +1,84 @@
+package toon
+
+import "strings"
+
+// encodeRawValue writes a RawValue verbatim under key at the given depth.
+// Object fields are written at fieldDepth; array rows keep their own
+// indentation relative to the header line.
+func encodeRawValue(w *writer, key string, raw RawValue, depth, fieldDepth int) {
+	lines := preprocessLines(string(raw))
+	if len(lines) == 0 {
+		if key != "" {
+			w.push(key+colon, depth)
+		}
+		return
+	}
+
+	switch detectLinesRootType(lines) {
+	case rootTypePrimitive:
+		if key == "" {
+			w.push(lines[0].content, depth)
+		} else {
+			w.push(key+colon+space+lines[0].content, depth)
+		}
+
+	case rootTypeArray:
+		w.push(key+lines[0].content, depth)
+		pushRawLines(w, lines[1:], depth)
+
+	default:
+		if key == "" {
+			fieldDepth = depth
+		} else {
+			w.push(key+colon, depth)
+		}
+		pushRawLines(w, lines, fieldDepth)
+	}
+}
+
+// encodeListItemRaw writes a RawValue as a list item.
+func encodeListItemRaw(w *writer, raw RawValue, depth int) {
+	lines := preprocessLines(string(raw))
+	if len(lines) == 0 {
+		w.push(listItemMarker, depth)
+		return
+	}
+
+	w.push(listItemPrefix+lines[0].content, depth)
+
+	switch detectLinesRootType(lines) {
+	case rootTypeArray:
+		// Rows sit one level below the item, as encodeListItemArray writes them.
+		pushRawRows(w, lines[1:], depth+1)
+	case rootTypeObject:
+		// Sibling fields of an object item align with the first field after "- ".
+		pushRawLines(w, lines[1:], depth+1)
+	default:
+		pushRawLines(w, lines[1:], depth)
+	}
+}
+
+// pushRawRows writes the rows of a raw array with the first row at depth,
+// keeping the indentation of later rows relative to it.
+func pushRawRows(w *writer, lines []lineInfo, depth int) {
+	base := -1
+	for _, line := range lines {
+		if line.isBlank {
+			continue
+		}
+		if base < 0 {
+			base = line.indent
+		}
+		w.push(strings.Repeat(space, max(line.indent-base, 0))+line.content, depth)
+	}
+}
+
+// pushRawLines writes lines at depth, keeping their relative indentation.
+func pushRawLines(w *writer, lines []lineInfo, depth int) {
+	for _, line := range lines {
+		if line.isBlank {
+			continue
+		}
+		w.push(line.original, depth)
+	}
+}
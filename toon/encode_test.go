@@ -24,6 +24,51 @@ func TestFlattenObjectCollisionStrict(t *testing.T) {
 	}
 }
 
+func TestFlattenObjectCollisionDeterministic(t *testing.T) {
+	obj := map[string]Value{
+		"a":   map[string]Value{"b": int64(1)},
+		"a.b": int64(2),
+	}
+
+	// Keys are visited in sorted order, so "a.b" always wins over "a" > "b"
+	for i := 0; i < 50; i++ {
+		result, err := flattenObject(obj, "", 0, &EncodeOptions{})
+		if err != nil {
+			t.Fatalf("flattenObject() error = %v", err)
+		}
+		if got := result["a.b"]; got != int64(2) {
+			t.Fatalf("run %d: result[\"a.b\"] = %v, want 2", i, got)
+		}
+	}
+}
+
+func TestWithFlattenPathsDefaultDepth(t *testing.T) {
+	v := map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}}
+
+	tests := []struct {
+		name string
+		opts []EncodeOption
+		want string
+	}{
+		{"unlimited by default", []EncodeOption{WithFlattenPaths(true)}, "a.b.c: 1"},
+		{"explicit depth", []EncodeOption{WithFlattenPaths(true), WithFlattenDepth(2)}, "a.b:\n  c: 1"},
+		{"depth before enabling", []EncodeOption{WithFlattenDepth(2), WithFlattenPaths(true)}, "a.b:\n  c: 1"},
+		{"zero depth", []EncodeOption{WithFlattenPaths(true), WithFlattenDepth(0)}, "a:\n  b:\n    c: 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalToString(v, tt.opts...)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Marshal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEscapeAndEncodeStringRoundTrip(t *testing.T) {
 	orig := "hello\tback\\slash\"newline\nend"
 	esc := escapeString(orig)
@@ -1,6 +1,9 @@
 package toon
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // EncodeError represents an error that occurred during encoding.
 type EncodeError struct {
@@ -66,3 +69,56 @@ func (e *DecodeError) Error() string {
 func (e *DecodeError) Unwrap() error {
 	return e.Cause
 }
+
+// ErrPathNotFound is the cause of a PathError when a path does not exist.
+var ErrPathNotFound = errors.New("path not found")
+
+// ErrPathType is the cause of a PathError when a path exists but the value
+// there has the wrong type for the requested operation.
+var ErrPathType = errors.New("wrong type at path")
+
+// PathError represents an error that occurred while parsing or resolving a path.
+type PathError struct {
+	Path    string
+	Message string
+	Cause   error
+}
+
+// Error implements the error interface.
+func (e *PathError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("%s (path: '%s')", e.Message, e.Path)
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying error.
+func (e *PathError) Unwrap() error {
+	return e.Cause
+}
+
+// ErrTestFailed is the cause of a PatchError when a "test" operation does
+// not match the document.
+var ErrTestFailed = errors.New("test operation failed")
+
+// PatchError represents an error that occurred while applying a JSON Patch.
+type PatchError struct {
+	Index   int    // position of the failing operation in the patch
+	Op      string // operation name, e.g. "add"
+	Path    string // JSON Pointer of the operation
+	Message string
+	Cause   error
+}
+
+// Error implements the error interface.
+func (e *PatchError) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("patch operation %d: %s", e.Index, e.Message)
+	}
+	return fmt.Sprintf("patch operation %d (%s '%s'): %s", e.Index, e.Op, e.Path, e.Message)
+}
+
+// Unwrap returns the underlying error.
+func (e *PatchError) Unwrap() error {
+	return e.Cause
+}
@@ -52,3 +52,19 @@ func TestExpandDottedKeyNonStrictOverwrites(t *testing.T) {
 		t.Fatalf("unexpected value at a.b after overwrite: %v", a["b"])
 	}
 }
+
+func TestIsExpandablePath(t *testing.T) {
+	for key, want := range map[string]bool{
+		"a.b":    true,
+		"_x.y_1": true,
+		"a":      false,
+		"a..b":   false,
+		"a.1b":   false,
+		"a.b-c":  false,
+		"a.":     false,
+	} {
+		if got := IsExpandablePath(key); got != want {
+			t.Errorf("IsExpandablePath(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
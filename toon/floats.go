@@ -0,0 +1,336 @@
+package toon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode selects how FloatFormat drops digits.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest value, ties to an even digit.
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest value, ties away from zero.
+	RoundHalfUp
+	// RoundDown rounds toward zero, truncating the dropped digits.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+)
+
+// Notation selects how FloatFormat writes a float.
+type Notation int
+
+const (
+	// NotationPlain writes floats without an exponent, as in 0.00000015.
+	NotationPlain Notation = iota
+	// NotationExponent writes floats with one digit before the decimal
+	// point and an exponent, as in 1.5e-7.
+	NotationExponent
+	// NotationShortest writes the shorter of the two, preferring plain.
+	NotationShortest
+)
+
+// FloatFormat controls how floats are written. The zero value writes the
+// shortest decimal that reads back as the same float, in plain notation.
+//
+// Rounding works on that shortest decimal, so 2.675 rounds to 2.68 with
+// two decimals even though the nearest float64 is slightly below it.
+// Trailing zeros are dropped and whole results are written as integers.
+// Integers, including floats with a whole value, are left as they are.
+type FloatFormat struct {
+	// Digits is the number of significant digits kept, or of digits after
+	// the decimal point when Fixed is set (default: 0, all digits).
+	Digits int
+
+	// Fixed makes Digits count decimals instead of significant digits.
+	// With Digits 0, floats are rounded to integers.
+	Fixed bool
+
+	// Rounding selects how dropped digits round (default: RoundHalfEven).
+	Rounding RoundingMode
+
+	// Notation selects plain or exponent notation (default: NotationPlain).
+	Notation Notation
+}
+
+// formatsFloats reports whether opts set a float format.
+func formatsFloats(opts *EncodeOptions) bool {
+	return opts.FloatFormat != (FloatFormat{}) || len(opts.FloatFormats) > 0
+}
+
+// floatFormatter rewrites the floats of a normalized value as json.Number
+// text in their FloatFormat, recording each rounded value.
+type floatFormatter struct {
+	base        FloatFormat
+	overrides   map[string]FloatFormat
+	conversions []Conversion
+}
+
+// formatFloats applies the float formats of opts to v. It returns v
+// unchanged when no format is set.
+func formatFloats(v Value, opts *EncodeOptions) (Value, []Conversion, error) {
+	if !formatsFloats(opts) {
+		return v, nil, nil
+	}
+
+	f := &floatFormatter{base: opts.FloatFormat, overrides: make(map[string]FloatFormat, len(opts.FloatFormats))}
+	for p, format := range opts.FloatFormats {
+		segments, err := parsePath(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		f.overrides[formatPath(segments)] = format
+	}
+
+	formatted, err := f.format(v, nil, f.base)
+	if err != nil {
+		return nil, nil, err
+	}
+	return formatted, f.conversions, nil
+}
+
+// format returns v, at path, with its floats written in format or in the
+// format of the nearest enclosing override.
+func (f *floatFormatter) format(v Value, path []pathSegment, format FloatFormat) (Value, error) {
+	if override, ok := f.override(path); ok {
+		format = override
+	}
+
+	switch val := v.(type) {
+	case float64:
+		return f.formatNumber(val, strconv.FormatFloat(val, 'e', -1, 64), path, format), nil
+	case json.Number:
+		if !isJSONNumber(string(val)) {
+			return nil, &EncodeError{Message: "invalid number", Value: val}
+		}
+		// Numbers beyond the float64 range keep their source text
+		if _, err := val.Float64(); err == nil && strings.ContainsAny(string(val), ".eE") {
+			return f.formatNumber(val, string(val), path, format), nil
+		}
+		return val, nil
+	case []Value:
+		items := make([]Value, len(val))
+		for i, item := range val {
+			formatted, err := f.format(item, appendPath(path, pathSegment{index: i, isIndex: true}), format)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = formatted
+		}
+		return items, nil
+	}
+
+	keys, values, ok := objectFields(v)
+	if !ok {
+		return v, nil
+	}
+	formatted := make([]Value, len(keys))
+	for i, k := range keys {
+		field, err := f.format(values[i], appendPath(path, pathSegment{key: k}), format)
+		if err != nil {
+			return nil, err
+		}
+		formatted[i] = field
+	}
+
+	// Plain maps keep the encoder's own key order
+	if _, plain := v.(map[string]Value); plain {
+		m := make(map[string]Value, len(keys))
+		for i, k := range keys {
+			m[k] = formatted[i]
+		}
+		return m, nil
+	}
+	obj := NewOrderedMap()
+	for i, k := range keys {
+		obj.Set(k, formatted[i])
+	}
+	return obj, nil
+}
+
+// override returns the format set for path, matching it with its array
+// indexes first and without them second.
+func (f *floatFormatter) override(path []pathSegment) (FloatFormat, bool) {
+	if len(f.overrides) == 0 || len(path) == 0 {
+		return FloatFormat{}, false
+	}
+	if format, ok := f.overrides[formatPath(path)]; ok {
+		return format, true
+	}
+
+	var keys []pathSegment
+	for _, seg := range path {
+		if !seg.isIndex {
+			keys = append(keys, seg)
+		}
+	}
+	if len(keys) == len(path) {
+		return FloatFormat{}, false
+	}
+	format, ok := f.overrides[formatPath(keys)]
+	return format, ok
+}
+
+// formatNumber writes the number with decimal text s in format, recording
+// a conversion when digits are dropped.
+func (f *floatFormatter) formatNumber(v Value, s string, path []pathSegment, format FloatFormat) Value {
+	neg, digits, point := parseDecimal(s)
+
+	keep := len(digits)
+	if format.Fixed {
+		keep = point + format.Digits
+	} else if format.Digits > 0 {
+		keep = format.Digits
+	}
+	rounded := keep < len(digits)
+	if rounded {
+		digits, point = roundDecimal(digits, point, keep, format.Rounding)
+	}
+
+	result := json.Number(writeDecimal(neg, digits, point, format.Notation))
+	if rounded {
+		f.conversions = append(f.conversions, Conversion{Kind: ConversionFloatRounded, Path: formatPath(path), Old: v, New: result})
+	}
+	return result
+}
+
+// parseDecimal splits the decimal number s into its sign and the digits
+// and point position of 0.digits × 10^point. Digits carry no leading or
+// trailing zeros; zero has no digits.
+func parseDecimal(s string) (neg bool, digits string, point int) {
+	if strings.HasPrefix(s, "-") {
+		neg, s = true, s[1:]
+	}
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, _ = strconv.Atoi(strings.TrimPrefix(s[i+1:], "+"))
+		s = s[:i]
+	}
+
+	point = len(s)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		point = i
+		s = s[:i] + s[i+1:]
+	}
+	trimmed := strings.TrimLeft(s, "0")
+	point += exp - (len(s) - len(trimmed))
+	digits = strings.TrimRight(trimmed, "0")
+	if digits == "" {
+		return false, "", 0
+	}
+	return neg, digits, point
+}
+
+// roundDecimal rounds 0.digits × 10^point to its first n digits, where n
+// may be zero or negative for numbers below the rounding unit.
+func roundDecimal(digits string, point, n int, mode RoundingMode) (string, int) {
+	kept, rest := "", digits
+	if n > 0 {
+		kept, rest = digits[:n], digits[n:]
+	} else {
+		rest = strings.Repeat("0", -n) + digits
+	}
+
+	up := false
+	switch mode {
+	case RoundUp:
+		up = true
+	case RoundHalfUp:
+		up = rest[0] >= '5'
+	case RoundHalfEven:
+		odd := kept != "" && (kept[len(kept)-1]-'0')%2 == 1
+		up = rest[0] > '5' || rest[0] == '5' && (strings.TrimRight(rest[1:], "0") != "" || odd)
+	}
+
+	if up {
+		// Add one unit in the last kept place, carrying through nines
+		b := []byte(kept)
+		i := len(b) - 1
+		for ; i >= 0 && b[i] == '9'; i-- {
+			b[i] = '0'
+		}
+		switch {
+		case i >= 0:
+			b[i]++
+		case kept == "":
+			return "1", point - n + 1
+		default:
+			b = append([]byte{'1'}, b...)
+			point++
+		}
+		kept = string(b)
+	}
+
+	kept = strings.TrimRight(kept, "0")
+	if kept == "" {
+		return "", 0
+	}
+	return kept, point
+}
+
+// writeDecimal formats 0.digits × 10^point in notation.
+func writeDecimal(neg bool, digits string, point int, notation Notation) string {
+	if digits == "" {
+		return "0"
+	}
+
+	var s string
+	switch notation {
+	case NotationExponent:
+		s = exponentDecimal(digits, point)
+	case NotationShortest:
+		s = plainDecimal(digits, point)
+		if e := exponentDecimal(digits, point); len(e) < len(s) {
+			s = e
+		}
+	default:
+		s = plainDecimal(digits, point)
+	}
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+// plainDecimal writes 0.digits × 10^point without an exponent.
+func plainDecimal(digits string, point int) string {
+	switch {
+	case point <= 0:
+		return "0." + strings.Repeat("0", -point) + digits
+	case point >= len(digits):
+		return digits + strings.Repeat("0", point-len(digits))
+	default:
+		return digits[:point] + "." + digits[point:]
+	}
+}
+
+// exponentDecimal writes 0.digits × 10^point with one digit before the
+// decimal point and an exponent, omitted when it is zero.
+func exponentDecimal(digits string, point int) string {
+	s := digits[:1]
+	if len(digits) > 1 {
+		s += "." + digits[1:]
+	}
+	if point != 1 {
+		s += fmt.Sprintf("e%d", point-1)
+	}
+	return s
+}
+
+// validateFloatFormat checks the fields of a FloatFormat.
+func validateFloatFormat(f FloatFormat) error {
+	if f.Digits < 0 {
+		return &EncodeError{Message: "float digits must be non-negative", Value: f.Digits}
+	}
+	if f.Rounding < RoundHalfEven || f.Rounding > RoundUp {
+		return &EncodeError{Message: "invalid float rounding mode", Value: f.Rounding}
+	}
+	if f.Notation < NotationPlain || f.Notation > NotationShortest {
+		return &EncodeError{Message: "invalid float notation", Value: f.Notation}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package toon
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithFloatFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  interface{}
+		format FloatFormat
+		want   string
+	}{
+		{"zero value", 3.14159, FloatFormat{}, "3.14159"},
+		{"significant digits", 3.14159, FloatFormat{Digits: 3}, "3.14"},
+		{"significant digits of small number", 0.000123456, FloatFormat{Digits: 2}, "0.00012"},
+		{"significant digits of large number", 123456.7, FloatFormat{Digits: 2}, "120000"},
+		{"fixed decimals", 19.999, FloatFormat{Digits: 2, Fixed: true}, "20"},
+		{"fixed decimals drop trailing zeros", 19.901, FloatFormat{Digits: 2, Fixed: true}, "19.9"},
+		{"fixed zero decimals", 2.5, FloatFormat{Fixed: true}, "2"},
+		{"below the rounding unit", 0.004, FloatFormat{Digits: 2, Fixed: true}, "0"},
+		{"negative rounded to zero", -0.004, FloatFormat{Digits: 2, Fixed: true}, "0"},
+		{"round up below the unit", 0.004, FloatFormat{Digits: 2, Fixed: true, Rounding: RoundUp}, "0.01"},
+		{"half even tie down", 0.125, FloatFormat{Digits: 2, Fixed: true}, "0.12"},
+		{"half even tie up", 0.135, FloatFormat{Digits: 2, Fixed: true}, "0.14"},
+		{"rounding uses shortest decimal", 2.675, FloatFormat{Digits: 2, Fixed: true}, "2.68"},
+		{"half up", 0.125, FloatFormat{Digits: 2, Fixed: true, Rounding: RoundHalfUp}, "0.13"},
+		{"half up negative", -0.125, FloatFormat{Digits: 2, Fixed: true, Rounding: RoundHalfUp}, "-0.13"},
+		{"round down", 1.99, FloatFormat{Digits: 1, Fixed: true, Rounding: RoundDown}, "1.9"},
+		{"round up", 1.01, FloatFormat{Digits: 1, Fixed: true, Rounding: RoundUp}, "1.1"},
+		{"carry", 9.96, FloatFormat{Digits: 2}, "10"},
+		{"exponent", 0.00000015, FloatFormat{Notation: NotationExponent}, "1.5e-7"},
+		{"exponent of large number", 123456.5, FloatFormat{Notation: NotationExponent}, "1.234565e5"},
+		{"exponent without exponent", 1.5, FloatFormat{Notation: NotationExponent}, "1.5"},
+		{"exponent with digits", 123456.5, FloatFormat{Digits: 2, Notation: NotationExponent}, "1.2e5"},
+		{"shortest picks exponent", 0.00000015, FloatFormat{Notation: NotationShortest}, "1.5e-7"},
+		{"shortest picks plain", 0.15, FloatFormat{Notation: NotationShortest}, "0.15"},
+		{"integers are left alone", 12345, FloatFormat{Digits: 2, Notation: NotationExponent}, "12345"},
+		{"json number", json.Number("1.23456"), FloatFormat{Digits: 3}, "1.23"},
+		{"json number with exponent", json.Number("1.5E+3"), FloatFormat{Digits: 1}, "2000"},
+		{"json number integer", json.Number("123456"), FloatFormat{Digits: 2}, "123456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalToString(tt.input, WithFloatFormat(tt.format))
+			if err != nil {
+				t.Fatalf("MarshalToString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MarshalToString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFloatFormatAt(t *testing.T) {
+	data := NewOrderedMap()
+	data.Set("price", 19.995)
+	data.Set("readings", []interface{}{
+		map[string]interface{}{"temp": 21.456, "lat": 45.123456789},
+		map[string]interface{}{"temp": 22.04, "lat": 45.987654321},
+	})
+	data.Set("ratio", 0.333333)
+
+	got, err := MarshalToString(data,
+		WithFloatFormat(FloatFormat{Digits: 3}),
+		WithFloatFormatAt("price", FloatFormat{Digits: 2, Fixed: true}),
+		WithFloatFormatAt("readings.temp", FloatFormat{Digits: 1, Fixed: true}),
+		WithFloatFormatAt("readings[1]", FloatFormat{}),
+	)
+	if err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+	want := "price: 20\nreadings[2]{lat,temp}:\n  45.1,21.5\n  45.987654321,22\nratio: 0.333"
+	if got != want {
+		t.Errorf("MarshalToString() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWithFloatFormatReport(t *testing.T) {
+	data := NewOrderedMap()
+	data.Set("a", 1.25)
+	data.Set("b", 1.5)
+	data.Set("c", []interface{}{2.0, 3.14159})
+
+	var report EncodeReport
+	got, err := MarshalToString(data, WithFloatFormat(FloatFormat{Digits: 1, Fixed: true}), WithReport(&report))
+	if err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+	if want := "a: 1.2\nb: 1.5\nc[2]: 2,3.1"; got != want {
+		t.Errorf("MarshalToString() = %q, want %q", got, want)
+	}
+
+	want := []Conversion{
+		{Kind: ConversionFloatRounded, Path: "a", Old: 1.25, New: json.Number("1.2")},
+		{Kind: ConversionFloatRounded, Path: "c[1]", Old: 3.14159, New: json.Number("3.1")},
+	}
+	if !reflect.DeepEqual(report.Conversions, want) {
+		t.Errorf("Conversions = %#v, want %#v", report.Conversions, want)
+	}
+}
+
+func TestWithFloatFormatAndTokenBudget(t *testing.T) {
+	data := map[string]interface{}{
+		"pi":   3.14159,
+		"text": strings.Repeat("x", 100),
+	}
+
+	var report EncodeReport
+	_, err := MarshalToString(data,
+		WithFloatFormat(FloatFormat{Digits: 2}),
+		WithTokenBudget(50, byteTokenizer{}),
+		WithReport(&report),
+	)
+	if err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+
+	var kinds []ConversionKind
+	for _, c := range report.Conversions {
+		kinds = append(kinds, c.Kind)
+	}
+	want := []ConversionKind{ConversionFloatRounded, ConversionStringShortened}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("conversion kinds = %v, want %v", kinds, want)
+	}
+}
+
+func TestWithFloatFormatErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  EncodeOption
+		want string
+	}{
+		{"negative digits", WithFloatFormat(FloatFormat{Digits: -1}), "float digits must be non-negative"},
+		{"invalid rounding", WithFloatFormat(FloatFormat{Rounding: RoundingMode(9)}), "invalid float rounding mode"},
+		{"invalid notation", WithFloatFormatAt("a", FloatFormat{Notation: Notation(9)}), "invalid float notation"},
+		{"invalid path", WithFloatFormatAt("a[", FloatFormat{Digits: 2}), `invalid float format path "a["`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MarshalToString(1.5, tt.opt)
+			var encErr *EncodeError
+			if !errors.As(err, &encErr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("MarshalToString() error = %v, want EncodeError containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscodeJSONRejectsFloatFormat(t *testing.T) {
+	var out strings.Builder
+	err := TranscodeJSON(&out, strings.NewReader(`{"a":1.5}`), WithFloatFormat(FloatFormat{Digits: 1}))
+	if err == nil || !strings.Contains(err.Error(), "float formatting is not supported") {
+		t.Errorf("TranscodeJSON() error = %v, want float formatting error", err)
+	}
+}
@@ -0,0 +1,96 @@
+package toon
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Format rewrites a TOON document in the layout Marshal would produce.
+//
+// Indentation, delimiters, length markers and quoting follow opts, which are
+// the encode options of Marshal. Declared array lengths are replaced by the
+// actual item counts, and arrays are switched between list and tabular
+// layout where the encoder would choose differently. Field order is kept,
+// and numbers keep their source text (1.50 stays 1.50), so the formatted
+// document decodes to the same value as src. A repeated key keeps the
+// position of its first occurrence and its last value.
+//
+// The input is parsed without strict validation, so wrong array lengths and
+// uneven indentation are fixed rather than rejected. The result ends with a
+// newline unless the document is empty.
+//
+// Example:
+//
+//	out, err := toon.Format([]byte("users[3]{id,name}:\n    1,Ada\n    2,Bob"))
+//	// out: "users[2]{id,name}:\n  1,Ada\n  2,Bob\n"
+func Format(src []byte, opts ...EncodeOption) ([]byte, error) {
+	doc, err := parseDocument(string(src), &DecodeOptions{
+		Keys:        StringKeys,
+		IndentSize:  defaultIndent,
+		ExpandPaths: "off",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if doc.Root == nil {
+		return []byte{}, nil
+	}
+
+	var buf bytes.Buffer
+	if err := Marshal(formatValue(doc.Root), &buf, opts...); err != nil {
+		return nil, err
+	}
+	buf.WriteString(newline)
+	return buf.Bytes(), nil
+}
+
+// formatValue converts a syntax node into a value for re-encoding, with
+// objects as *OrderedMap and numbers as json.Number of their source text.
+func formatValue(n SyntaxNode) Value {
+	switch node := n.(type) {
+	case *ObjectNode:
+		obj := NewOrderedMap()
+		for _, f := range node.Fields {
+			obj.Set(f.Key, formatValue(f.Value))
+		}
+		return obj
+	case *FieldNode:
+		return formatValue(node.Value)
+	case *ArrayNode:
+		items := make([]Value, len(node.Items))
+		for i, item := range node.Items {
+			if row, ok := item.(*RowNode); ok {
+				items[i] = formatRow(node.Header.Fields, row)
+			} else {
+				items[i] = formatValue(item)
+			}
+		}
+		return items
+	case *ListItemNode:
+		return formatValue(node.Value)
+	case *RowNode:
+		return formatRow(nil, node)
+	case *ScalarNode:
+		switch node.Value.(type) {
+		case string, bool, nil:
+			return node.Value
+		}
+		if !node.Quoted && isJSONNumber(node.Raw) {
+			return json.Number(node.Raw)
+		}
+		return node.Value
+	default:
+		return nil
+	}
+}
+
+// formatRow converts a tabular row into an object with the header's field order.
+func formatRow(fields []string, row *RowNode) Value {
+	obj := NewOrderedMap()
+	for i, k := range fields {
+		if i < len(row.Cells) {
+			obj.Set(k, formatValue(row.Cells[i]))
+		}
+	}
+	return obj
+}
@@ -0,0 +1,151 @@
+package toon
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []EncodeOption
+		want  string
+	}{
+		{
+			name:  "indentation and counts",
+			input: "users[3]{id,name}:\n    1,Ada\n    2,Bob",
+			want:  "users[2]{id,name}:\n  1,Ada\n  2,Bob\n",
+		},
+		{
+			name:  "delimiters, quoting and number text",
+			input: "a[2|]: 1.50|\"x\"\nb: \"plain\"\nc: \"a,b\"",
+			want:  "a[2]: 1.50,x\nb: plain\nc: \"a,b\"\n",
+		},
+		{
+			name:  "list to tabular",
+			input: "items[2]:\n  - id: 1\n    n: x\n  - id: 2\n    n: y",
+			want:  "items[2]{id,n}:\n  1,x\n  2,y\n",
+		},
+		{
+			name:  "tabular with missing cell to list",
+			input: "rows[2]{a,b}:\n  1,2\n  3",
+			want:  "rows[2]:\n  - a: 1\n    b: 2\n  - a: 3\n",
+		},
+		{
+			name:  "field order and repeated keys",
+			input: "z: 1\na: 2\nz: 3",
+			want:  "z: 3\na: 2\n",
+		},
+		{
+			name:  "options",
+			input: "t[2]: a,b\nobj:\n  k: v",
+			opts:  []EncodeOption{WithIndent(4), WithDelimiter(tab), WithLengthMarker("#")},
+			want:  "t[#2\t]: a\tb\nobj:\n    k: v\n",
+		},
+		{
+			name:  "root primitive",
+			input: "  hello  ",
+			want:  "hello\n",
+		},
+		{
+			name:  "empty",
+			input: "\n\n",
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format([]byte(tt.input), tt.opts...)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Format() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatEmptyListItems(t *testing.T) {
+	for _, input := range []string{
+		"a[2]:\n  -\n  - x: 1\n",
+		"a[2]:\n  -\n  -\n",
+		"a[2]:\n  - x: 1\n  -\n",
+	} {
+		got, err := Format([]byte(input))
+		if err != nil {
+			t.Fatalf("Format(%q) error = %v", input, err)
+		}
+		if string(got) != input {
+			t.Errorf("Format(%q) = %q", input, got)
+		}
+
+		var v interface{}
+		if err := Unmarshal(bytes.NewReader(got), &v); err != nil {
+			t.Errorf("Unmarshal(Format(%q)) error = %v", input, err)
+		}
+		again, err := Format(got)
+		if err != nil || string(again) != string(got) {
+			t.Errorf("Format(Format(%q)) = %q, %v", input, again, err)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	if _, err := Format([]byte("a: \"unterminated")); err == nil {
+		t.Error("Format(invalid) error = nil, want error")
+	}
+	if _, err := Format([]byte("a: 1"), WithDelimiter(";")); err == nil {
+		t.Error("Format(invalid delimiter) error = nil, want error")
+	}
+}
+
+func TestFormatFixtures(t *testing.T) {
+	files, err := filepath.Glob("../testdata/fixtures/decode/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no decode fixtures found: %v", err)
+	}
+
+	for _, file := range files {
+		fixture, err := loadFixture(file)
+		if err != nil {
+			t.Fatalf("loadFixture(%s) error = %v", file, err)
+		}
+
+		for _, tt := range fixture.Tests {
+			decOpts := fixtureOptionsToDecodeOptions(tt.Options)
+			if tt.ShouldError || (decOpts != nil && decOpts.ExpandPaths == "safe") {
+				continue
+			}
+			input, ok := tt.Input.(string)
+			if !ok {
+				continue
+			}
+			t.Run(filepath.Base(file)+"/"+tt.Name, func(t *testing.T) {
+				formatted, err := Format([]byte(input))
+				if err != nil {
+					t.Fatalf("Format() error = %v", err)
+				}
+
+				var got interface{}
+				if err := UnmarshalFromString(string(formatted), &got); err != nil {
+					t.Fatalf("Unmarshal(%q) error = %v", formatted, err)
+				}
+				if !deepEqual(normalizeValue(got), normalizeValue(tt.Expected)) {
+					t.Errorf("Format() = %q, decodes to %#v, want %#v", formatted, got, tt.Expected)
+				}
+
+				again, err := Format(formatted)
+				if err != nil {
+					t.Fatalf("Format(formatted) error = %v", err)
+				}
+				if string(again) != string(formatted) {
+					t.Errorf("Format() is not idempotent:\n%q\n%q", formatted, again)
+				}
+			})
+		}
+	}
+}
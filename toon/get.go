@@ -0,0 +1,299 @@
+package toon
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Get returns the value at path inside v.
+//
+// The path syntax matches TOON keys: dotted keys, quoted segments for keys
+// that are not identifiers, and [i] for array elements, e.g.
+// `users[3].address."zip-code"`. The empty path returns v itself.
+//
+// v may be a decoded value (map[string]Value, map[string]interface{},
+// OrderedMap, *OrderedMap, []Value, []interface{}), a *Node, a *Document or
+// any SyntaxNode. Nodes are converted into decoded values on return; use
+// Node.At to keep the result as a *Node.
+//
+// Errors are *PathError values wrapping ErrPathNotFound when a key or index
+// does not exist, or ErrPathType when a value cannot be traversed.
+//
+// Example:
+//
+//	var data map[string]interface{}
+//	toon.UnmarshalFromString("users[2]{id,name}:\n  1,Ada\n  2,Bob", &data)
+//	name, err := toon.GetString(data, "users[1].name")
+//	// name == "Bob"
+func Get(v Value, path string) (Value, error) {
+	if n, ok := v.(*Node); ok {
+		found, err := n.At(path)
+		if err != nil {
+			return nil, err
+		}
+		return found.Value(), nil
+	}
+
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := v
+	if doc, ok := v.(*Document); ok {
+		cur = doc.Root
+		if cur == nil {
+			cur = map[string]Value{}
+		}
+	}
+
+	for i, seg := range segments {
+		cur, err = getStep(cur, seg, segments[:i+1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if n, ok := cur.(SyntaxNode); ok {
+		return syntaxNodeValue(n), nil
+	}
+	return cur, nil
+}
+
+// GetString returns the string at path inside v.
+func GetString(v Value, path string) (string, error) {
+	val, err := Get(v, path)
+	if err != nil {
+		return "", err
+	}
+	s, ok := val.(string)
+	if !ok {
+		return "", getTypeError(path, "string", val)
+	}
+	return s, nil
+}
+
+// GetInt64 returns the integer at path inside v.
+// Floats are accepted when they hold an integral value in range.
+func GetInt64(v Value, path string) (int64, error) {
+	val, err := Get(v, path)
+	if err != nil {
+		return 0, err
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() <= math.MaxInt64 {
+			return int64(rv.Uint()), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+	}
+	return 0, getTypeError(path, "integer", val)
+}
+
+// GetFloat64 returns the number at path inside v as a float64.
+func GetFloat64(v Value, path string) (float64, error) {
+	val, err := Get(v, path)
+	if err != nil {
+		return 0, err
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+	return 0, getTypeError(path, "number", val)
+}
+
+// GetBool returns the boolean at path inside v.
+func GetBool(v Value, path string) (bool, error) {
+	val, err := Get(v, path)
+	if err != nil {
+		return false, err
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return false, getTypeError(path, "boolean", val)
+	}
+	return b, nil
+}
+
+// GetSlice returns the array at path inside v.
+func GetSlice(v Value, path string) ([]Value, error) {
+	val, err := Get(v, path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch s := val.(type) {
+	case []Value:
+		return s, nil
+	case []interface{}:
+		result := make([]Value, len(s))
+		for i, item := range s {
+			result[i] = item
+		}
+		return result, nil
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, getTypeError(path, "array", val)
+	}
+	result := make([]Value, rv.Len())
+	for i := range result {
+		result[i] = rv.Index(i).Interface()
+	}
+	return result, nil
+}
+
+// GetMap returns the object at path inside v.
+// OrderedMap values are converted, so key order is not preserved.
+func GetMap(v Value, path string) (map[string]Value, error) {
+	val, err := Get(v, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if m, ok := val.(map[string]interface{}); ok {
+		result := make(map[string]Value, len(m))
+		for k, item := range m {
+			result[k] = item
+		}
+		return result, nil
+	}
+
+	m, err := convertToMapValue(val)
+	if err != nil {
+		return nil, getTypeError(path, "object", val)
+	}
+	return m, nil
+}
+
+// getStep resolves a single segment below cur; segments is used for errors.
+func getStep(cur Value, seg pathSegment, segments []pathSegment) (Value, error) {
+	switch n := cur.(type) {
+	case *FieldNode:
+		cur = n.Value
+	case *ListItemNode:
+		cur = n.Value
+	}
+
+	if seg.isIndex {
+		return getIndex(cur, seg.index, segments)
+	}
+	return getKey(cur, seg.key, segments)
+}
+
+// getKey looks up key in an object value or node.
+func getKey(cur Value, key string, segments []pathSegment) (Value, error) {
+	var (
+		val   Value
+		found bool
+	)
+
+	switch m := cur.(type) {
+	case map[string]Value:
+		val, found = m[key]
+	case map[string]interface{}:
+		val, found = m[key]
+	case OrderedMap:
+		val, found = m.Get(key)
+	case *OrderedMap:
+		val, found = m.Get(key)
+	case *ObjectNode:
+		if f := m.Field(key); f != nil {
+			val, found = f.Value, true
+		}
+	default:
+		rv := reflect.ValueOf(cur)
+		if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			return nil, pathTypeError(segments, fmt.Sprintf("cannot look up key %q in %s", key, valueKind(cur)))
+		}
+		if item := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key())); item.IsValid() {
+			val, found = item.Interface(), true
+		}
+	}
+
+	if !found {
+		return nil, pathNotFound(segments, fmt.Sprintf("key %q not found", key))
+	}
+	return val, nil
+}
+
+// getIndex looks up element index in an array value or node.
+func getIndex(cur Value, index int, segments []pathSegment) (Value, error) {
+	if arr, ok := cur.(*ArrayNode); ok {
+		if index >= len(arr.Items) {
+			return nil, pathNotFound(segments, fmt.Sprintf("index %d out of range (length %d)", index, len(arr.Items)))
+		}
+		switch item := arr.Items[index].(type) {
+		case *RowNode:
+			return rowValue(arr.Header.Fields, item), nil
+		case *ListItemNode:
+			return item.Value, nil
+		default:
+			return item, nil
+		}
+	}
+
+	rv := reflect.ValueOf(cur)
+	if cur == nil || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) {
+		return nil, pathTypeError(segments, fmt.Sprintf("cannot index %s", valueKind(cur)))
+	}
+	if index >= rv.Len() {
+		return nil, pathNotFound(segments, fmt.Sprintf("index %d out of range (length %d)", index, rv.Len()))
+	}
+	return rv.Index(index).Interface(), nil
+}
+
+// getTypeError reports that the value at path is not of the wanted kind.
+func getTypeError(path, want string, val Value) error {
+	return &PathError{
+		Path:    path,
+		Message: fmt.Sprintf("expected %s, got %s", want, valueKind(val)),
+		Cause:   ErrPathType,
+	}
+}
+
+// valueKind describes the kind of a value for error messages.
+func valueKind(v Value) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case *ObjectNode:
+		return "object"
+	case *ArrayNode:
+		return "array"
+	case *ScalarNode:
+		return valueKind(n.Value)
+	}
+
+	switch {
+	case isMap(v):
+		return "object"
+	case isList(v):
+		return "array"
+	case isPrimitive(v):
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,129 @@
+package toon
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const getTestInput = "name: Ada\nusers[2]{id,name}:\n  1,Ada\n  2,Bob\nitems[2]:\n  - id: 1\n    tags[2]: a,b\n  - 3.5\n\"odd key\":\n  ok: true"
+
+func TestGet(t *testing.T) {
+	var decoded map[string]interface{}
+	if err := UnmarshalFromString(getTestInput, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	doc, err := ParseTreeFromString(getTestInput)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want Value
+	}{
+		{"name", "Ada"},
+		{"users[1].name", "Bob"},
+		{"users[0]", map[string]Value{"id": int64(1), "name": "Ada"}},
+		{"items[0].tags[1]", "b"},
+		{"items[1]", 3.5},
+		{`"odd key".ok`, true},
+	}
+
+	for _, tt := range tests {
+		for name, v := range map[string]Value{"decoded": decoded, "document": doc, "root node": doc.Root} {
+			got, err := Get(v, tt.path)
+			if err != nil {
+				t.Errorf("Get(%s, %q) error = %v", name, tt.path, err)
+				continue
+			}
+			if !deepEqual(normalizeValue(got), normalizeValue(tt.want)) {
+				t.Errorf("Get(%s, %q) = %#v, want %#v", name, tt.path, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestGetOrderedMap(t *testing.T) {
+	inner := NewOrderedMap()
+	inner.Set("city", "Paris")
+	om := NewOrderedMap()
+	om.Set("address", inner)
+	om.Set("list", []interface{}{*inner})
+
+	for _, path := range []string{"address.city", "list[0].city"} {
+		for _, v := range []Value{om, *om} {
+			if got, err := GetString(v, path); err != nil || got != "Paris" {
+				t.Errorf("GetString(%T, %q) = %q, %v, want %q", v, path, got, err, "Paris")
+			}
+		}
+	}
+}
+
+func TestGetTyped(t *testing.T) {
+	doc, err := ParseTreeFromString(getTestInput)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+
+	if got, err := GetInt64(doc, "users[1].id"); err != nil || got != 2 {
+		t.Errorf("GetInt64() = %d, %v, want 2", got, err)
+	}
+	if got, err := GetFloat64(doc, "items[1]"); err != nil || got != 3.5 {
+		t.Errorf("GetFloat64() = %v, %v, want 3.5", got, err)
+	}
+	if got, err := GetBool(doc, `"odd key".ok`); err != nil || !got {
+		t.Errorf("GetBool() = %v, %v, want true", got, err)
+	}
+	if got, err := GetSlice(doc, "items[0].tags"); err != nil || !reflect.DeepEqual(got, []Value{"a", "b"}) {
+		t.Errorf("GetSlice() = %#v, %v", got, err)
+	}
+	if got, err := GetMap(doc, "users[0]"); err != nil || got["name"] != "Ada" {
+		t.Errorf("GetMap() = %#v, %v", got, err)
+	}
+	if got, err := GetInt64(map[string]interface{}{"n": 4.0}, "n"); err != nil || got != 4 {
+		t.Errorf("GetInt64() with integral float = %d, %v, want 4", got, err)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	data := map[string]interface{}{
+		"a": []interface{}{1, 2},
+		"s": "text",
+		"f": 1.5,
+	}
+
+	tests := []struct {
+		path string
+		want error
+		get  func(Value, string) error
+	}{
+		{"missing", ErrPathNotFound, nil},
+		{"a[2]", ErrPathNotFound, nil},
+		{"a.b", ErrPathType, nil},
+		{"s[0]", ErrPathType, nil},
+		{"s", ErrPathType, func(v Value, p string) error { _, err := GetInt64(v, p); return err }},
+		{"f", ErrPathType, func(v Value, p string) error { _, err := GetInt64(v, p); return err }},
+		{"a", ErrPathType, func(v Value, p string) error { _, err := GetMap(v, p); return err }},
+		{"s", ErrPathType, func(v Value, p string) error { _, err := GetBool(v, p); return err }},
+	}
+
+	for _, tt := range tests {
+		get := tt.get
+		if get == nil {
+			get = func(v Value, p string) error { _, err := Get(v, p); return err }
+		}
+		err := get(data, tt.path)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("path %q: error = %v, want %v", tt.path, err, tt.want)
+		}
+	}
+
+	var pathErr *PathError
+	if _, err := Get(data, "a[1].x"); !errors.As(err, &pathErr) || pathErr.Path != "a[1].x" {
+		t.Errorf("Get() error = %v, want PathError for %q", err, "a[1].x")
+	}
+	if _, err := Get(data, "a[x]"); err == nil {
+		t.Error("Get() with invalid path expected error")
+	}
+}
@@ -0,0 +1,157 @@
+package toon
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/sstraus/toon_go/toon/tokens"
+)
+
+// Cost is the size of one value of a document in its TOON encoding and
+// in compact JSON.
+type Cost struct {
+	// Path locates the value using Get path syntax; "" is the root.
+	Path string
+
+	// Bytes and Tokens measure the lines the encoder wrote for the value,
+	// including its key and the newline before them. The costs of an
+	// object's fields add up to the cost of the object minus its own key
+	// line.
+	Bytes  int
+	Tokens int
+
+	// JSONBytes and JSONTokens measure the value encoded alone as compact
+	// JSON, preceded by its key for object fields. A field folded by
+	// WithFlattenPaths is measured as the nested objects it stands for.
+	JSONBytes  int
+	JSONTokens int
+
+	// Children are the costs of the object fields, list items and table
+	// rows of the value, in output order. Elements of inline arrays are
+	// part of their array's line and have no costs of their own.
+	Children []*Cost
+
+	value      Value
+	keys       []string // key segments of an object field
+	start, end int
+}
+
+// Heatmap encodes v and returns the cost of every value in the output,
+// as a tree rooted at the document, to find the parts of a document that
+// make a prompt expensive.
+//
+// Costs are taken from the encoder's own output, so the bytes of the root
+// equal the length of Marshal's output with the same options. Tokens are
+// counted by tokenizer (default: tokens.Heuristic when nil) on each value's
+// output separately. A token budget in opts is ignored.
+//
+// Example:
+//
+//	cost, err := toon.Heatmap(data, nil)
+//	for _, field := range cost.Children {
+//		fmt.Println(field.Path, field.Tokens, field.JSONTokens)
+//	}
+func Heatmap(v Value, tokenizer tokens.Tokenizer, opts ...EncodeOption) (*Cost, error) {
+	normalized, encOpts, _, err := prepareEncode(v, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if tokenizer == nil {
+		tokenizer = tokens.Heuristic{}
+	}
+
+	root := &Cost{value: normalized}
+	w := newWriter(encOpts.Indent)
+	w.costs = &costTrace{stack: []*Cost{root}, flatten: encOpts.FlattenPaths}
+	if err := encodeValue(w, "", normalized, 0, encOpts); err != nil {
+		return nil, err
+	}
+	root.end = w.Len()
+
+	if err := measureCosts(root, w.String(), tokenizer); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// costTrace builds the cost tree while the writer encodes.
+type costTrace struct {
+	stack   []*Cost
+	path    []pathSegment
+	flatten bool // root keys may be folded paths
+}
+
+// enter starts a child of the current value at offset. A root key folded
+// by WithFlattenPaths is split back into the keys it was folded from.
+func (t *costTrace) enter(seg pathSegment, v Value, offset int) {
+	parent := t.stack[len(t.stack)-1]
+	c := &Cost{value: v, start: offset}
+
+	if seg.isIndex {
+		t.path = append(t.path, seg)
+	} else {
+		c.keys = []string{seg.key}
+		if t.flatten && len(t.stack) == 1 && strings.Contains(seg.key, ".") {
+			if _, err := getKey(parent.value, seg.key, nil); err != nil {
+				c.keys = strings.Split(seg.key, ".")
+			}
+		}
+		for _, k := range c.keys {
+			t.path = append(t.path, pathSegment{key: k})
+		}
+	}
+
+	c.Path = formatPath(t.path)
+	parent.Children = append(parent.Children, c)
+	t.stack = append(t.stack, c)
+}
+
+// leave ends the current value at offset.
+func (t *costTrace) leave(offset int) {
+	c := t.stack[len(t.stack)-1]
+	c.end = offset
+	t.stack = t.stack[:len(t.stack)-1]
+	t.path = t.path[:len(t.path)-max(len(c.keys), 1)]
+}
+
+// measureCosts fills in the sizes of c and its children from out.
+func measureCosts(c *Cost, out string, tokenizer tokens.Tokenizer) error {
+	text := out[c.start:c.end]
+	c.Bytes, c.Tokens = len(text), tokenizer.Count(text)
+
+	encoded, err := compactJSON(c.value)
+	if err != nil {
+		return err
+	}
+	// A folded field costs what the objects it was folded from cost
+	for i := len(c.keys) - 1; i >= 0; i-- {
+		key, err := compactJSON(c.keys[i])
+		if err != nil {
+			return err
+		}
+		encoded = key + colon + encoded
+		if i > 0 {
+			encoded = "{" + encoded + "}"
+		}
+	}
+	c.JSONBytes, c.JSONTokens = len(encoded), tokenizer.Count(encoded)
+
+	for _, child := range c.Children {
+		if err := measureCosts(child, out, tokenizer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactJSON encodes v as compact JSON without HTML escaping.
+func compactJSON(v Value) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", &EncodeError{Message: "JSON encoding failed", Cause: err}
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
@@ -0,0 +1,140 @@
+package toon
+
+import (
+	"reflect"
+	"testing"
+)
+
+// costPaths returns the paths of c and its descendants in output order.
+func costPaths(c *Cost) []string {
+	paths := []string{c.Path}
+	for _, child := range c.Children {
+		paths = append(paths, costPaths(child)...)
+	}
+	return paths
+}
+
+// findCost returns the cost at path inside c, or nil.
+func findCost(c *Cost, path string) *Cost {
+	if c.Path == path {
+		return c
+	}
+	for _, child := range c.Children {
+		if found := findCost(child, path); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func TestHeatmap(t *testing.T) {
+	data := NewOrderedMap()
+	data.Set("name", "report")
+	data.Set("users", budgetUsers(2))
+	data.Set("items", []interface{}{
+		map[string]interface{}{"x": 1, "tags": []interface{}{"a", "b"}},
+		"s",
+		[]interface{}{map[string]interface{}{"q": 1}},
+	})
+
+	for _, opts := range [][]EncodeOption{nil, {WithIndent(4), WithDelimiter("|")}} {
+		out, err := MarshalToString(data, opts...)
+		if err != nil {
+			t.Fatalf("MarshalToString() error = %v", err)
+		}
+		cost, err := Heatmap(data, byteTokenizer{}, opts...)
+		if err != nil {
+			t.Fatalf("Heatmap() error = %v", err)
+		}
+
+		if cost.Bytes != len(out) || cost.Tokens != len(out) {
+			t.Errorf("root cost = %d bytes, %d tokens, want %d", cost.Bytes, cost.Tokens, len(out))
+		}
+		sum := 0
+		for _, field := range cost.Children {
+			sum += field.Bytes
+		}
+		if sum != cost.Bytes {
+			t.Errorf("field bytes add up to %d, want %d", sum, cost.Bytes)
+		}
+	}
+
+	cost, err := Heatmap(data, byteTokenizer{})
+	if err != nil {
+		t.Fatalf("Heatmap() error = %v", err)
+	}
+	wantPaths := []string{
+		"", "name", "users", "users[0]", "users[1]",
+		"items", "items[0]", "items[0].tags", "items[0].x", "items[1]", "items[2]", "items[2][0]", "items[2][0].q",
+	}
+	if got := costPaths(cost); !reflect.DeepEqual(got, wantPaths) {
+		t.Errorf("paths = %q, want %q", got, wantPaths)
+	}
+
+	tests := []struct {
+		path             string
+		bytes, jsonBytes int
+	}{
+		{path: "name", bytes: len("name: report"), jsonBytes: len(`"name":"report"`)},
+		{path: "users", bytes: len("\nusers[2]{id,name}:\n  1,ua\n  2,ub"), jsonBytes: len(`"users":[{"id":1,"name":"ua"},{"id":2,"name":"ub"}]`)},
+		{path: "users[1]", bytes: len("\n  2,ub"), jsonBytes: len(`{"id":2,"name":"ub"}`)},
+		{path: "items[0].tags", bytes: len("\n  - tags[2]: a,b"), jsonBytes: len(`"tags":["a","b"]`)},
+		{path: "items[0].x", bytes: len("\n    x: 1"), jsonBytes: len(`"x":1`)},
+	}
+	for _, tt := range tests {
+		c := findCost(cost, tt.path)
+		if c == nil {
+			t.Errorf("no cost for %q", tt.path)
+			continue
+		}
+		if c.Bytes != tt.bytes || c.JSONBytes != tt.jsonBytes || c.JSONTokens != tt.jsonBytes {
+			t.Errorf("cost of %q = %d bytes, %d JSON bytes, %d JSON tokens, want %d, %d",
+				tt.path, c.Bytes, c.JSONBytes, c.JSONTokens, tt.bytes, tt.jsonBytes)
+		}
+	}
+}
+
+func TestHeatmapFlattenPaths(t *testing.T) {
+	data := map[string]interface{}{
+		"a":   map[string]interface{}{"b": map[string]interface{}{"c": 1}},
+		"d":   2,
+		"x.y": 3,
+	}
+	cost, err := Heatmap(data, byteTokenizer{}, WithFlattenPaths(true))
+	if err != nil {
+		t.Fatalf("Heatmap() error = %v", err)
+	}
+
+	wantPaths := []string{"", "a.b.c", "d", `"x.y"`}
+	if got := costPaths(cost); !reflect.DeepEqual(got, wantPaths) {
+		t.Fatalf("paths = %q, want %q", got, wantPaths)
+	}
+	for _, path := range wantPaths[1:] {
+		if _, err := Get(data, path); err != nil {
+			t.Errorf("Get(%q) error = %v", path, err)
+		}
+	}
+
+	folded := findCost(cost, "a.b.c")
+	if want := len(`"a":{"b":{"c":1}}`); folded.JSONBytes != want {
+		t.Errorf("JSON bytes of a.b.c = %d, want %d", folded.JSONBytes, want)
+	}
+	if want := len("a.b.c: 1"); folded.Bytes != want {
+		t.Errorf("bytes of a.b.c = %d, want %d", folded.Bytes, want)
+	}
+}
+
+func TestHeatmapErrors(t *testing.T) {
+	if _, err := Heatmap(map[string]interface{}{"a": 1}, nil, WithIndent(-1)); err == nil {
+		t.Error("Heatmap() with invalid options error = nil, want error")
+	}
+
+	// The default tokenizer counts something for any non-empty output
+	cost, err := Heatmap(map[string]interface{}{"a": 1}, nil)
+	if err != nil {
+		t.Fatalf("Heatmap() error = %v", err)
+	}
+	if cost.Tokens == 0 || cost.JSONTokens == 0 {
+		t.Errorf("Heatmap() = %+v, want token counts", cost)
+	}
+}
@@ -0,0 +1,168 @@
+package toon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// JSONLinesOptions configures FromJSONLines.
+type JSONLinesOptions struct {
+	// Columns selects and orders the fields kept from each record
+	// (default: nil, every field in order of first appearance).
+	Columns []string
+
+	// Limit is the maximum number of records read (default: 0, unlimited).
+	Limit int
+}
+
+// JSONLinesOption is a functional option for configuring FromJSONLines.
+type JSONLinesOption func(*JSONLinesOptions)
+
+// WithJSONLinesColumns keeps only the given fields of each record, in the given order.
+func WithJSONLinesColumns(columns ...string) JSONLinesOption {
+	return func(o *JSONLinesOptions) {
+		o.Columns = columns
+	}
+}
+
+// WithJSONLinesLimit stops reading after n records (default: 0, unlimited).
+func WithJSONLinesLimit(n int) JSONLinesOption {
+	return func(o *JSONLinesOptions) {
+		o.Limit = n
+	}
+}
+
+// applyJSONLinesOptions applies functional options to create JSONLinesOptions.
+func applyJSONLinesOptions(opts ...JSONLinesOption) *JSONLinesOptions {
+	jsonlOpts := &JSONLinesOptions{}
+	for _, opt := range opts {
+		opt(jsonlOpts)
+	}
+	return jsonlOpts
+}
+
+// FromJSONLines reads newline-delimited JSON records from r and returns them
+// as one array, ready to be encoded with Marshal.
+//
+// The result is the array itself when key is empty, otherwise an *OrderedMap
+// holding the array under key. Blank lines are skipped and numbers keep their
+// source text.
+//
+// The schema is the union of the record fields in order of first appearance.
+// When every record is an object with primitive values, missing fields are
+// filled with null so the array encodes as a single tabular array. Otherwise
+// records are kept as read and Marshal falls back to list format, as for any
+// other non-uniform array.
+//
+// Example:
+//
+//	v, err := toon.FromJSONLines(file, "events", toon.WithJSONLinesLimit(100))
+//	out, err := toon.MarshalToString(v)
+//	// events[100]{ts,level,msg}:
+//	//   ...
+func FromJSONLines(r io.Reader, key string, opts ...JSONLinesOption) (Value, error) {
+	jsonlOpts := applyJSONLinesOptions(opts...)
+
+	var records []Value
+	reader := bufio.NewReader(r)
+	for lineNum := 1; jsonlOpts.Limit <= 0 || len(records) < jsonlOpts.Limit; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			record, decErr := decodeJSONLine(line)
+			if decErr != nil {
+				decErr.Line = lineNum
+				return nil, decErr
+			}
+			if jsonlOpts.Columns != nil {
+				record = selectColumns(record, jsonlOpts.Columns)
+			}
+			records = append(records, record)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	rows := unionRows(records)
+	if key == "" {
+		return rows, nil
+	}
+	result := NewOrderedMap()
+	result.Set(key, rows)
+	return result, nil
+}
+
+// decodeJSONLine decodes a single JSON Lines record.
+func decodeJSONLine(line []byte) (Value, *DecodeError) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	v, err := decodeJSONValue(dec)
+	if err == nil {
+		if _, tokErr := dec.Token(); tokErr != io.EOF {
+			err = &DecodeError{Message: "invalid JSON: unexpected data after record"}
+		}
+	}
+	if err != nil {
+		var decErr *DecodeError
+		if !errors.As(err, &decErr) {
+			decErr = &DecodeError{Message: err.Error(), Cause: err}
+		}
+		return nil, decErr
+	}
+	return v, nil
+}
+
+// selectColumns projects an object record onto columns; missing fields are null.
+// Records that are not objects are kept as they are.
+func selectColumns(record Value, columns []string) Value {
+	obj, ok := record.(*OrderedMap)
+	if !ok {
+		return record
+	}
+	selected := NewOrderedMap()
+	for _, c := range columns {
+		val, _ := obj.Get(c)
+		selected.Set(c, val)
+	}
+	return selected
+}
+
+// unionRows fills every object record with the union of all fields when the
+// result is a tabular array. Other record sets are returned unchanged.
+func unionRows(records []Value) []Value {
+	rows := make([]Value, len(records))
+	copy(rows, records)
+
+	var columns []string
+	seen := make(map[string]bool)
+	for _, record := range records {
+		obj, ok := record.(*OrderedMap)
+		if !ok || !orderedMapHasPrimitiveValues(obj) {
+			return rows
+		}
+		for _, k := range obj.Keys() {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+
+	for i, record := range records {
+		obj := record.(*OrderedMap)
+		filled := NewOrderedMap()
+		for _, c := range columns {
+			val, _ := obj.Get(c)
+			filled.Set(c, val)
+		}
+		rows[i] = filled
+	}
+	return rows
+}
@@ -0,0 +1,120 @@
+package toon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFromJSONLines(t *testing.T) {
+	logs := `{"ts": "2025-01-01T00:00:00Z", "level": "info", "msg": "start"}
+{"ts": "2025-01-01T00:00:01Z", "level": "warn", "msg": "slow", "ms": 1.50}
+
+{"ts": "2025-01-01T00:00:02Z", "msg": "done", "level": "info"}
+`
+
+	tests := []struct {
+		name  string
+		input string
+		key   string
+		opts  []JSONLinesOption
+		want  string
+	}{
+		{
+			name:  "union schema",
+			input: logs,
+			key:   "events",
+			want:  "events[3]{ts,level,msg,ms}:\n  \"2025-01-01T00:00:00Z\",info,start,null\n  \"2025-01-01T00:00:01Z\",warn,slow,1.50\n  \"2025-01-01T00:00:02Z\",info,done,null",
+		},
+		{
+			name:  "columns",
+			input: logs,
+			key:   "events",
+			opts:  []JSONLinesOption{WithJSONLinesColumns("level", "ms", "host")},
+			want:  "events[3]{level,ms,host}:\n  info,null,null\n  warn,1.50,null\n  info,null,null",
+		},
+		{
+			name:  "limit",
+			input: logs,
+			opts:  []JSONLinesOption{WithJSONLinesLimit(1)},
+			want:  "[1]{ts,level,msg}:\n  \"2025-01-01T00:00:00Z\",info,start",
+		},
+		{
+			name:  "nested values fall back to list",
+			input: "{\"id\": 1}\n{\"id\": 2, \"tags\": [\"a\"]}",
+			key:   "rows",
+			want:  "rows[2]:\n  - id: 1\n  - id: 2\n    tags[1]: a",
+		},
+		{
+			name:  "nested values dropped by columns",
+			input: "{\"id\": 1}\n{\"id\": 2, \"tags\": [\"a\"]}",
+			key:   "rows",
+			opts:  []JSONLinesOption{WithJSONLinesColumns("id")},
+			want:  "rows[2]{id}:\n  1\n  2",
+		},
+		{
+			name:  "primitive records",
+			input: "1\n\"two\"\nnull",
+			key:   "values",
+			want:  "values[3]: 1,two,null",
+		},
+		{
+			name:  "empty input",
+			input: "\n\n",
+			key:   "rows",
+			want:  "rows[0]:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := FromJSONLines(strings.NewReader(tt.input), tt.key, tt.opts...)
+			if err != nil {
+				t.Fatalf("FromJSONLines() error = %v", err)
+			}
+			got, err := MarshalToString(v)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FromJSONLines() encodes as\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromJSONLinesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{"invalid", "{\"a\": 1}\n{\"a\": tru}\n", 2},
+		{"truncated", "{\"a\": 1}\n\n{\"a\": \n", 3},
+		{"two values on a line", "{\"a\": 1} {\"a\": 2}\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromJSONLines(strings.NewReader(tt.input), "rows")
+			var decErr *DecodeError
+			if !errors.As(err, &decErr) {
+				t.Fatalf("FromJSONLines() error = %v, want DecodeError", err)
+			}
+			if decErr.Line != tt.line {
+				t.Errorf("FromJSONLines() error line = %d, want %d", decErr.Line, tt.line)
+			}
+		})
+	}
+}
+
+func TestFromJSONLinesLimitStopsReading(t *testing.T) {
+	// Lines after the limit are never read, so they may be invalid
+	v, err := FromJSONLines(strings.NewReader("{\"a\": 1}\nnot json\n"), "", WithJSONLinesLimit(1))
+	if err != nil {
+		t.Fatalf("FromJSONLines() error = %v", err)
+	}
+	if rows := v.([]Value); len(rows) != 1 {
+		t.Errorf("FromJSONLines() = %d rows, want 1", len(rows))
+	}
+}
@@ -0,0 +1,207 @@
+// Package lint reports problems in TOON documents that parse but make
+// poorer model input, such as list arrays that could be tabular, quotes the
+// encoder would not write and array lengths that only a non-strict decoder
+// accepts.
+//
+// Each Diagnostic names the Rule that produced it. Rules have a fixed
+// severity and are all enabled by default:
+//
+//	syntax              error    the document cannot be parsed
+//	length-mismatch     warning  declared [N] differs from the number of items
+//	indentation         warning  indentation uses tabs or is not a multiple of the indent size
+//	path-collision      warning  dotted keys collide when expanded with WithExpandPaths("safe")
+//	prefer-tabular      warning  list array of uniform objects that could be tabular
+//	unnecessary-quotes  info     quoted key or string the encoder would write unquoted
+//
+// Example:
+//
+//	for _, d := range lint.Lint(src) {
+//		fmt.Printf("%d:%d: %s: %s (%s)\n", d.Span.Start.Line, d.Span.Start.Column, d.Severity, d.Message, d.Rule)
+//	}
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// Severity is the importance of a diagnostic.
+type Severity int
+
+const (
+	// SeverityInfo is a stylistic suggestion.
+	SeverityInfo Severity = iota
+	// SeverityWarning is a problem a non-strict decoder tolerates, or a
+	// layout that costs tokens.
+	SeverityWarning
+	// SeverityError is a document that cannot be decoded.
+	SeverityError
+)
+
+// String returns the severity name: "info", "warning" or "error".
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSeverity returns the severity with the given name.
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, errors.New("unknown severity " + name)
+}
+
+// Rule identifiers.
+const (
+	RuleSyntax            = "syntax"
+	RuleLengthMismatch    = "length-mismatch"
+	RuleIndentation       = "indentation"
+	RulePathCollision     = "path-collision"
+	RulePreferTabular     = "prefer-tabular"
+	RuleUnnecessaryQuotes = "unnecessary-quotes"
+)
+
+// Rule describes a check.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+}
+
+var rules = []Rule{
+	{RuleSyntax, SeverityError, "The document cannot be parsed."},
+	{RuleLengthMismatch, SeverityWarning, "The declared array length differs from the number of items; strict decoders reject it."},
+	{RuleIndentation, SeverityWarning, "Indentation uses tabs or is not a multiple of the indent size."},
+	{RulePathCollision, SeverityWarning, "Dotted keys collide when expanded with WithExpandPaths(\"safe\")."},
+	{RulePreferTabular, SeverityWarning, "A list array of objects with the same primitive fields can be written as a tabular array."},
+	{RuleUnnecessaryQuotes, SeverityInfo, "A key or string is quoted although the encoder would write it unquoted."},
+}
+
+// Rules returns the rules in the order they are documented.
+func Rules() []Rule {
+	return append([]Rule(nil), rules...)
+}
+
+// Diagnostic is a single problem found by Lint.
+type Diagnostic struct {
+	// Rule is the ID of the rule that reported the problem.
+	Rule     string
+	Severity Severity
+	Message  string
+	// Span is the source range of the problem. Syntax errors carry only a
+	// start line and column.
+	Span toon.Span
+}
+
+// Options configures Lint.
+type Options struct {
+	// IndentSize is the expected indentation in spaces (default: the
+	// indentation of the first indented line).
+	IndentSize int
+	// Disabled holds the IDs of rules that are not run.
+	Disabled []string
+}
+
+// Option is a functional option for Lint.
+type Option func(*Options)
+
+// WithIndentSize sets the expected indentation in spaces.
+func WithIndentSize(n int) Option {
+	return func(o *Options) {
+		o.IndentSize = n
+	}
+}
+
+// WithDisabled turns off the rules with the given IDs.
+func WithDisabled(ids ...string) Option {
+	return func(o *Options) {
+		o.Disabled = append(o.Disabled, ids...)
+	}
+}
+
+// applyOptions returns the options selected by opts.
+func applyOptions(opts ...Option) *Options {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Lint checks src and returns its diagnostics ordered by position.
+//
+// The document is parsed without strict validation so that problems a
+// strict decoder rejects, such as wrong array lengths, are reported as
+// diagnostics. A document that cannot be parsed at all produces a single
+// syntax diagnostic in addition to the indentation diagnostics.
+func Lint(src []byte, opts ...Option) []Diagnostic {
+	o := applyOptions(opts...)
+	l := &linter{disabled: make(map[string]bool, len(o.Disabled))}
+	for _, id := range o.Disabled {
+		l.disabled[id] = true
+	}
+
+	l.checkIndentation(string(src), o.IndentSize)
+
+	doc, err := toon.ParseTree(bytes.NewReader(src), toon.WithStrictDecoding(false))
+	if err != nil {
+		l.syntaxError(err)
+	} else if doc.Root != nil {
+		l.visit(doc.Root, ",")
+	}
+
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		a, b := l.diags[i].Span.Start, l.diags[j].Span.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.diags
+}
+
+// linter collects diagnostics.
+type linter struct {
+	disabled map[string]bool
+	diags    []Diagnostic
+}
+
+// report adds a diagnostic for rule unless the rule is disabled.
+func (l *linter) report(rule string, span toon.Span, msg string) {
+	if l.disabled[rule] {
+		return
+	}
+	for _, r := range rules {
+		if r.ID == rule {
+			l.diags = append(l.diags, Diagnostic{Rule: rule, Severity: r.Severity, Message: msg, Span: span})
+			return
+		}
+	}
+}
+
+// syntaxError reports a parse error.
+func (l *linter) syntaxError(err error) {
+	var span toon.Span
+	msg := err.Error()
+	var decErr *toon.DecodeError
+	if errors.As(err, &decErr) {
+		span.Start = toon.Pos{Line: decErr.Line, Column: decErr.Column}
+		span.End = span.Start
+		msg = decErr.Message
+	}
+	l.report(RuleSyntax, span, msg)
+}
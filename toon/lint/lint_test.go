@@ -0,0 +1,129 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// summarize formats diagnostics as "line:col rule" lines.
+func summarize(diags []Diagnostic) string {
+	lines := make([]string, len(diags))
+	for i, d := range diags {
+		lines[i] = fmt.Sprintf("%d:%d %s", d.Span.Start.Line, d.Span.Start.Column, d.Rule)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []Option
+		want  string
+	}{
+		{
+			name:  "clean",
+			input: "users[2]{id,name}:\n  1,Ada\n  2,Bob\ntags[2]: a,b\nnote: \"a,b\"",
+			want:  "",
+		},
+		{
+			name:  "length mismatch",
+			input: "a[3]: 1,2\nrows[1]{x}:\n  1\n  2",
+			want:  "1:2 length-mismatch\n2:5 length-mismatch",
+		},
+		{
+			name:  "prefer tabular",
+			input: "items[2]:\n  - id: 1\n    name: Ada\n  - name: Bob\n    id: 2",
+			want:  "1:6 prefer-tabular",
+		},
+		{
+			name:  "list that must stay a list",
+			input: "items[2]:\n  - id: 1\n  - id: 2\n    tags[1]: x\nmixed[2]:\n  - a: 1\n  - b: 2",
+			want:  "",
+		},
+		{
+			name:  "unnecessary quotes",
+			input: "\"name\": \"Ada\"\n\"a b\": \"true\"\nt[2|]: \"a,b\"|\"a|b\"",
+			want:  "1:1 unnecessary-quotes\n1:9 unnecessary-quotes\n3:8 unnecessary-quotes",
+		},
+		{
+			name:  "path collisions",
+			input: "a.b: 1\na: 2\nc: 1\nc.d: 2\ne.f: 1\ne.g: 2\nx.y: 1\nx.y: 2\n\"p.q\": 1\np: 2",
+			want:  "2:1 path-collision\n4:1 path-collision\n8:1 path-collision",
+		},
+		{
+			name:  "indentation",
+			input: "a:\n  b:\n     c: 1\n  d: 1",
+			want:  "3:1 indentation",
+		},
+		{
+			name:  "indent size option",
+			input: "a:\n  b: 1",
+			opts:  []Option{WithIndentSize(4)},
+			want:  "2:1 indentation",
+		},
+		{
+			name:  "tab",
+			input: "a:\n\tb: 1",
+			want:  "2:1 indentation",
+		},
+		{
+			name:  "disabled rules",
+			input: "a[3]: 1,2\n\"k\": 1",
+			opts:  []Option{WithDisabled(RuleLengthMismatch, RuleUnnecessaryQuotes)},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarize(Lint([]byte(tt.input), tt.opts...))
+			if got != tt.want {
+				t.Errorf("Lint() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintDiagnostic(t *testing.T) {
+	diags := Lint([]byte("a: \"x\nb: 1"))
+	if len(diags) != 1 {
+		t.Fatalf("Lint() = %v, want one diagnostic", diags)
+	}
+	d := diags[0]
+	if d.Rule != RuleSyntax || d.Severity != SeverityError || d.Span.Start.Line != 1 || d.Message == "" {
+		t.Errorf("Lint() = %+v, want a syntax error on line 1", d)
+	}
+
+	diags = Lint([]byte("items[2]:\n  - a: 1\n  - a: 2"))
+	if len(diags) != 1 || diags[0].Severity != SeverityWarning || !strings.Contains(diags[0].Message, "[2]{a}") {
+		t.Errorf("Lint() = %+v, want a prefer-tabular warning", diags)
+	}
+}
+
+func TestRules(t *testing.T) {
+	seen := map[string]bool{}
+	for _, r := range Rules() {
+		if r.ID == "" || r.Description == "" || seen[r.ID] {
+			t.Errorf("invalid or duplicate rule %+v", r)
+		}
+		seen[r.ID] = true
+	}
+	for _, id := range []string{RuleSyntax, RuleLengthMismatch, RuleIndentation, RulePathCollision, RulePreferTabular, RuleUnnecessaryQuotes} {
+		if !seen[id] {
+			t.Errorf("Rules() is missing %s", id)
+		}
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, s := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		if got, err := ParseSeverity(s.String()); err != nil || got != s {
+			t.Errorf("ParseSeverity(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("ParseSeverity(fatal) error = nil, want error")
+	}
+}
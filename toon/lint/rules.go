@@ -0,0 +1,183 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// visit checks n and its descendants. delim is the delimiter that applies
+// to scalars directly under n.
+func (l *linter) visit(n toon.SyntaxNode, delim string) {
+	switch node := n.(type) {
+	case *toon.ObjectNode:
+		l.checkPathCollisions(node)
+		for _, f := range node.Fields {
+			l.checkKeyQuotes(f)
+			l.visit(f.Value, ",")
+		}
+	case *toon.ArrayNode:
+		l.checkLength(node)
+		l.checkPreferTabular(node)
+		for _, item := range node.Items {
+			l.visit(item, node.Header.Delimiter)
+		}
+	case *toon.RowNode:
+		for _, cell := range node.Cells {
+			l.visit(cell, delim)
+		}
+	case *toon.ListItemNode:
+		l.visit(node.Value, delim)
+	case *toon.ScalarNode:
+		l.checkStringQuotes(node, delim)
+	}
+}
+
+// checkLength reports a declared length that differs from the item count.
+func (l *linter) checkLength(arr *toon.ArrayNode) {
+	if arr.Header.Length == len(arr.Items) {
+		return
+	}
+	l.report(RuleLengthMismatch, arr.Header.Span(),
+		fmt.Sprintf("array declares %d items but has %d", arr.Header.Length, len(arr.Items)))
+}
+
+// checkPreferTabular reports a list array that the encoder would write as
+// a tabular array: every item an object with the same primitive fields.
+func (l *linter) checkPreferTabular(arr *toon.ArrayNode) {
+	if arr.Layout != toon.ArrayList || len(arr.Items) == 0 {
+		return
+	}
+
+	var fields []string
+	for i, item := range arr.Items {
+		obj, ok := item.(*toon.ListItemNode).Value.(*toon.ObjectNode)
+		if !ok || len(obj.Fields) == 0 {
+			return
+		}
+		keys := make(map[string]bool, len(obj.Fields))
+		for _, f := range obj.Fields {
+			if _, ok := f.Value.(*toon.ScalarNode); !ok || keys[f.Key] {
+				return
+			}
+			keys[f.Key] = true
+			if i == 0 {
+				fields = append(fields, f.Key)
+			}
+		}
+		if len(keys) != len(fields) {
+			return
+		}
+		for _, k := range fields {
+			if !keys[k] {
+				return
+			}
+		}
+	}
+
+	l.report(RulePreferTabular, arr.Header.Span(),
+		fmt.Sprintf("list array of %d objects with the same fields can be tabular: [%d]{%s}",
+			len(arr.Items), len(arr.Items), strings.Join(fields, ",")))
+}
+
+// checkKeyQuotes reports a quoted key that the encoder would not quote.
+// Quotes on a dotted key are kept, since they prevent path expansion.
+func (l *linter) checkKeyQuotes(f *toon.FieldNode) {
+	if !f.Quoted || toon.IsExpandablePath(f.Key) {
+		return
+	}
+	obj := toon.NewOrderedMap()
+	obj.Set(f.Key, nil)
+	encoded, err := toon.MarshalToString(obj)
+	if err != nil || strings.HasPrefix(encoded, `"`) {
+		return
+	}
+	l.report(RuleUnnecessaryQuotes, f.KeySpan, fmt.Sprintf("key %s does not need quotes", f.RawKey))
+}
+
+// checkStringQuotes reports a quoted string that the encoder would not
+// quote with the active delimiter.
+func (l *linter) checkStringQuotes(s *toon.ScalarNode, delim string) {
+	str, ok := s.Value.(string)
+	if !s.Quoted || !ok {
+		return
+	}
+	encoded, err := toon.MarshalToString(str, toon.WithDelimiter(delim))
+	if err != nil || strings.HasPrefix(encoded, `"`) {
+		return
+	}
+	l.report(RuleUnnecessaryQuotes, s.Span(), fmt.Sprintf("string %s does not need quotes", s.Raw))
+}
+
+// pathNode is a key in the object built by expanding dotted keys.
+type pathNode struct {
+	field    *toon.FieldNode // first field that created the key
+	leaf     bool            // whether the key holds a field value
+	children map[string]*pathNode
+}
+
+// checkPathCollisions reports fields of obj whose keys overwrite or merge
+// into each other when dotted keys are expanded, e.g. "a.b" and "a".
+func (l *linter) checkPathCollisions(obj *toon.ObjectNode) {
+	root := &pathNode{children: map[string]*pathNode{}}
+	for _, f := range obj.Fields {
+		segments := []string{f.Key}
+		dotted := !f.Quoted && toon.IsExpandablePath(f.Key)
+		if dotted {
+			segments = strings.Split(f.Key, ".")
+		}
+
+		node := root
+		for i, seg := range segments {
+			child, exists := node.children[seg]
+			last := i == len(segments)-1
+			if exists && (child.leaf || last) {
+				if dotted || strings.Contains(child.field.Key, ".") {
+					l.report(RulePathCollision, f.KeySpan,
+						fmt.Sprintf("key %s collides with %s at line %d when paths are expanded",
+							f.RawKey, child.field.RawKey, child.field.KeySpan.Start.Line))
+				}
+				break
+			}
+			if !exists {
+				child = &pathNode{field: f, leaf: last, children: map[string]*pathNode{}}
+				node.children[seg] = child
+			}
+			node = child
+		}
+	}
+}
+
+// checkIndentation reports lines indented with tabs or by a number of
+// spaces that is not a multiple of size. A size of 0 is taken from the
+// first indented line.
+func (l *linter) checkIndentation(src string, size int) {
+	offset := 0
+	for i, line := range strings.Split(src, "\n") {
+		start := offset
+		offset += len(line) + 1
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == 0 {
+			continue
+		}
+		span := toon.Span{
+			Start: toon.Pos{Offset: start, Line: i + 1, Column: 1},
+			End:   toon.Pos{Offset: start + indent, Line: i + 1, Column: indent + 1},
+		}
+
+		switch {
+		case strings.Contains(line[:indent], "\t"):
+			l.report(RuleIndentation, span, "indentation contains a tab")
+		case size == 0:
+			size = indent
+		case indent%size != 0:
+			l.report(RuleIndentation, span,
+				fmt.Sprintf("indentation of %d spaces is not a multiple of %d", indent, size))
+		}
+	}
+}
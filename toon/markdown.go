@@ -0,0 +1,240 @@
+package toon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ToMarkdownTable renders the tabular array at path inside v as a Markdown
+// pipe table.
+//
+// v is anything Get accepts. Columns follow the field order of the first
+// object (sorted for plain maps, source order for a *Document or *Node).
+// Number columns are right-aligned. Nulls are empty cells, pipes are escaped
+// as \| and newlines are written as <br>. An empty array renders as "".
+//
+// Example:
+//
+//	table, err := toon.ToMarkdownTable(doc, "users")
+//	// | id | name |
+//	// | ---: | --- |
+//	// | 1 | Ada |
+//	// | 2 | Bob |
+func ToMarkdownTable(v Value, path string) (string, error) {
+	if d, ok := v.(*Document); ok {
+		v = d.Node()
+	}
+	val, err := Get(v, path)
+	if err != nil {
+		return "", err
+	}
+
+	items, ok := normalize(val).([]Value)
+	if !ok {
+		return "", &EncodeError{Message: fmt.Sprintf("value at %q is not an array", path), Value: val}
+	}
+	format := detectArrayFormat(items)
+	if format == arrayFormatEmpty {
+		return "", nil
+	}
+	if format != arrayFormatTabular {
+		return "", &EncodeError{Message: fmt.Sprintf("array at %q is not tabular", path), Value: val}
+	}
+
+	keys := tabularKeys(items[0])
+	rows := make([][]string, len(items))
+	numeric := make([]bool, len(keys))
+	for i := range numeric {
+		numeric[i] = true
+	}
+	for r, item := range items {
+		rows[r] = make([]string, len(keys))
+		for c, k := range keys {
+			cell := tabularCell(item, k)
+			switch cell.(type) {
+			case nil:
+			case int64, float64, json.Number:
+			default:
+				numeric[c] = false
+			}
+			text, err := markdownCell(cell)
+			if err != nil {
+				return "", err
+			}
+			rows[r][c] = text
+		}
+	}
+
+	var b strings.Builder
+	header := make([]string, len(keys))
+	align := make([]string, len(keys))
+	for i, k := range keys {
+		header[i] = escapeMarkdownCell(k)
+		align[i] = "---"
+		if numeric[i] {
+			align[i] = "---:"
+		}
+	}
+	writeMarkdownRow(&b, header)
+	writeMarkdownRow(&b, align)
+	for _, row := range rows {
+		writeMarkdownRow(&b, row)
+	}
+	return strings.TrimSuffix(b.String(), newline), nil
+}
+
+// markdownCell formats a primitive as the text of a table cell.
+func markdownCell(v Value) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return escapeMarkdownCell(val), nil
+	default:
+		return encodePrimitive(val, comma)
+	}
+}
+
+// escapeMarkdownCell escapes pipes and replaces line breaks in cell text.
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// writeMarkdownRow writes one table row followed by a newline.
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, cell := range cells {
+		b.WriteString(" ")
+		b.WriteString(cell)
+		b.WriteString(" |")
+	}
+	b.WriteString(newline)
+}
+
+// FromMarkdownTable parses the first Markdown pipe table in text into an
+// array of objects, which Marshal encodes as a tabular array.
+//
+// Text before and after the table, such as the prose around a table in a
+// model's answer, is ignored. The table needs a header row and an alignment
+// row (|---|:---:|); outer pipes are optional and \| is a literal pipe.
+// Missing cells and empty cells are null, extra cells are dropped and <br>
+// becomes a newline.
+//
+// Types are inferred per column with the rules of TOON primitives, as in
+// FromCSV: a column of numbers becomes numbers, a column of true/false
+// becomes booleans, and any other column keeps its cells as strings.
+//
+// Example:
+//
+//	rows, err := toon.FromMarkdownTable("| id | name |\n|---|---|\n| 1 | Ada |")
+//	out, err := toon.MarshalToString(map[string]interface{}{"users": rows})
+//	// users[1]{id,name}:
+//	//   1,Ada
+func FromMarkdownTable(text string) (Value, error) {
+	lines := strings.Split(text, newline)
+
+	start := -1
+	for i := 0; i+1 < len(lines); i++ {
+		if isMarkdownTableRow(lines[i]) && isMarkdownAlignmentRow(lines[i+1]) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, &DecodeError{Message: "no Markdown table found"}
+	}
+
+	header := splitMarkdownRow(lines[start])
+	seen := make(map[string]bool, len(header))
+	for _, h := range header {
+		if seen[h] {
+			return nil, &DecodeError{Message: fmt.Sprintf("duplicate column %q", h), Line: start + 1}
+		}
+		seen[h] = true
+	}
+
+	var records [][]string
+	for _, line := range lines[start+2:] {
+		if !isMarkdownTableRow(line) {
+			break
+		}
+		cells := splitMarkdownRow(line)
+		record := make([]string, len(header))
+		copy(record, cells)
+		records = append(records, record)
+	}
+
+	columns := make([][]Value, len(header))
+	csvOpts := applyCSVOptions()
+	for col := range header {
+		columns[col] = csvColumn(records, col, csvOpts)
+	}
+
+	rows := make([]Value, len(records))
+	for i := range records {
+		row := NewOrderedMap()
+		for col, h := range header {
+			row.Set(h, columns[col][i])
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// isMarkdownTableRow reports whether line can be a row of a pipe table.
+func isMarkdownTableRow(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && strings.Contains(line, "|")
+}
+
+// isMarkdownAlignmentRow reports whether line is the delimiter row of a
+// pipe table, e.g. | --- | :---: | --: |
+func isMarkdownAlignmentRow(line string) bool {
+	if !isMarkdownTableRow(line) {
+		return false
+	}
+	for _, cell := range splitMarkdownRow(line) {
+		cell = strings.TrimSuffix(strings.TrimPrefix(cell, ":"), ":")
+		if cell == "" || strings.Trim(cell, "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// splitMarkdownRow splits a table row into trimmed, unescaped cells.
+func splitMarkdownRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, markdownCellText(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, markdownCellText(cell.String()))
+}
+
+// markdownCellText trims a cell and turns <br> back into newlines.
+func markdownCellText(s string) string {
+	s = strings.TrimSpace(s)
+	for _, br := range []string{"<br>", "<br/>", "<br />"} {
+		s = strings.ReplaceAll(s, br, "\n")
+	}
+	return s
+}
@@ -0,0 +1,118 @@
+package toon
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToMarkdownTable(t *testing.T) {
+	doc, err := ParseTreeFromString("report:\n  rows[3]{name,score,note}:\n    Ada,9.5,\"a|b\"\n    Bob,null,\"two\\nlines\"\n    Cy,10,null")
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+
+	got, err := ToMarkdownTable(doc, "report.rows")
+	if err != nil {
+		t.Fatalf("ToMarkdownTable() error = %v", err)
+	}
+	want := "| name | score | note |\n" +
+		"| --- | ---: | --- |\n" +
+		"| Ada | 9.5 | a\\|b |\n" +
+		"| Bob |  | two<br>lines |\n" +
+		"| Cy | 10 |  |"
+	if got != want {
+		t.Errorf("ToMarkdownTable() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got, err := ToMarkdownTable([]interface{}{}, ""); err != nil || got != "" {
+		t.Errorf("ToMarkdownTable(empty) = %q, %v", got, err)
+	}
+
+	var encErr *EncodeError
+	if _, err := ToMarkdownTable([]interface{}{1, 2}, ""); !errors.As(err, &encErr) {
+		t.Errorf("ToMarkdownTable(inline array) error = %v, want EncodeError", err)
+	}
+}
+
+func TestFromMarkdownTable(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "model answer",
+			input: "Here are the results:\n\n" +
+				"| id | name | price | in stock | zip |\n" +
+				"|---:|:-----|:-----:|----------|-----|\n" +
+				"| 1 | Widget | 9.99 | true | 02134 |\n" +
+				"| 2 | Gadget \\| XL | 12 | false | 10001 |\n" +
+				"\nLet me know if you need more.",
+			want: "[2]{id,name,price,\"in stock\",zip}:\n  1,Widget,9.99,true,\"02134\"\n  2,Gadget | XL,12,false,\"10001\"",
+		},
+		{
+			name:  "no outer pipes, missing and extra cells",
+			input: "a | b\n--- | ---\n1 |\n2 | x | extra\n3 | <br>y",
+			want:  "[3]{a,b}:\n  1,null\n  2,x\n  3,\"\\ny\"",
+		},
+		{
+			name:  "header only",
+			input: "| a |\n| - |",
+			want:  "[0]:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := FromMarkdownTable(tt.input)
+			if err != nil {
+				t.Fatalf("FromMarkdownTable() error = %v", err)
+			}
+			got, err := MarshalToString(rows)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FromMarkdownTable() encodes as\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromMarkdownTableErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"no table here",
+		"| a | b |\n| x | y |",
+		"| a | a |\n|---|---|\n| 1 | 2 |",
+	} {
+		var decErr *DecodeError
+		if _, err := FromMarkdownTable(input); !errors.As(err, &decErr) {
+			t.Errorf("FromMarkdownTable(%q) error = %v, want DecodeError", input, err)
+		}
+	}
+}
+
+func TestMarkdownTableRoundTrip(t *testing.T) {
+	input := "items[2]{sku,label,qty}:\n  A-1,x|y,3\n  B-2,\"two\\nlines\",null"
+	doc, err := ParseTreeFromString(input)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+
+	table, err := ToMarkdownTable(doc, "items")
+	if err != nil {
+		t.Fatalf("ToMarkdownTable() error = %v", err)
+	}
+	rows, err := FromMarkdownTable(table)
+	if err != nil {
+		t.Fatalf("FromMarkdownTable() error = %v", err)
+	}
+	got, err := MarshalToString(map[string]interface{}{"items": rows})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if got != input {
+		t.Errorf("round trip =\n%s\nwant\n%s", got, input)
+	}
+}
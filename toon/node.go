@@ -0,0 +1,540 @@
+package toon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// Kind identifies the type of value held by a Node.
+type Kind int
+
+const (
+	// KindNull is the null value. It is the kind of the zero Node.
+	KindNull Kind = iota
+	// KindBool is true or false.
+	KindBool
+	// KindInt is an integer that fits in an int64.
+	KindInt
+	// KindFloat is any other number.
+	KindFloat
+	// KindString is a string.
+	KindString
+	// KindArray is an ordered list of nodes.
+	KindArray
+	// KindObject is an ordered list of key/node fields.
+	KindObject
+)
+
+// String returns the kind name.
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindString:
+		return "string"
+	case KindArray:
+		return "array"
+	case KindObject:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// Node is a typed TOON value: null, bool, int, float, string, array or object.
+// Object fields keep their order.
+//
+// Read accessors are safe to call on a nil *Node, which behaves as null, so
+// lookups can be chained: n.Get("user").Get("name").StringValue().
+// Mutation methods return a *PathError for a node of the wrong kind or an
+// index out of range.
+//
+// Marshal and Get accept *Node, Unmarshal can decode into a *Node, and
+// Document.Node returns one with fields in source order.
+type Node struct {
+	kind   Kind
+	b      bool
+	i      int64
+	f      float64
+	s      string
+	items  []*Node
+	fields []NodeField
+}
+
+// NodeField is a single field of an object node.
+type NodeField struct {
+	Key   string
+	Value *Node
+}
+
+// NewNull returns a null node.
+func NewNull() *Node { return &Node{} }
+
+// NewBool returns a bool node.
+func NewBool(b bool) *Node { return &Node{kind: KindBool, b: b} }
+
+// NewInt returns an int node.
+func NewInt(i int64) *Node { return &Node{kind: KindInt, i: i} }
+
+// NewFloat returns a float node.
+func NewFloat(f float64) *Node { return &Node{kind: KindFloat, f: f} }
+
+// NewString returns a string node.
+func NewString(s string) *Node { return &Node{kind: KindString, s: s} }
+
+// NewArray returns an array node holding items.
+func NewArray(items ...*Node) *Node {
+	return &Node{kind: KindArray, items: append([]*Node{}, items...)}
+}
+
+// NewObject returns an empty object node.
+func NewObject() *Node { return &Node{kind: KindObject} }
+
+// NewNode converts a Go value into a Node.
+//
+// v may be any value accepted by Marshal: nil, bool, integers, floats,
+// json.Number, string, slices, map[string]Value, map[string]interface{},
+// OrderedMap, *OrderedMap, RawValue or *Node. Plain map keys are sorted;
+// OrderedMap keys keep their order. Unsupported types and integers beyond
+// int64, including json.Number text, return an *EncodeError.
+func NewNode(v interface{}) (*Node, error) {
+	switch val := v.(type) {
+	case nil:
+		return NewNull(), nil
+	case *Node:
+		return val.Clone(), nil
+	case Node:
+		return val.Clone(), nil
+	case bool:
+		return NewBool(val), nil
+	case string:
+		return NewString(val), nil
+	case float32:
+		return NewFloat(float64(val)), nil
+	case float64:
+		return NewFloat(val), nil
+	case int, int8, int16, int32, int64:
+		return NewInt(reflect.ValueOf(val).Int()), nil
+	case uint, uint8, uint16, uint32, uint64:
+		u := reflect.ValueOf(val).Uint()
+		if u > math.MaxInt64 {
+			return nil, &EncodeError{Message: "integer overflows int64", Value: v}
+		}
+		return NewInt(int64(u)), nil
+	case json.Number:
+		i, err := val.Int64()
+		if err == nil {
+			return NewInt(i), nil
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			// Integer text that a float64 would round
+			return nil, &EncodeError{Message: "integer overflows int64", Value: v, Cause: err}
+		}
+		f, err := val.Float64()
+		if err != nil {
+			return nil, &EncodeError{Message: "invalid number", Value: v, Cause: err}
+		}
+		return NewFloat(f), nil
+	case OrderedMap:
+		return newObjectNode(val.Keys(), val.Get)
+	case *OrderedMap:
+		return newObjectNode(val.Keys(), val.Get)
+	case RawValue:
+		doc, err := parseDocument(string(val), nil)
+		if err != nil {
+			return nil, err
+		}
+		return doc.Node(), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		n := &Node{kind: KindArray, items: make([]*Node, rv.Len())}
+		for i := range n.items {
+			item, err := NewNode(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			n.items[i] = item
+		}
+		return n, nil
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, &EncodeError{Message: "unsupported map key type", Value: v}
+		}
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sortStrings(keys)
+		return newObjectNode(keys, func(k string) (interface{}, bool) {
+			return rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface(), true
+		})
+	}
+
+	return nil, &EncodeError{Message: "unsupported type", Value: v}
+}
+
+// newObjectNode builds an object node from keys and a lookup function.
+func newObjectNode(keys []string, get func(string) (interface{}, bool)) (*Node, error) {
+	n := &Node{kind: KindObject, fields: make([]NodeField, 0, len(keys))}
+	for _, k := range keys {
+		val, _ := get(k)
+		child, err := NewNode(val)
+		if err != nil {
+			return nil, err
+		}
+		n.fields = append(n.fields, NodeField{Key: k, Value: child})
+	}
+	return n, nil
+}
+
+// Value converts the node into the Value model: nil, bool, int64, float64,
+// string, []Value, or *OrderedMap for objects so field order is preserved.
+func (n *Node) Value() Value {
+	switch n.Kind() {
+	case KindBool:
+		return n.b
+	case KindInt:
+		return n.i
+	case KindFloat:
+		return n.f
+	case KindString:
+		return n.s
+	case KindArray:
+		result := make([]Value, len(n.items))
+		for i, item := range n.items {
+			result[i] = item.Value()
+		}
+		return result
+	case KindObject:
+		result := NewOrderedMap()
+		for _, f := range n.fields {
+			result.Set(f.Key, f.Value.Value())
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// Clone returns a deep copy of the node.
+func (n *Node) Clone() *Node {
+	if n == nil {
+		return NewNull()
+	}
+	c := *n
+	if n.items != nil {
+		c.items = make([]*Node, len(n.items))
+		for i, item := range n.items {
+			c.items[i] = item.Clone()
+		}
+	}
+	if n.fields != nil {
+		c.fields = make([]NodeField, len(n.fields))
+		for i, f := range n.fields {
+			c.fields[i] = NodeField{Key: f.Key, Value: f.Value.Clone()}
+		}
+	}
+	return &c
+}
+
+// Kind returns the kind of the node.
+func (n *Node) Kind() Kind {
+	if n == nil {
+		return KindNull
+	}
+	return n.kind
+}
+
+// IsNull reports whether the node is null.
+func (n *Node) IsNull() bool {
+	return n.Kind() == KindNull
+}
+
+// BoolValue returns the value of a bool node.
+func (n *Node) BoolValue() (bool, bool) {
+	if n.Kind() != KindBool {
+		return false, false
+	}
+	return n.b, true
+}
+
+// IntValue returns the value of an int node, or of a float node holding an
+// integral value in range.
+func (n *Node) IntValue() (int64, bool) {
+	switch n.Kind() {
+	case KindInt:
+		return n.i, true
+	case KindFloat:
+		if n.f == math.Trunc(n.f) && n.f >= math.MinInt64 && n.f < math.MaxInt64 {
+			return int64(n.f), true
+		}
+	}
+	return 0, false
+}
+
+// FloatValue returns the value of an int or float node as a float64.
+func (n *Node) FloatValue() (float64, bool) {
+	switch n.Kind() {
+	case KindInt:
+		return float64(n.i), true
+	case KindFloat:
+		return n.f, true
+	}
+	return 0, false
+}
+
+// StringValue returns the value of a string node.
+func (n *Node) StringValue() (string, bool) {
+	if n.Kind() != KindString {
+		return "", false
+	}
+	return n.s, true
+}
+
+// Len returns the number of items of an array or fields of an object.
+func (n *Node) Len() int {
+	switch n.Kind() {
+	case KindArray:
+		return len(n.items)
+	case KindObject:
+		return len(n.fields)
+	}
+	return 0
+}
+
+// Index returns item i of an array, or nil when out of range.
+// Negative indexes count from the end.
+func (n *Node) Index(i int) *Node {
+	if n.Kind() != KindArray {
+		return nil
+	}
+	if i < 0 {
+		i += len(n.items)
+	}
+	if i < 0 || i >= len(n.items) {
+		return nil
+	}
+	return n.items[i]
+}
+
+// Items returns the items of an array. The slice must not be modified.
+func (n *Node) Items() []*Node {
+	if n.Kind() != KindArray {
+		return nil
+	}
+	return n.items
+}
+
+// Get returns the value of field key of an object, or nil.
+func (n *Node) Get(key string) *Node {
+	if i := n.fieldIndex(key); i >= 0 {
+		return n.fields[i].Value
+	}
+	return nil
+}
+
+// Has reports whether an object has field key.
+func (n *Node) Has(key string) bool {
+	return n.fieldIndex(key) >= 0
+}
+
+// Keys returns the field keys of an object in order.
+func (n *Node) Keys() []string {
+	if n.Kind() != KindObject {
+		return nil
+	}
+	keys := make([]string, len(n.fields))
+	for i, f := range n.fields {
+		keys[i] = f.Key
+	}
+	return keys
+}
+
+// Fields returns the fields of an object in order. The slice must not be
+// modified.
+func (n *Node) Fields() []NodeField {
+	if n.Kind() != KindObject {
+		return nil
+	}
+	return n.fields
+}
+
+// At returns the node at path, using the same syntax as Get.
+func (n *Node) At(path string) (*Node, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := n
+	for i, seg := range segments {
+		if seg.isIndex {
+			if cur.Kind() != KindArray {
+				return nil, pathTypeError(segments[:i+1], fmt.Sprintf("cannot index %s", cur.Kind()))
+			}
+			if seg.index >= cur.Len() {
+				return nil, pathNotFound(segments[:i+1], fmt.Sprintf("index %d out of range (length %d)", seg.index, cur.Len()))
+			}
+			cur = cur.items[seg.index]
+			continue
+		}
+
+		if cur.Kind() != KindObject {
+			return nil, pathTypeError(segments[:i+1], fmt.Sprintf("cannot look up key %q in %s", seg.key, cur.Kind()))
+		}
+		next := cur.Get(seg.key)
+		if next == nil {
+			return nil, pathNotFound(segments[:i+1], fmt.Sprintf("key %q not found", seg.key))
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// Set sets field key of an object. An existing field keeps its position;
+// a new field is appended. A nil value is stored as null. Set returns a
+// *PathError wrapping ErrPathType if n is not an object.
+func (n *Node) Set(key string, value *Node) error {
+	if err := n.checkKind(KindObject, "Set"); err != nil {
+		return err
+	}
+	n.set(key, value)
+	return nil
+}
+
+// Delete removes field key from an object and reports whether it existed.
+// It returns false if n is not an object.
+func (n *Node) Delete(key string) bool {
+	i := n.fieldIndex(key)
+	if i < 0 {
+		return false
+	}
+	n.fields = append(n.fields[:i], n.fields[i+1:]...)
+	return true
+}
+
+// Append adds items to the end of an array. It returns a *PathError
+// wrapping ErrPathType if n is not an array.
+func (n *Node) Append(items ...*Node) error {
+	if err := n.checkKind(KindArray, "Append"); err != nil {
+		return err
+	}
+	n.appendItems(items...)
+	return nil
+}
+
+// Insert inserts item before position i of an array; i == Len() appends.
+// An index out of range returns a *PathError wrapping ErrPathNotFound.
+func (n *Node) Insert(i int, item *Node) error {
+	if err := n.checkIndex(i, len(n.items)+1, "Insert"); err != nil {
+		return err
+	}
+	if item == nil {
+		item = NewNull()
+	}
+	n.items = append(n.items, nil)
+	copy(n.items[i+1:], n.items[i:])
+	n.items[i] = item
+	return nil
+}
+
+// SetIndex replaces item i of an array. An index out of range returns a
+// *PathError wrapping ErrPathNotFound.
+func (n *Node) SetIndex(i int, item *Node) error {
+	if err := n.checkIndex(i, len(n.items), "SetIndex"); err != nil {
+		return err
+	}
+	if item == nil {
+		item = NewNull()
+	}
+	n.items[i] = item
+	return nil
+}
+
+// RemoveAt removes item i of an array. An index out of range returns a
+// *PathError wrapping ErrPathNotFound.
+func (n *Node) RemoveAt(i int) error {
+	if err := n.checkIndex(i, len(n.items), "RemoveAt"); err != nil {
+		return err
+	}
+	n.items = append(n.items[:i], n.items[i+1:]...)
+	return nil
+}
+
+// String returns the TOON encoding of the node.
+func (n *Node) String() string {
+	s, err := MarshalToString(n)
+	if err != nil {
+		return fmt.Sprintf("<%s: %v>", n.Kind(), err)
+	}
+	return s
+}
+
+// fieldIndex returns the position of field key, or -1.
+func (n *Node) fieldIndex(key string) int {
+	if n.Kind() != KindObject {
+		return -1
+	}
+	for i, f := range n.fields {
+		if f.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// set sets field key of an object node.
+func (n *Node) set(key string, value *Node) {
+	if value == nil {
+		value = NewNull()
+	}
+	if i := n.fieldIndex(key); i >= 0 {
+		n.fields[i].Value = value
+		return
+	}
+	n.fields = append(n.fields, NodeField{Key: key, Value: value})
+}
+
+// appendItems adds items to the end of an array node.
+func (n *Node) appendItems(items ...*Node) {
+	for _, item := range items {
+		if item == nil {
+			item = NewNull()
+		}
+		n.items = append(n.items, item)
+	}
+}
+
+// checkKind returns a *PathError wrapping ErrPathType unless n has kind k.
+func (n *Node) checkKind(k Kind, method string) error {
+	if n.Kind() != k {
+		return &PathError{Message: fmt.Sprintf("Node.%s called on %s node", method, n.Kind()), Cause: ErrPathType}
+	}
+	return nil
+}
+
+// checkIndex checks that n is an array and 0 <= i < limit.
+func (n *Node) checkIndex(i, limit int, method string) error {
+	if err := n.checkKind(KindArray, method); err != nil {
+		return err
+	}
+	if i < 0 || i >= limit {
+		msg := fmt.Sprintf("Node.%s index %d out of range (length %d)", method, i, len(n.items))
+		return pathNotFound([]pathSegment{{isIndex: true, index: i}}, msg)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package toon
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const nodeTestInput = "zeta: 1\nalpha:\n  b: 2.5\n  a: x\nusers[2]{name,id}:\n  Ada,1\n  Bob,2\nitems[2]: true,null"
+
+func TestUnmarshalNodeKeepsOrder(t *testing.T) {
+	var n Node
+	if err := UnmarshalFromString(nodeTestInput, &n); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if got, want := n.Keys(), []string{"zeta", "alpha", "users", "items"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if got, want := n.Get("alpha").Keys(), []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("alpha.Keys() = %v, want %v", got, want)
+	}
+	if got, want := n.Get("users").Index(0).Keys(), []string{"name", "id"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("users[0].Keys() = %v, want %v", got, want)
+	}
+
+	got, err := MarshalToString(&n)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if got != nodeTestInput {
+		t.Errorf("Marshal(Unmarshal(s)) = %q, want %q", got, nodeTestInput)
+	}
+}
+
+func TestNodeAccessors(t *testing.T) {
+	doc, err := ParseTreeFromString(nodeTestInput)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+	n := doc.Node()
+
+	if i, ok := n.Get("zeta").IntValue(); !ok || i != 1 {
+		t.Errorf("zeta.IntValue() = %d, %v", i, ok)
+	}
+	if f, ok := n.Get("alpha").Get("b").FloatValue(); !ok || f != 2.5 {
+		t.Errorf("alpha.b.FloatValue() = %v, %v", f, ok)
+	}
+	if _, ok := n.Get("alpha").Get("b").IntValue(); ok {
+		t.Error("alpha.b.IntValue() ok = true for 2.5")
+	}
+	if s, ok := n.Get("users").Index(-1).Get("name").StringValue(); !ok || s != "Bob" {
+		t.Errorf("users[-1].name.StringValue() = %q, %v", s, ok)
+	}
+	if b, ok := n.Get("items").Index(0).BoolValue(); !ok || !b {
+		t.Errorf("items[0].BoolValue() = %v, %v", b, ok)
+	}
+	if k := n.Get("items").Index(1).Kind(); k != KindNull {
+		t.Errorf("items[1].Kind() = %v, want null", k)
+	}
+
+	// Missing lookups chain to a nil node that behaves as null
+	missing := n.Get("nope").Get("deeper").Index(3)
+	if missing != nil || !missing.IsNull() || missing.Len() != 0 {
+		t.Errorf("missing lookup = %#v, want nil", missing)
+	}
+	if _, ok := missing.StringValue(); ok {
+		t.Error("nil.StringValue() ok = true")
+	}
+
+	if got := n.Get("users").Len(); got != 2 {
+		t.Errorf("users.Len() = %d, want 2", got)
+	}
+	if !n.Has("items") || n.Has("nope") {
+		t.Error("Has() mismatch")
+	}
+}
+
+func TestNodeAtAndGet(t *testing.T) {
+	var n Node
+	if err := UnmarshalFromString(nodeTestInput, &n); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	at, err := n.At("users[1].id")
+	if err != nil {
+		t.Fatalf("At() error = %v", err)
+	}
+	if i, _ := at.IntValue(); i != 2 {
+		t.Errorf("At(users[1].id) = %v, want 2", at)
+	}
+
+	if s, err := GetString(&n, "alpha.a"); err != nil || s != "x" {
+		t.Errorf("GetString(node) = %q, %v", s, err)
+	}
+	if _, err := n.At("users[5]"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("At(users[5]) error = %v, want ErrPathNotFound", err)
+	}
+	if _, err := n.At("zeta.x"); !errors.Is(err, ErrPathType) {
+		t.Errorf("At(zeta.x) error = %v, want ErrPathType", err)
+	}
+}
+
+func TestNodeMutation(t *testing.T) {
+	n := NewObject()
+	a := NewArray(NewString("x"))
+	for _, err := range []error{
+		n.Set("b", NewInt(1)),
+		n.Set("a", a),
+		n.Set("b", NewFloat(1.5)),
+		a.Append(NewString("z")),
+		a.Insert(1, NewString("y")),
+		a.SetIndex(0, NewString("w")),
+		n.Set("c", nil),
+	} {
+		if err != nil {
+			t.Fatalf("mutation error = %v", err)
+		}
+	}
+
+	got, err := MarshalToString(n)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := "b: 1.5\na[3]: w,y,z\nc: null"; got != want {
+		t.Errorf("Marshal() = %q, want %q", got, want)
+	}
+
+	if err := a.RemoveAt(0); err != nil {
+		t.Fatalf("RemoveAt() error = %v", err)
+	}
+	if !n.Delete("c") || n.Delete("c") {
+		t.Error("Delete() result mismatch")
+	}
+	if got := n.String(); got != "b: 1.5\na[2]: y,z" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestNodeMutationErrors(t *testing.T) {
+	obj := NewObject()
+	arr := NewArray(NewInt(1))
+
+	for name, err := range map[string]error{
+		"Append(object)":   obj.Append(NewNull()),
+		"Insert(object)":   obj.Insert(0, NewNull()),
+		"Set(array)":       arr.Set("k", NewNull()),
+		"SetIndex(string)": NewString("s").SetIndex(0, NewNull()),
+	} {
+		if !errors.Is(err, ErrPathType) {
+			t.Errorf("%s error = %v, want ErrPathType", name, err)
+		}
+	}
+	for name, err := range map[string]error{
+		"Insert(2)":    arr.Insert(2, NewNull()),
+		"Insert(-1)":   arr.Insert(-1, NewNull()),
+		"SetIndex(1)":  arr.SetIndex(1, NewNull()),
+		"RemoveAt(1)":  arr.RemoveAt(1),
+		"RemoveAt(-1)": arr.RemoveAt(-1),
+	} {
+		if !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("%s error = %v, want ErrPathNotFound", name, err)
+		}
+	}
+	if arr.String() != "[1]: 1" || obj.Len() != 0 {
+		t.Errorf("failed mutations changed nodes: %s, %s", arr, obj)
+	}
+	if arr.Delete("k") {
+		t.Error("Delete() on array = true")
+	}
+}
+
+func TestNewNodeRoundTrip(t *testing.T) {
+	om := NewOrderedMap()
+	om.Set("z", []interface{}{1, uint8(2), float32(0.5), "s", nil, true})
+	om.Set("a", map[string]interface{}{"y": 1, "x": map[string]Value{"k": "v"}})
+	om.Set("raw", RawValue("[2]: p,q"))
+
+	n, err := NewNode(om)
+	if err != nil {
+		t.Fatalf("NewNode() error = %v", err)
+	}
+	if got, want := n.Keys(), []string{"z", "a", "raw"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if got, want := n.Get("a").Keys(), []string{"x", "y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("a.Keys() = %v, want %v", got, want)
+	}
+	if got := n.Get("raw").Len(); got != 2 {
+		t.Errorf("raw.Len() = %d, want 2", got)
+	}
+
+	back, err := NewNode(n.Value())
+	if err != nil {
+		t.Fatalf("NewNode(Value()) error = %v", err)
+	}
+	if !reflect.DeepEqual(back, n) {
+		t.Errorf("NewNode(n.Value()) = %v, want %v", back, n)
+	}
+
+	clone := n.Clone()
+	if err := clone.Get("z").SetIndex(0, NewString("changed")); err != nil {
+		t.Fatalf("SetIndex() error = %v", err)
+	}
+	if s, _ := n.Get("z").Index(0).StringValue(); s == "changed" {
+		t.Error("Clone() shares items with the original")
+	}
+
+	if _, err := NewNode(struct{}{}); err == nil {
+		t.Error("NewNode(struct) expected error")
+	}
+	if _, err := NewNode(uint64(1 << 63)); err == nil {
+		t.Error("NewNode(uint64 overflow) expected error")
+	}
+	if _, err := NewNode(json.Number("12345678901234567890")); err == nil {
+		t.Error("NewNode(json.Number overflow) expected error")
+	}
+	if f, err := NewNode(json.Number("1.5e3")); err != nil || f.Kind() != KindFloat {
+		t.Errorf("NewNode(json.Number float) = %v, %v", f, err)
+	}
+}
+
+func TestUnmarshalNodeExpandPaths(t *testing.T) {
+	var n Node
+	if err := UnmarshalFromString("a.b: 1\na.c: 2", &n, WithExpandPaths("safe")); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got, want := n.Get("a").Keys(), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("a.Keys() = %v, want %v", got, want)
+	}
+}
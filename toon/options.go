@@ -16,11 +16,32 @@ func validateEncodeOptions(opts *EncodeOptions) error {
 		}
 	}
 
+	// Validate token budget
+	if opts.TokenBudget < 0 {
+		return &EncodeError{
+			Message: "token budget must be non-negative",
+			Value:   opts.TokenBudget,
+		}
+	}
+
+	// Validate float formats
+	if err := validateFloatFormat(opts.FloatFormat); err != nil {
+		return err
+	}
+	for path, f := range opts.FloatFormats {
+		if _, err := parsePath(path); err != nil {
+			return &EncodeError{Message: fmt.Sprintf("invalid float format path %q", path), Value: path, Cause: err}
+		}
+		if err := validateFloatFormat(f); err != nil {
+			return err
+		}
+	}
+
 	// Validate delimiter
-	if opts.Delimiter != "" && !isValidDelimiter(opts.Delimiter) {
+	if opts.Delimiter != "" && opts.Delimiter != DelimiterAuto && !isValidDelimiter(opts.Delimiter) {
 		return &EncodeError{
-			Message: fmt.Sprintf("invalid delimiter %q, must be one of: %q, %q, %q",
-				opts.Delimiter, comma, tab, pipe),
+			Message: fmt.Sprintf("invalid delimiter %q, must be one of: %q, %q, %q, %q",
+				opts.Delimiter, comma, tab, pipe, DelimiterAuto),
 			Value: opts.Delimiter,
 		}
 	}
@@ -68,6 +89,12 @@ func getEncodeOptions(opts *EncodeOptions) *EncodeOptions {
 		FlattenPaths: opts.FlattenPaths,
 		FlattenDepth: opts.FlattenDepth,
 		Strict:       opts.Strict,
+		SortKeys:     opts.SortKeys,
+		TokenBudget:  opts.TokenBudget,
+		Tokenizer:    opts.Tokenizer,
+		Report:       opts.Report,
+		FloatFormat:  opts.FloatFormat,
+		FloatFormats: opts.FloatFormats,
 	}
 
 	// Handle FlattenDepth defaults for infinite folding
@@ -0,0 +1,367 @@
+package toon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7386) to doc and returns
+// the result.
+//
+// Fields of an object patch are merged recursively into doc; a null field
+// removes the key, and any non-object patch replaces the target. Existing
+// fields keep their position and new fields are appended, so the order of
+// an OrderedMap or *Node document survives the patch. doc and patch are not
+// modified; objects in the result are *OrderedMap values.
+//
+// Example:
+//
+//	patched, err := toon.ApplyMergePatch(config, map[string]interface{}{
+//	    "debug": nil,
+//	    "server": map[string]interface{}{"port": 8081},
+//	})
+func ApplyMergePatch(doc, patch Value) (Value, error) {
+	target, err := NewNode(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewNode(patch)
+	if err != nil {
+		return nil, err
+	}
+	return mergePatch(target, p).Value(), nil
+}
+
+// mergePatch merges patch into target and returns the merged node.
+func mergePatch(target, patch *Node) *Node {
+	if patch.Kind() != KindObject {
+		return patch
+	}
+	if target.Kind() != KindObject {
+		target = NewObject()
+	}
+	for _, f := range patch.fields {
+		if f.Value.IsNull() {
+			target.Delete(f.Key)
+			continue
+		}
+		target.set(f.Key, mergePatch(target.Get(f.Key), f.Value))
+	}
+	return target
+}
+
+// ApplyPatch applies a JSON Patch (RFC 6902) to doc and returns the result.
+//
+// ops is an array of operation objects with the fields op, path, from and
+// value, where paths are JSON Pointers such as "/users/0/name" and "-"
+// addresses the end of an array. Supported operations are add, remove,
+// replace, move, copy and test; test compares values as Equal does.
+//
+// Operations are applied in order and the patch is atomic: on error doc is
+// left untouched and a *PatchError is returned. Missing paths wrap
+// ErrPathNotFound, failed tests wrap ErrTestFailed.
+//
+// Because ops is an ordinary value, a patch can be written in TOON tabular
+// form and decoded with Unmarshal:
+//
+//	ops[2]{op,path,value}:
+//	  replace,/server/port,8081
+//	  remove,/debug,null
+func ApplyPatch(doc, ops Value) (Value, error) {
+	root, err := NewNode(doc)
+	if err != nil {
+		return nil, err
+	}
+	list, err := NewNode(ops)
+	if err != nil {
+		return nil, err
+	}
+	if list.Kind() != KindArray {
+		return nil, &PatchError{Message: fmt.Sprintf("patch must be an array, got %s", list.Kind()), Cause: ErrPathType}
+	}
+
+	for i, op := range list.items {
+		root, err = applyPatchOp(root, op)
+		if err != nil {
+			if pe, ok := err.(*PatchError); ok {
+				pe.Index = i
+			}
+			return nil, err
+		}
+	}
+	return root.Value(), nil
+}
+
+// applyPatchOp applies one operation to root and returns the new root.
+func applyPatchOp(root, op *Node) (*Node, error) {
+	if op.Kind() != KindObject {
+		return nil, &PatchError{Message: fmt.Sprintf("operation must be an object, got %s", op.Kind())}
+	}
+	name, _ := op.Get("op").StringValue()
+	path, ok := op.Get("path").StringValue()
+	if !ok {
+		return nil, &PatchError{Op: name, Message: `missing "path"`}
+	}
+	pe := &PatchError{Op: name, Path: path}
+
+	segments, err := parsePointer(path)
+	if err != nil {
+		return nil, pe.wrap(err)
+	}
+
+	switch name {
+	case "add", "replace", "test":
+		if !op.Has("value") {
+			pe.Message = `missing "value"`
+			return nil, pe
+		}
+		value := op.Get("value")
+		switch name {
+		case "add":
+			root, err = pointerAdd(root, segments, value)
+		case "replace":
+			root, err = pointerReplace(root, segments, value)
+		default:
+			var current *Node
+			current, err = pointerGet(root, segments)
+			if err == nil && !nodesEqual(current, value, &CompareOptions{}) {
+				pe.Message = "value does not match"
+				pe.Cause = ErrTestFailed
+				return nil, pe
+			}
+		}
+
+	case "remove":
+		root, _, err = pointerRemove(root, segments)
+
+	case "move", "copy":
+		fromPath, ok := op.Get("from").StringValue()
+		if !ok {
+			pe.Message = `missing "from"`
+			return nil, pe
+		}
+		from, ferr := parsePointer(fromPath)
+		if ferr != nil {
+			return nil, pe.wrap(ferr)
+		}
+
+		var value *Node
+		if name == "move" {
+			if len(from) < len(segments) && isPointerPrefix(from, segments) {
+				pe.Message = fmt.Sprintf("cannot move '%s' into itself", fromPath)
+				return nil, pe
+			}
+			root, value, err = pointerRemove(root, from)
+		} else {
+			value, err = pointerGet(root, from)
+			value = value.Clone()
+		}
+		if err == nil {
+			root, err = pointerAdd(root, segments, value)
+		}
+
+	default:
+		pe.Message = fmt.Sprintf("unknown operation %q", name)
+		return nil, pe
+	}
+
+	if err != nil {
+		return nil, pe.wrap(err)
+	}
+	return root, nil
+}
+
+// wrap fills the message and cause of e from a path error.
+func (e *PatchError) wrap(err error) *PatchError {
+	e.Message = err.Error()
+	if pathErr, ok := err.(*PathError); ok {
+		e.Message = pathErr.Message
+		e.Cause = pathErr.Cause
+	}
+	return e
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, &PathError{Path: pointer, Message: "JSON Pointer must start with '/'"}
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, tok := range tokens {
+		for j := 0; j < len(tok); j++ {
+			if tok[j] == '~' && (j+1 == len(tok) || (tok[j+1] != '0' && tok[j+1] != '1')) {
+				return nil, &PathError{Path: pointer, Message: "invalid '~' escape in JSON Pointer"}
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// formatPointer joins tokens into a JSON Pointer.
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(tok, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// isPointerPrefix reports whether prefix addresses an ancestor of (or the
+// same location as) tokens.
+func isPointerPrefix(prefix, tokens []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pointerGet returns the node at tokens.
+func pointerGet(root *Node, tokens []string) (*Node, error) {
+	cur := root
+	for i, tok := range tokens {
+		switch cur.Kind() {
+		case KindObject:
+			next := cur.Get(tok)
+			if next == nil {
+				return nil, pointerNotFound(tokens[:i+1], fmt.Sprintf("key %q not found", tok))
+			}
+			cur = next
+		case KindArray:
+			index, err := pointerIndex(cur, tokens[:i+1], false)
+			if err != nil {
+				return nil, err
+			}
+			cur = cur.items[index]
+		default:
+			return nil, pointerTypeError(tokens[:i+1], fmt.Sprintf("cannot look up %q in %s", tok, cur.Kind()))
+		}
+	}
+	return cur, nil
+}
+
+// pointerParent returns the container holding the last token of tokens.
+func pointerParent(root *Node, tokens []string) (*Node, error) {
+	parent, err := pointerGet(root, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	if k := parent.Kind(); k != KindObject && k != KindArray {
+		return nil, pointerTypeError(tokens, fmt.Sprintf("cannot look up %q in %s", tokens[len(tokens)-1], k))
+	}
+	return parent, nil
+}
+
+// pointerAdd adds value at tokens: objects set the key, arrays insert
+// before the index or append for "-". An empty pointer replaces the root.
+func pointerAdd(root *Node, tokens []string, value *Node) (*Node, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parent, err := pointerParent(root, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	key := tokens[len(tokens)-1]
+	if parent.Kind() == KindObject {
+		parent.set(key, value)
+		return root, nil
+	}
+	index, err := pointerIndex(parent, tokens, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := parent.Insert(index, value); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// pointerReplace replaces the existing value at tokens.
+func pointerReplace(root *Node, tokens []string, value *Node) (*Node, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	if _, err := pointerGet(root, tokens); err != nil {
+		return nil, err
+	}
+	parent, _ := pointerParent(root, tokens)
+
+	key := tokens[len(tokens)-1]
+	if parent.Kind() == KindObject {
+		parent.set(key, value)
+		return root, nil
+	}
+	index, _ := strconv.Atoi(key)
+	if err := parent.SetIndex(index, value); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// pointerRemove removes the value at tokens and returns it.
+func pointerRemove(root *Node, tokens []string) (*Node, *Node, error) {
+	if len(tokens) == 0 {
+		return nil, nil, &PathError{Message: "cannot remove the document root", Cause: ErrPathType}
+	}
+	removed, err := pointerGet(root, tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+	parent, _ := pointerParent(root, tokens)
+
+	key := tokens[len(tokens)-1]
+	if parent.Kind() == KindObject {
+		parent.Delete(key)
+	} else {
+		index, _ := strconv.Atoi(key)
+		if err := parent.RemoveAt(index); err != nil {
+			return nil, nil, err
+		}
+	}
+	return root, removed, nil
+}
+
+// pointerIndex parses the last token of tokens as an index into arr.
+// When insert is true, "-" and len(arr) address the end of the array.
+func pointerIndex(arr *Node, tokens []string, insert bool) (int, error) {
+	tok := tokens[len(tokens)-1]
+	limit := len(arr.items)
+	if insert {
+		if tok == "-" {
+			return limit, nil
+		}
+		limit++
+	}
+
+	index, err := strconv.Atoi(tok)
+	if err != nil || index < 0 || (len(tok) > 1 && tok[0] == '0') || tok[0] == '+' {
+		return 0, pointerTypeError(tokens, fmt.Sprintf("invalid array index %q", tok))
+	}
+	if index >= limit {
+		return 0, pointerNotFound(tokens, fmt.Sprintf("index %d out of range (length %d)", index, len(arr.items)))
+	}
+	return index, nil
+}
+
+// pointerNotFound returns a PathError wrapping ErrPathNotFound.
+func pointerNotFound(tokens []string, msg string) error {
+	return &PathError{Path: formatPointer(tokens), Message: msg, Cause: ErrPathNotFound}
+}
+
+// pointerTypeError returns a PathError wrapping ErrPathType.
+func pointerTypeError(tokens []string, msg string) error {
+	return &PathError{Path: formatPointer(tokens), Message: msg, Cause: ErrPathType}
+}
@@ -0,0 +1,179 @@
+package toon
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	var doc Node
+	if err := UnmarshalFromString("title: Goodbye!\nauthor:\n  givenName: John\n  familyName: Doe\ntags[2]: example,sample\ncontent: This will be unchanged", &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	patch := map[string]Value{
+		"title":       "Hello!",
+		"phoneNumber": "+01-555-1234",
+		"author":      map[string]Value{"familyName": nil},
+		"tags":        []Value{"example"},
+	}
+
+	got, err := ApplyMergePatch(&doc, patch)
+	if err != nil {
+		t.Fatalf("ApplyMergePatch() error = %v", err)
+	}
+	s, err := MarshalToString(got)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := "title: Hello!\nauthor:\n  givenName: John\ntags[1]: example\ncontent: This will be unchanged\nphoneNumber: +01-555-1234"
+	if s != want {
+		t.Errorf("ApplyMergePatch() =\n%s\nwant\n%s", s, want)
+	}
+	if doc.Get("title").s != "Goodbye!" {
+		t.Error("ApplyMergePatch() modified doc")
+	}
+}
+
+func TestApplyMergePatchNonObject(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc, patch Value
+		want       Value
+	}{
+		{"replace scalar", map[string]Value{"a": "b"}, "c", "c"},
+		{"object over array", []Value{1}, map[string]Value{"a": 1}, map[string]Value{"a": 1}},
+		{"array replaces array", map[string]Value{"a": []Value{1, 2}}, map[string]Value{"a": []Value{3}}, map[string]Value{"a": []Value{3}}},
+		{"remove missing", map[string]Value{"a": 1}, map[string]Value{"b": nil}, map[string]Value{"a": 1}},
+		{"nested create", map[string]Value{}, map[string]Value{"a": map[string]Value{"b": map[string]Value{"c": nil, "d": 1}}}, map[string]Value{"a": map[string]Value{"b": map[string]Value{"d": 1}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyMergePatch(tt.doc, tt.patch)
+			if err != nil {
+				t.Fatalf("ApplyMergePatch() error = %v", err)
+			}
+			if !Equal(got, tt.want) {
+				t.Errorf("ApplyMergePatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	var doc Node
+	if err := UnmarshalFromString("name: app\nservers[2]{host,port}:\n  a,80\n  b,81\ndebug: true", &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	var patch map[string]interface{}
+	ops := "ops[5]{op,path,from,value}:\n" +
+		"  test,/name,null,app\n" +
+		"  replace,/servers/1/port,null,8081\n" +
+		"  remove,/debug,null,null\n" +
+		"  copy,/backup,/servers/0,null\n" +
+		"  move,/servers/-,/backup,null"
+	if err := UnmarshalFromString(ops, &patch); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	got, err := ApplyPatch(&doc, patch["ops"])
+	if err != nil {
+		t.Fatalf("ApplyPatch() error = %v", err)
+	}
+	s, err := MarshalToString(got)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := "name: app\nservers[3]{host,port}:\n  a,80\n  b,8081\n  a,80"
+	if s != want {
+		t.Errorf("ApplyPatch() =\n%s\nwant\n%s", s, want)
+	}
+}
+
+func TestApplyPatchOperations(t *testing.T) {
+	doc := map[string]Value{"foo": []Value{"bar", "baz"}, "a/b": 1, "m~n": 2}
+
+	tests := []struct {
+		name string
+		op   map[string]Value
+		want Value
+	}{
+		{"add array index", map[string]Value{"op": "add", "path": "/foo/1", "value": "qux"},
+			map[string]Value{"foo": []Value{"bar", "qux", "baz"}, "a/b": 1, "m~n": 2}},
+		{"add existing key", map[string]Value{"op": "add", "path": "/a~1b", "value": 3},
+			map[string]Value{"foo": []Value{"bar", "baz"}, "a/b": 3, "m~n": 2}},
+		{"remove array element", map[string]Value{"op": "remove", "path": "/foo/0"},
+			map[string]Value{"foo": []Value{"baz"}, "a/b": 1, "m~n": 2}},
+		{"replace escaped key", map[string]Value{"op": "replace", "path": "/m~0n", "value": nil},
+			map[string]Value{"foo": []Value{"bar", "baz"}, "a/b": 1, "m~n": nil}},
+		{"move within array", map[string]Value{"op": "move", "from": "/foo/0", "path": "/foo/1"},
+			map[string]Value{"foo": []Value{"baz", "bar"}, "a/b": 1, "m~n": 2}},
+		{"replace root", map[string]Value{"op": "replace", "path": "", "value": "x"}, "x"},
+		{"test number", map[string]Value{"op": "test", "path": "/a~1b", "value": 1.0}, doc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyPatch(doc, []Value{tt.op})
+			if err != nil {
+				t.Fatalf("ApplyPatch() error = %v", err)
+			}
+			if !Equal(got, tt.want) {
+				t.Errorf("ApplyPatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	doc := map[string]Value{"foo": []Value{"bar"}, "n": 1}
+
+	tests := []struct {
+		name  string
+		ops   Value
+		cause error
+	}{
+		{"missing key", []Value{map[string]Value{"op": "remove", "path": "/nope"}}, ErrPathNotFound},
+		{"index out of range", []Value{map[string]Value{"op": "add", "path": "/foo/2", "value": 1}}, ErrPathNotFound},
+		{"leading zero index", []Value{map[string]Value{"op": "replace", "path": "/foo/00", "value": 1}}, ErrPathType},
+		{"index into scalar", []Value{map[string]Value{"op": "add", "path": "/n/x", "value": 1}}, ErrPathType},
+		{"test failed", []Value{map[string]Value{"op": "test", "path": "/n", "value": "1"}}, ErrTestFailed},
+		{"unknown op", []Value{map[string]Value{"op": "frob", "path": "/n"}}, nil},
+		{"missing value", []Value{map[string]Value{"op": "add", "path": "/x"}}, nil},
+		{"bad pointer", []Value{map[string]Value{"op": "remove", "path": "n"}}, nil},
+		{"move into child", []Value{map[string]Value{"op": "move", "from": "/foo", "path": "/foo/0"}}, nil},
+		{"not a list", map[string]Value{"op": "remove", "path": "/n"}, ErrPathType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyPatch(doc, tt.ops)
+			var pe *PatchError
+			if !errors.As(err, &pe) {
+				t.Fatalf("ApplyPatch() error = %v, want *PatchError", err)
+			}
+			if tt.cause != nil && !errors.Is(err, tt.cause) {
+				t.Errorf("ApplyPatch() error = %v, want cause %v", err, tt.cause)
+			}
+		})
+	}
+}
+
+func TestApplyPatchAtomic(t *testing.T) {
+	doc := map[string]Value{"a": int64(1)}
+	ops := []Value{
+		map[string]Value{"op": "replace", "path": "/a", "value": 2},
+		map[string]Value{"op": "remove", "path": "/missing"},
+	}
+
+	_, err := ApplyPatch(doc, ops)
+	var pe *PatchError
+	if !errors.As(err, &pe) || pe.Index != 1 || pe.Op != "remove" {
+		t.Fatalf("ApplyPatch() error = %#v, want PatchError for operation 1", err)
+	}
+	if doc["a"] != int64(1) {
+		t.Errorf("ApplyPatch() modified doc: %v", doc)
+	}
+}
@@ -0,0 +1,132 @@
+package toon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is a single step of a path: an object key or an array index.
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// String formats the segment using path syntax.
+func (s pathSegment) String() string {
+	if s.isIndex {
+		return openBracket + strconv.Itoa(s.index) + closeBracket
+	}
+	if isValidIdentifier(s.key) {
+		return s.key
+	}
+	return doubleQuote + escapeString(s.key) + doubleQuote
+}
+
+// parsePath parses a path such as `users[3].address."zip-code"`.
+//
+// Keys are separated by dots, keys that are not plain identifiers are
+// written as quoted TOON strings, and array elements are selected with [i].
+// The empty path refers to the root value.
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	i := 0
+	needKey := false
+
+	for i < len(path) {
+		switch ch := path[i]; {
+		case ch == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, pathSyntaxError(path, "unterminated index")
+			}
+			index, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || index < 0 {
+				return nil, pathSyntaxError(path, fmt.Sprintf("invalid index %q", path[i+1:i+end]))
+			}
+			if needKey {
+				return nil, pathSyntaxError(path, "expected key after '.'")
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			i += end + 1
+
+		case ch == '.':
+			if len(segments) == 0 || needKey {
+				return nil, pathSyntaxError(path, "unexpected '.'")
+			}
+			needKey = true
+			i++
+
+		default:
+			if len(segments) > 0 && !needKey {
+				return nil, pathSyntaxError(path, "expected '.' or '[' between segments")
+			}
+			key, n, err := parsePathKey(path[i:])
+			if err != nil {
+				return nil, pathSyntaxError(path, err.Error())
+			}
+			segments = append(segments, pathSegment{key: key})
+			needKey = false
+			i += n
+		}
+	}
+
+	if needKey {
+		return nil, pathSyntaxError(path, "path ends with '.'")
+	}
+	return segments, nil
+}
+
+// parsePathKey parses a quoted or unquoted key at the start of s and
+// returns it with the number of bytes consumed.
+func parsePathKey(s string) (string, int, error) {
+	if s[0] != '"' {
+		end := strings.IndexAny(s, ".[")
+		if end < 0 {
+			end = len(s)
+		}
+		return s[:end], end, nil
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			key, err := validateAndUnescape(s[1:i])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid quoted key: %s", errorMessage(err))
+			}
+			return key, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted key")
+}
+
+// formatPath formats segments back into path syntax.
+func formatPath(segments []pathSegment) string {
+	var b strings.Builder
+	for i, seg := range segments {
+		if i > 0 && !seg.isIndex {
+			b.WriteString(".")
+		}
+		b.WriteString(seg.String())
+	}
+	return b.String()
+}
+
+// pathSyntaxError creates a PathError for a malformed path.
+func pathSyntaxError(path, msg string) error {
+	return &PathError{Path: path, Message: "invalid path: " + msg}
+}
+
+// pathNotFound creates a PathError wrapping ErrPathNotFound.
+func pathNotFound(segments []pathSegment, msg string) error {
+	return &PathError{Path: formatPath(segments), Message: msg, Cause: ErrPathNotFound}
+}
+
+// pathTypeError creates a PathError wrapping ErrPathType.
+func pathTypeError(segments []pathSegment, msg string) error {
+	return &PathError{Path: formatPath(segments), Message: msg, Cause: ErrPathType}
+}
@@ -0,0 +1,52 @@
+package toon
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+		segs int
+	}{
+		{"", "", 0},
+		{"a", "a", 1},
+		{"a.b[2].c", "a.b[2].c", 4},
+		{`"x.y"[0]`, `"x.y"[0]`, 2},
+		{"[1][0]", "[1][0]", 2},
+	}
+
+	for _, tt := range tests {
+		segs, err := parsePath(tt.path)
+		if err != nil {
+			t.Errorf("parsePath(%q) error = %v", tt.path, err)
+			continue
+		}
+		if len(segs) != tt.segs {
+			t.Errorf("parsePath(%q) returned %d segments, want %d", tt.path, len(segs), tt.segs)
+		}
+		if got := formatPath(segs); got != tt.want {
+			t.Errorf("formatPath(parsePath(%q)) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParsePathErrors(t *testing.T) {
+	for _, path := range []string{"a.", ".a", "a[", "a[-1]", "a[x]", `"a`, "a..b"} {
+		if _, err := parsePath(path); err == nil {
+			t.Errorf("parsePath(%q) expected error", path)
+		}
+	}
+}
+
+func TestPathError(t *testing.T) {
+	err := pathNotFound([]pathSegment{{key: "a"}, {index: 1, isIndex: true}}, "index 1 out of range")
+	if !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("errors.Is(%v, ErrPathNotFound) = false", err)
+	}
+	if got, want := err.Error(), "index 1 out of range (path: 'a[1]')"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -271,6 +271,7 @@ func TestEncodeFloat_EdgeCases(t *testing.T) {
 		{"float32 max", float32(math.MaxFloat32), "340282346638528860000000000000000000000", false},
 		{"whole number within safe int range", float64(9007199254740992), "9007199254740992", false}, // 2^53 - max safe integer for float64
 		{"negative whole number", float64(-9007199254740992), "-9007199254740992", false},
+		{"2^63 is beyond int64", float64(math.MaxInt64), "9223372036854776000", false}, // MaxInt64 rounds up to 2^63
 		{"invalid type (string)", "not a float", "", true},
 	}
 
@@ -293,6 +294,16 @@ func TestEncodeFloat_EdgeCases(t *testing.T) {
 	}
 }
 
+// TestNormalizeFloat_Int64Boundary tests that 2^63 is not converted to int64
+func TestNormalizeFloat_Int64Boundary(t *testing.T) {
+	if got := normalizeFloat(float64(math.MaxInt64)); got != float64(math.MaxInt64) {
+		t.Errorf("normalizeFloat(2^63) = %v (%T), want float64", got, got)
+	}
+	if got := normalizeFloat(float64(math.MinInt64)); got != int64(math.MinInt64) {
+		t.Errorf("normalizeFloat(-2^63) = %v (%T), want int64", got, got)
+	}
+}
+
 // TestEncodeString_AllDelimiters tests string encoding with all valid delimiters
 func TestEncodeString_AllDelimiters(t *testing.T) {
 	tests := []struct {
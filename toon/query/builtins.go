@@ -0,0 +1,272 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// builtinFunc evaluates a builtin with unevaluated arguments.
+type builtinFunc func(in toon.Value, args []expr) ([]toon.Value, error)
+
+// builtinKey identifies a builtin by name and arity.
+type builtinKey struct {
+	name  string
+	arity int
+}
+
+// builtins holds the supported functions.
+var builtins map[builtinKey]builtinFunc
+
+func init() {
+	builtins = map[builtinKey]builtinFunc{
+		{"empty", 0}:   builtinEmpty,
+		{"not", 0}:     builtinNot,
+		{"length", 0}:  builtinLength,
+		{"keys", 0}:    builtinKeys,
+		{"has", 1}:     builtinHas,
+		{"select", 1}:  builtinSelect,
+		{"map", 1}:     builtinMap,
+		{"sort", 0}:    builtinSort,
+		{"sort_by", 1}: builtinSortBy,
+		{"reverse", 0}: builtinReverse,
+		{"first", 0}:   builtinFirst,
+		{"last", 0}:    builtinLast,
+		{"first", 1}:   builtinFirstOf,
+		{"limit", 2}:   builtinLimit,
+	}
+}
+
+// builtinEmpty produces no outputs.
+func builtinEmpty(toon.Value, []expr) ([]toon.Value, error) {
+	return nil, nil
+}
+
+// builtinNot negates the truthiness of its input.
+func builtinNot(in toon.Value, _ []expr) ([]toon.Value, error) {
+	return []toon.Value{!truthy(in)}, nil
+}
+
+// builtinLength returns the length of a string, array or object, the
+// absolute value of a number, or 0 for null.
+func builtinLength(in toon.Value, _ []expr) ([]toon.Value, error) {
+	switch v := in.(type) {
+	case nil:
+		return []toon.Value{int64(0)}, nil
+	case string:
+		return []toon.Value{int64(utf8.RuneCountInString(v))}, nil
+	case bool:
+		return nil, &Error{Message: "boolean has no length"}
+	}
+	if items, ok := asArray(in); ok {
+		return []toon.Value{int64(len(items))}, nil
+	}
+	if keys, ok := objectKeys(in); ok {
+		return []toon.Value{int64(len(keys))}, nil
+	}
+	if i, ok := in.(int64); ok {
+		if i < 0 {
+			i = -i
+		}
+		return []toon.Value{i}, nil
+	}
+	if f, ok := toFloat(in); ok {
+		return []toon.Value{math.Abs(f)}, nil
+	}
+	return nil, &Error{Message: fmt.Sprintf("%s has no length", typeName(in))}
+}
+
+// builtinKeys returns the sorted keys of an object or the indexes of an array.
+func builtinKeys(in toon.Value, _ []expr) ([]toon.Value, error) {
+	if keys, ok := objectKeys(in); ok {
+		keys = sortedCopy(keys)
+		out := make([]toon.Value, len(keys))
+		for i, k := range keys {
+			out[i] = k
+		}
+		return []toon.Value{out}, nil
+	}
+	if items, ok := asArray(in); ok {
+		out := make([]toon.Value, len(items))
+		for i := range items {
+			out[i] = int64(i)
+		}
+		return []toon.Value{out}, nil
+	}
+	return nil, &Error{Message: fmt.Sprintf("%s has no keys", typeName(in))}
+}
+
+// builtinHas reports whether an object has a key or an array has an index.
+func builtinHas(in toon.Value, args []expr) ([]toon.Value, error) {
+	keys, err := args[0].eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []toon.Value
+	for _, k := range keys {
+		switch key := k.(type) {
+		case string:
+			if _, ok := objectKeys(in); !ok {
+				return nil, &Error{Message: fmt.Sprintf("cannot check whether %s has a string key", typeName(in))}
+			}
+			_, found := objectGet(in, key)
+			out = append(out, found)
+		default:
+			n, isNumber := toFloat(k)
+			items, isArray := asArray(in)
+			if !isNumber || !isArray {
+				return nil, &Error{Message: fmt.Sprintf("cannot check whether %s has a %s key", typeName(in), typeName(k))}
+			}
+			out = append(out, n >= 0 && n < float64(len(items)))
+		}
+	}
+	return out, nil
+}
+
+// builtinSelect emits its input for each truthy output of the predicate.
+func builtinSelect(in toon.Value, args []expr) ([]toon.Value, error) {
+	conds, err := args[0].eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []toon.Value
+	for _, c := range conds {
+		if truthy(c) {
+			out = append(out, in)
+		}
+	}
+	return out, nil
+}
+
+// builtinMap applies f to every element of an array: [.[] | f].
+func builtinMap(in toon.Value, args []expr) ([]toon.Value, error) {
+	items, ok := asArray(in)
+	if !ok {
+		return nil, &Error{Message: fmt.Sprintf("cannot map over %s", typeName(in))}
+	}
+	out := []toon.Value{}
+	for _, item := range items {
+		results, err := args[0].eval(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, results...)
+	}
+	return []toon.Value{out}, nil
+}
+
+// builtinSort sorts an array.
+func builtinSort(in toon.Value, _ []expr) ([]toon.Value, error) {
+	items, ok := asArray(in)
+	if !ok {
+		return nil, &Error{Message: fmt.Sprintf("cannot sort %s", typeName(in))}
+	}
+	sorted := append([]toon.Value{}, items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return compare(sorted[i], sorted[j]) < 0 })
+	return []toon.Value{sorted}, nil
+}
+
+// builtinSortBy sorts an array by the outputs of f.
+func builtinSortBy(in toon.Value, args []expr) ([]toon.Value, error) {
+	items, ok := asArray(in)
+	if !ok {
+		return nil, &Error{Message: fmt.Sprintf("cannot sort %s", typeName(in))}
+	}
+
+	keys := make([]toon.Value, len(items))
+	for i, item := range items {
+		k, err := args[0].eval(item)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = k
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return compare(keys[order[i]], keys[order[j]]) < 0 })
+
+	sorted := make([]toon.Value, len(items))
+	for i, idx := range order {
+		sorted[i] = items[idx]
+	}
+	return []toon.Value{sorted}, nil
+}
+
+// builtinReverse reverses an array.
+func builtinReverse(in toon.Value, _ []expr) ([]toon.Value, error) {
+	if in == nil {
+		return []toon.Value{[]toon.Value{}}, nil
+	}
+	items, ok := asArray(in)
+	if !ok {
+		return nil, &Error{Message: fmt.Sprintf("cannot reverse %s", typeName(in))}
+	}
+	reversed := make([]toon.Value, len(items))
+	for i, item := range items {
+		reversed[len(items)-1-i] = item
+	}
+	return []toon.Value{reversed}, nil
+}
+
+// builtinFirst returns the first element of an array: .[0].
+func builtinFirst(in toon.Value, _ []expr) ([]toon.Value, error) {
+	v, err := index(in, int64(0))
+	if err != nil {
+		return nil, err
+	}
+	return []toon.Value{v}, nil
+}
+
+// builtinLast returns the last element of an array: .[-1].
+func builtinLast(in toon.Value, _ []expr) ([]toon.Value, error) {
+	v, err := index(in, int64(-1))
+	if err != nil {
+		return nil, err
+	}
+	return []toon.Value{v}, nil
+}
+
+// builtinFirstOf returns the first output of f.
+func builtinFirstOf(in toon.Value, args []expr) ([]toon.Value, error) {
+	out, err := args[0].eval(in)
+	if err != nil || len(out) == 0 {
+		return nil, err
+	}
+	return out[:1], nil
+}
+
+// builtinLimit returns at most n outputs of f: limit(n; f).
+func builtinLimit(in toon.Value, args []expr) ([]toon.Value, error) {
+	counts, err := args[0].eval(in)
+	if err != nil {
+		return nil, err
+	}
+	out, err := args[1].eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []toon.Value
+	for _, c := range counts {
+		n, ok := toFloat(c)
+		if !ok {
+			return nil, &Error{Message: fmt.Sprintf("limit count must be a number, got %s", typeName(c))}
+		}
+		if n <= 0 {
+			continue
+		}
+		if int(n) < len(out) {
+			result = append(result, out[:int(n)]...)
+		} else {
+			result = append(result, out...)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,555 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// expr is a compiled expression. Evaluating an expression against an input
+// produces zero or more outputs.
+type expr interface {
+	eval(in toon.Value) ([]toon.Value, error)
+}
+
+// identityExpr is ".".
+type identityExpr struct{}
+
+// literalExpr is a constant.
+type literalExpr struct {
+	value toon.Value
+}
+
+// pipeExpr is "left | right".
+type pipeExpr struct {
+	left, right expr
+}
+
+// commaExpr is "left, right".
+type commaExpr struct {
+	left, right expr
+}
+
+// logicExpr is "left and right" or "left or right".
+type logicExpr struct {
+	op          string
+	left, right expr
+}
+
+// compareExpr is a comparison such as "left == right".
+type compareExpr struct {
+	op          string
+	left, right expr
+}
+
+// indexExpr is ".name", ."name" or "base[index]".
+type indexExpr struct {
+	base, index expr
+}
+
+// iterateExpr is "base[]".
+type iterateExpr struct {
+	base expr
+}
+
+// arrayExpr is "[body]".
+type arrayExpr struct {
+	body expr
+}
+
+// objectExpr is "{key: value, ...}".
+type objectExpr struct {
+	entries []objectEntry
+}
+
+// objectEntry is a single entry of an object construction.
+type objectEntry struct {
+	key, value expr
+}
+
+// callExpr is a builtin function call.
+type callExpr struct {
+	name string
+	fn   builtinFunc
+	args []expr
+}
+
+func (identityExpr) eval(in toon.Value) ([]toon.Value, error) {
+	return []toon.Value{in}, nil
+}
+
+func (e *literalExpr) eval(toon.Value) ([]toon.Value, error) {
+	return []toon.Value{e.value}, nil
+}
+
+func (e *pipeExpr) eval(in toon.Value) ([]toon.Value, error) {
+	lefts, err := e.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []toon.Value
+	for _, l := range lefts {
+		rights, err := e.right.eval(l)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rights...)
+	}
+	return out, nil
+}
+
+func (e *commaExpr) eval(in toon.Value) ([]toon.Value, error) {
+	lefts, err := e.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	rights, err := e.right.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	return append(lefts, rights...), nil
+}
+
+func (e *logicExpr) eval(in toon.Value) ([]toon.Value, error) {
+	lefts, err := e.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []toon.Value
+	for _, l := range lefts {
+		lt := truthy(l)
+		if (e.op == "or" && lt) || (e.op == "and" && !lt) {
+			out = append(out, lt)
+			continue
+		}
+		rights, err := e.right.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rights {
+			out = append(out, truthy(r))
+		}
+	}
+	return out, nil
+}
+
+func (e *compareExpr) eval(in toon.Value) ([]toon.Value, error) {
+	lefts, err := e.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	rights, err := e.right.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []toon.Value
+	for _, l := range lefts {
+		for _, r := range rights {
+			c := compare(l, r)
+			switch e.op {
+			case "==":
+				out = append(out, c == 0)
+			case "!=":
+				out = append(out, c != 0)
+			case "<":
+				out = append(out, c < 0)
+			case "<=":
+				out = append(out, c <= 0)
+			case ">":
+				out = append(out, c > 0)
+			case ">=":
+				out = append(out, c >= 0)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (e *indexExpr) eval(in toon.Value) ([]toon.Value, error) {
+	bases, err := e.base.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := e.index.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []toon.Value
+	for _, b := range bases {
+		for _, idx := range indexes {
+			v, err := index(b, idx)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func (e *iterateExpr) eval(in toon.Value) ([]toon.Value, error) {
+	bases, err := e.base.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []toon.Value
+	for _, b := range bases {
+		if items, ok := asArray(b); ok {
+			out = append(out, items...)
+			continue
+		}
+		if keys, ok := objectKeys(b); ok {
+			for _, k := range keys {
+				v, _ := objectGet(b, k)
+				out = append(out, v)
+			}
+			continue
+		}
+		return nil, &Error{Message: fmt.Sprintf("cannot iterate over %s", typeName(b))}
+	}
+	return out, nil
+}
+
+func (e *arrayExpr) eval(in toon.Value) ([]toon.Value, error) {
+	items := []toon.Value{}
+	if e.body != nil {
+		out, err := e.body.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, out...)
+	}
+	return []toon.Value{items}, nil
+}
+
+func (e *objectExpr) eval(in toon.Value) ([]toon.Value, error) {
+	// Entries producing several outputs yield one object per combination.
+	objects := []*toon.OrderedMap{toon.NewOrderedMap()}
+
+	for _, entry := range e.entries {
+		keys, err := entry.key.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		values, err := entry.value.eval(in)
+		if err != nil {
+			return nil, err
+		}
+
+		next := make([]*toon.OrderedMap, 0, len(objects)*len(keys)*len(values))
+		for _, obj := range objects {
+			for _, k := range keys {
+				key, ok := k.(string)
+				if !ok {
+					return nil, &Error{Message: fmt.Sprintf("object keys must be strings, got %s", typeName(k))}
+				}
+				for _, v := range values {
+					o := copyObject(obj)
+					o.Set(key, v)
+					next = append(next, o)
+				}
+			}
+		}
+		objects = next
+	}
+
+	out := make([]toon.Value, len(objects))
+	for i, o := range objects {
+		out[i] = o
+	}
+	return out, nil
+}
+
+func (e *callExpr) eval(in toon.Value) ([]toon.Value, error) {
+	return e.fn(in, e.args)
+}
+
+// index applies a key or array index to v.
+func index(v, idx toon.Value) (toon.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if key, ok := idx.(string); ok {
+		if _, isObject := objectKeys(v); !isObject {
+			return nil, &Error{Message: fmt.Sprintf("cannot index %s with %q", typeName(v), key)}
+		}
+		val, _ := objectGet(v, key)
+		return val, nil
+	}
+
+	n, ok := toFloat(idx)
+	if !ok {
+		return nil, &Error{Message: fmt.Sprintf("cannot index with %s", typeName(idx))}
+	}
+	items, ok := asArray(v)
+	if !ok {
+		return nil, &Error{Message: fmt.Sprintf("cannot index %s with number", typeName(v))}
+	}
+
+	i := int(math.Floor(n))
+	if i < 0 {
+		i += len(items)
+	}
+	if i < 0 || i >= len(items) {
+		return nil, nil
+	}
+	return items[i], nil
+}
+
+// asArray returns the elements of an array value.
+func asArray(v toon.Value) ([]toon.Value, bool) {
+	switch a := v.(type) {
+	case []toon.Value:
+		return a, true
+	case nil, string:
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	items := make([]toon.Value, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, true
+}
+
+// objectKeys returns the keys of an object value: insertion order for
+// OrderedMap and sorted order for plain maps, matching Marshal.
+func objectKeys(v toon.Value) ([]string, bool) {
+	switch m := v.(type) {
+	case *toon.OrderedMap:
+		return m.Keys(), true
+	case toon.OrderedMap:
+		return m.Keys(), true
+	case nil:
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys, true
+}
+
+// objectGet returns the value of key in an object value.
+func objectGet(v toon.Value, key string) (toon.Value, bool) {
+	switch m := v.(type) {
+	case *toon.OrderedMap:
+		return m.Get(key)
+	case toon.OrderedMap:
+		return m.Get(key)
+	case map[string]toon.Value:
+		val, ok := m[key]
+		return val, ok
+	case map[string]interface{}:
+		val, ok := m[key]
+		return val, ok
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return nil, false
+	}
+	item := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+	if !item.IsValid() {
+		return nil, false
+	}
+	return item.Interface(), true
+}
+
+// copyObject returns a shallow copy of an OrderedMap.
+func copyObject(o *toon.OrderedMap) *toon.OrderedMap {
+	c := toon.NewOrderedMap()
+	for _, k := range o.Keys() {
+		v, _ := o.Get(k)
+		c.Set(k, v)
+	}
+	return c
+}
+
+// truthy reports whether v is neither null nor false.
+func truthy(v toon.Value) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return v != nil
+}
+
+// toFloat converts any Go number to float64.
+func toFloat(v toon.Value) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// typeRank orders values of different types: null < false < true < numbers
+// < strings < arrays < objects.
+func typeRank(v toon.Value) int {
+	switch b := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if b {
+			return 2
+		}
+		return 1
+	case string:
+		return 4
+	}
+	if _, ok := toFloat(v); ok {
+		return 3
+	}
+	if _, ok := asArray(v); ok {
+		return 5
+	}
+	return 6
+}
+
+// compare orders two values, returning -1, 0 or +1.
+func compare(a, b toon.Value) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return sign(ra - rb)
+	}
+
+	switch ra {
+	case 3:
+		return compareNumbers(a, b)
+	case 4:
+		as, bs := a.(string), b.(string)
+		switch {
+		case as < bs:
+			return -1
+		case as > bs:
+			return 1
+		}
+		return 0
+	case 5:
+		aa, _ := asArray(a)
+		ba, _ := asArray(b)
+		for i := 0; i < len(aa) && i < len(ba); i++ {
+			if c := compare(aa[i], ba[i]); c != 0 {
+				return c
+			}
+		}
+		return sign(len(aa) - len(ba))
+	case 6:
+		return compareObjects(a, b)
+	}
+	return 0
+}
+
+// compareNumbers compares two numbers, exactly when both are integers.
+func compareNumbers(a, b toon.Value) int {
+	ai, aInt := a.(int64)
+	bi, bInt := b.(int64)
+	if aInt && bInt {
+		switch {
+		case ai < bi:
+			return -1
+		case ai > bi:
+			return 1
+		}
+		return 0
+	}
+
+	af, _ := toFloat(a)
+	bf, _ := toFloat(b)
+	switch {
+	case af < bf:
+		return -1
+	case af > bf:
+		return 1
+	}
+	return 0
+}
+
+// compareObjects compares objects by their sorted key sets, then by values.
+func compareObjects(a, b toon.Value) int {
+	ak, _ := objectKeys(a)
+	bk, _ := objectKeys(b)
+	ak = sortedCopy(ak)
+	bk = sortedCopy(bk)
+
+	keysA := make([]toon.Value, len(ak))
+	for i, k := range ak {
+		keysA[i] = k
+	}
+	keysB := make([]toon.Value, len(bk))
+	for i, k := range bk {
+		keysB[i] = k
+	}
+	if c := compare(keysA, keysB); c != 0 {
+		return c
+	}
+
+	for _, k := range ak {
+		av, _ := objectGet(a, k)
+		bv, _ := objectGet(b, k)
+		if c := compare(av, bv); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// sortedCopy returns a sorted copy of keys.
+func sortedCopy(keys []string) []string {
+	c := append([]string(nil), keys...)
+	sort.Strings(c)
+	return c
+}
+
+// sign returns -1, 0 or +1 according to the sign of n.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// typeName names the type of v for error messages.
+func typeName(v toon.Value) string {
+	switch typeRank(v) {
+	case 0:
+		return "null"
+	case 1, 2:
+		return "boolean"
+	case 3:
+		return "number"
+	case 4:
+		return "string"
+	case 5:
+		return "array"
+	}
+	if _, ok := objectKeys(v); ok {
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,163 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind identifies the kind of a lexical token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenDot
+	tokenField  // .name or ."name"
+	tokenIdent  // name
+	tokenNumber // 42, 1.5
+	tokenString // "text"
+	tokenPunct  // | , ; : ( ) [ ] { }
+	tokenOp     // == != < <= > >=
+)
+
+// token is a single lexical token.
+type token struct {
+	kind tokenKind
+	text string // identifier, field name, decoded string, number or operator
+	pos  int    // byte offset in the source
+}
+
+// String describes the token for error messages.
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenField:
+		return "." + t.text
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return t.text
+	}
+}
+
+// lex splits src into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+
+	for i < len(src) {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+
+		case ch == '.':
+			start := i
+			i++
+			switch {
+			case i < len(src) && isIdentStart(src[i]):
+				end := scanIdent(src, i)
+				tokens = append(tokens, token{kind: tokenField, text: src[i:end], pos: start})
+				i = end
+			case i < len(src) && src[i] == '"':
+				s, end, err := scanString(src, i)
+				if err != nil {
+					return nil, err
+				}
+				tokens = append(tokens, token{kind: tokenField, text: s, pos: start})
+				i = end
+			default:
+				tokens = append(tokens, token{kind: tokenDot, text: ".", pos: start})
+			}
+
+		case ch == '"':
+			s, end, err := scanString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s, pos: i})
+			i = end
+
+		case isDigit(ch):
+			end := scanNumber(src, i)
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:end], pos: i})
+			i = end
+
+		case isIdentStart(ch):
+			end := scanIdent(src, i)
+			tokens = append(tokens, token{kind: tokenIdent, text: src[i:end], pos: i})
+			i = end
+
+		case strings.IndexByte("|,;:()[]{}", ch) >= 0:
+			tokens = append(tokens, token{kind: tokenPunct, text: string(ch), pos: i})
+			i++
+
+		case strings.IndexByte("=!<>", ch) >= 0:
+			op := string(ch)
+			if i+1 < len(src) && src[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, &SyntaxError{Message: fmt.Sprintf("unexpected %q", op), Offset: i}
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+
+		case ch == '-' && i+1 < len(src) && isDigit(src[i+1]):
+			end := scanNumber(src, i+1)
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:end], pos: i})
+			i = end
+
+		default:
+			return nil, &SyntaxError{Message: fmt.Sprintf("unexpected character %q", ch), Offset: i}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// scanIdent returns the end offset of the identifier starting at i.
+func scanIdent(src string, i int) int {
+	for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i])) {
+		i++
+	}
+	return i
+}
+
+// scanNumber returns the end offset of the number starting at i.
+func scanNumber(src string, i int) int {
+	for i < len(src) && (isDigit(src[i]) || strings.IndexByte(".eE", src[i]) >= 0 ||
+		((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+		i++
+	}
+	return i
+}
+
+// scanString decodes the JSON-style string literal starting at i and
+// returns it with the offset after the closing quote.
+func scanString(src string, i int) (string, int, error) {
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '"':
+			s, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return "", 0, &SyntaxError{Message: "invalid string literal", Offset: i}
+			}
+			return s, j + 1, nil
+		}
+	}
+	return "", 0, &SyntaxError{Message: "unterminated string literal", Offset: i}
+}
+
+// isIdentStart reports whether ch can start an identifier.
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// isDigit reports whether ch is an ASCII digit.
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
@@ -0,0 +1,366 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// parser builds an expression tree from tokens.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses a complete query.
+func parse(src string) (expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	e, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+	return e, nil
+}
+
+// parsePipe parses: comma ('|' comma)*
+func (p *parser) parsePipe() (expr, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptPunct("|") {
+		right, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		left = &pipeExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseComma parses: or (',' or)*
+func (p *parser) parseComma() (expr, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptPunct(",") {
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		left = &commaExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseOr parses: and ('or' and)*
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptIdent("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicExpr{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: compare ('and' compare)*
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptIdent("and") {
+		right, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicExpr{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseCompare parses: postfix (op postfix)?
+func (p *parser) parseCompare() (expr, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == tokenOp {
+		p.pos++
+		right, err := p.parsePostfix()
+		if err != nil {
+			return nil, err
+		}
+		return &compareExpr{op: tok.text, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+// parsePostfix parses a term followed by field, index and iterator suffixes.
+func (p *parser) parsePostfix() (expr, error) {
+	e, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenField:
+			p.pos++
+			e = &indexExpr{base: e, index: &literalExpr{value: tok.text}}
+		case tok.kind == tokenPunct && tok.text == "[":
+			e, err = p.parseBracket(e)
+			if err != nil {
+				return nil, err
+			}
+		case tok.kind == tokenDot && p.peekAt(1).kind == tokenPunct && p.peekAt(1).text == "[":
+			p.pos++
+			e, err = p.parseBracket(e)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return e, nil
+		}
+	}
+}
+
+// parseBracket parses "[]" or "[expr]" applied to base.
+func (p *parser) parseBracket(base expr) (expr, error) {
+	p.pos++ // [
+	if p.acceptPunct("]") {
+		return &iterateExpr{base: base}, nil
+	}
+	index, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct("]"); err != nil {
+		return nil, err
+	}
+	return &indexExpr{base: base, index: index}, nil
+}
+
+// parseTerm parses a single term.
+func (p *parser) parseTerm() (expr, error) {
+	tok := p.peek()
+
+	switch tok.kind {
+	case tokenDot:
+		p.pos++
+		if next := p.peek(); next.kind == tokenPunct && next.text == "[" {
+			return p.parseBracket(identityExpr{})
+		}
+		return identityExpr{}, nil
+
+	case tokenField:
+		p.pos++
+		return &indexExpr{base: identityExpr{}, index: &literalExpr{value: tok.text}}, nil
+
+	case tokenNumber:
+		p.pos++
+		n, err := parseNumber(tok.text)
+		if err != nil {
+			return nil, &SyntaxError{Message: fmt.Sprintf("invalid number %q", tok.text), Offset: tok.pos}
+		}
+		return &literalExpr{value: n}, nil
+
+	case tokenString:
+		p.pos++
+		return &literalExpr{value: tok.text}, nil
+
+	case tokenIdent:
+		return p.parseIdent()
+
+	case tokenPunct:
+		switch tok.text {
+		case "(":
+			p.pos++
+			e, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expectPunct(")")
+		case "[":
+			p.pos++
+			if p.acceptPunct("]") {
+				return &arrayExpr{}, nil
+			}
+			e, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return &arrayExpr{body: e}, p.expectPunct("]")
+		case "{":
+			return p.parseObject()
+		}
+	}
+
+	return nil, p.unexpected(tok)
+}
+
+// parseIdent parses a literal keyword or a function call.
+func (p *parser) parseIdent() (expr, error) {
+	tok := p.next()
+	switch tok.text {
+	case "null":
+		return &literalExpr{value: nil}, nil
+	case "true":
+		return &literalExpr{value: true}, nil
+	case "false":
+		return &literalExpr{value: false}, nil
+	case "and", "or":
+		return nil, p.unexpected(tok)
+	}
+
+	var args []expr
+	if p.acceptPunct("(") {
+		for {
+			arg, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.acceptPunct(")") {
+				break
+			}
+			if err := p.expectPunct(";"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fn, ok := builtins[builtinKey{tok.text, len(args)}]
+	if !ok {
+		return nil, &SyntaxError{Message: fmt.Sprintf("unknown function %s/%d", tok.text, len(args)), Offset: tok.pos}
+	}
+	return &callExpr{name: tok.text, fn: fn, args: args}, nil
+}
+
+// parseObject parses an object construction such as {id, name: .n, "a b": 1}.
+func (p *parser) parseObject() (expr, error) {
+	p.pos++ // {
+	obj := &objectExpr{}
+	if p.acceptPunct("}") {
+		return obj, nil
+	}
+
+	for {
+		var entry objectEntry
+		tok := p.next()
+		switch {
+		case tok.kind == tokenIdent || tok.kind == tokenString:
+			entry.key = &literalExpr{value: tok.text}
+		case tok.kind == tokenPunct && tok.text == "(":
+			key, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			entry.key = key
+		default:
+			return nil, p.unexpected(tok)
+		}
+
+		if p.acceptPunct(":") {
+			value, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			entry.value = value
+		} else if lit, ok := entry.key.(*literalExpr); ok {
+			entry.value = &indexExpr{base: identityExpr{}, index: lit}
+		} else {
+			return nil, p.unexpected(p.peek())
+		}
+		obj.entries = append(obj.entries, entry)
+
+		if p.acceptPunct("}") {
+			return obj, nil
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// peek returns the current token.
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// peekAt returns the token n positions ahead, or the EOF token.
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+// next consumes and returns the current token.
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// acceptPunct consumes the punctuation s if it is next.
+func (p *parser) acceptPunct(s string) bool {
+	if tok := p.peek(); tok.kind == tokenPunct && tok.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// acceptIdent consumes the keyword s if it is next.
+func (p *parser) acceptIdent(s string) bool {
+	if tok := p.peek(); tok.kind == tokenIdent && tok.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expectPunct consumes the punctuation s or reports an error.
+func (p *parser) expectPunct(s string) error {
+	if !p.acceptPunct(s) {
+		tok := p.peek()
+		return &SyntaxError{Message: fmt.Sprintf("expected %q, found %s", s, tok), Offset: tok.pos}
+	}
+	return nil
+}
+
+// unexpected reports an unexpected token.
+func (p *parser) unexpected(tok token) error {
+	return &SyntaxError{Message: fmt.Sprintf("unexpected %s", tok), Offset: tok.pos}
+}
+
+// parseNumber parses a numeric literal as int64 when possible, else float64.
+func parseNumber(s string) (toon.Value, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,120 @@
+// Package query implements a small jq-style filter language over decoded
+// TOON values.
+//
+// A query is a pipeline of filters. Each filter receives one input value and
+// produces zero or more outputs:
+//
+//	.                  identity
+//	.name ."key"       object field (null for missing fields or null input)
+//	.[i] .[-1]         array element
+//	.[]                every array element or object value
+//	a | b              feed every output of a into b
+//	a, b               outputs of a followed by outputs of b
+//	[a]                collect the outputs of a into an array
+//	{id, name: .n}     object construction; field order is kept
+//	== != < <= > >=    comparisons using jq ordering
+//	and or not         boolean logic; only false and null are falsy
+//
+// Builtins: select(f), map(f), sort, sort_by(f), limit(n; f), first, last,
+// first(f), length, keys, has(k), reverse, empty.
+//
+// Objects built with {...} are *toon.OrderedMap values, so collecting them
+// into an array and passing it to toon.Marshal produces a tabular array with
+// the columns in the order they were selected.
+//
+// Example:
+//
+//	q, err := query.Compile(`[.users[] | select(.active) | {id, name}] | sort_by(.name)`)
+//	out, err := q.Run(data)
+//	s, err := toon.MarshalToString(map[string]interface{}{"users": out[0]})
+//	// users[2]{id,name}:
+//	//   1,Ada
+//	//   3,Cy
+package query
+
+import (
+	"fmt"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+// Query is a compiled query. It is safe for concurrent use.
+type Query struct {
+	src  string
+	root expr
+}
+
+// SyntaxError represents an error in the query text.
+type SyntaxError struct {
+	Message string
+	Offset  int // byte offset in the query
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("query syntax error at offset %d: %s", e.Offset, e.Message)
+}
+
+// Error represents an error that occurred while evaluating a query,
+// such as indexing a string or iterating over a number.
+type Error struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return "query error: " + e.Message
+}
+
+// Compile parses a query.
+func Compile(src string) (*Query, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{src: src, root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the query cannot be parsed.
+func MustCompile(src string) *Query {
+	q, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// String returns the source text of the query.
+func (q *Query) String() string {
+	return q.src
+}
+
+// Run evaluates the query against v and returns all outputs.
+//
+// v may contain map[string]interface{}, map[string]toon.Value, OrderedMap,
+// slices and primitives as produced by toon.Unmarshal or built by hand,
+// or be a *toon.Node.
+func (q *Query) Run(v toon.Value) ([]toon.Value, error) {
+	if n, ok := v.(*toon.Node); ok {
+		v = n.Value()
+	}
+	out, err := q.root.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		out = []toon.Value{}
+	}
+	return out, nil
+}
+
+// Run compiles src and evaluates it against v.
+//
+// This is a convenience function that wraps Compile and Query.Run.
+func Run(src string, v toon.Value) ([]toon.Value, error) {
+	q, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	return q.Run(v)
+}
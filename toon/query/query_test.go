@@ -0,0 +1,176 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sstraus/toon_go/toon"
+)
+
+const testDoc = `users[4]{id,name,age,active}:
+  1,Ada,36,true
+  2,Bob,25,false
+  3,Cy,41,true
+  4,Dee,29,true
+meta:
+  version: 2
+  tags[3]: x,y,z`
+
+func decodeTestDoc(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var data map[string]interface{}
+	if err := toon.UnmarshalFromString(testDoc, &data); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return data
+}
+
+func TestRun(t *testing.T) {
+	data := decodeTestDoc(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{".meta.version", "2"},
+		{".meta.tags[1]", "y"},
+		{".meta.tags[-1]", "z"},
+		{".meta.missing", "null"},
+		{`.meta."version"`, "2"},
+		{`.meta["tags"] | length`, "3"},
+		{".users | length", "4"},
+		{".users[] | select(.age > 30) | .name", "[2]: Ada,Cy"},
+		{".users[] | select(.active and .age < 30) | .id", "4"},
+		{".users[] | select(.active | not) | .name", "Bob"},
+		{".users[] | select(.name == \"Bob\" or .id == 3) | .id", "[2]: 2,3"},
+		{"[.users[] | .age] | sort | first", "25"},
+		{".users | sort_by(.age) | last | .name", "Cy"},
+		{".users | map(.id) | reverse", "[4]: 4,3,2,1"},
+		{"limit(2; .users[] | .name)", "[2]: Ada,Bob"},
+		{"first(.users[] | select(.age < 30)) | .name", "Bob"},
+		{".meta | keys", "[2]: tags,version"},
+		{".meta | has(\"tags\"), has(\"nope\")", "[2]: true,false"},
+		{".meta.tags | has(2)", "true"},
+		{"[.meta.tags[], .meta.version]", "[4]: x,y,z,2"},
+		{".users[] | select(.id == 1) | {name, years: .age}", "name: Ada\nyears: 36"},
+		{"{(.meta.tags[0]): 1, \"a b\": null}", "x: 1\n\"a b\": null"},
+		{"[.users[] | empty]", "[0]:"},
+		{".users[0].name | length", "3"},
+		{"null | length", "0"},
+		{"-3 | length", "3"},
+		{"1.5 >= 1", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			out, err := Run(tt.query, data)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			var v interface{} = out
+			if len(out) == 1 {
+				v = out[0]
+			}
+			got, err := toon.MarshalToString(v)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Run(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunKeepsTabularOutput(t *testing.T) {
+	data := decodeTestDoc(t)
+	q := MustCompile(`[.users[] | select(.active) | {id, name}] | sort_by(.name) | reverse`)
+
+	out, err := q.Run(data)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	got, err := toon.MarshalToString(map[string]interface{}{"users": out[0]})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := "users[3]{id,name}:\n  4,Dee\n  3,Cy\n  1,Ada"
+	if got != want {
+		t.Errorf("Marshal(result) = %q, want %q", got, want)
+	}
+}
+
+func TestRunOrderedInput(t *testing.T) {
+	om := toon.NewOrderedMap()
+	om.Set("b", 1)
+	om.Set("a", []interface{}{int64(2), 3.5})
+
+	out, err := Run("[.[]] , (.a | map(. > 2))", om)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []toon.Value{
+		[]toon.Value{1, []interface{}{int64(2), 3.5}},
+		[]toon.Value{false, true},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Run() = %#v, want %#v", out, want)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	data := decodeTestDoc(t)
+
+	for _, src := range []string{
+		".meta.version.x",
+		".meta.version[]",
+		".meta.tags.x",
+		".meta | map(.)",
+		"true | length",
+		"limit(\"a\"; .)",
+	} {
+		_, err := Run(src, data)
+		var qerr *Error
+		if !errors.As(err, &qerr) {
+			t.Errorf("Run(%q) error = %v, want *Error", src, err)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		offset int
+	}{
+		{".a |", 4},
+		{".a = 1", 3},
+		{"select(.a", 9},
+		{"nope(1)", 0},
+		{"{a b}", 3},
+		{"\"abc", 0},
+		{".a ]", 3},
+		{"@", 0},
+	}
+
+	for _, tt := range tests {
+		_, err := Compile(tt.src)
+		var serr *SyntaxError
+		if !errors.As(err, &serr) {
+			t.Errorf("Compile(%q) error = %v, want *SyntaxError", tt.src, err)
+			continue
+		}
+		if serr.Offset != tt.offset {
+			t.Errorf("Compile(%q) offset = %d, want %d (%v)", tt.src, serr.Offset, tt.offset, err)
+		}
+	}
+}
+
+func TestMustCompilePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile() did not panic")
+		}
+	}()
+	MustCompile("[")
+}
@@ -0,0 +1,243 @@
+package toon
+
+import (
+	"reflect"
+	"testing"
+)
+
+const rawTestInput = `name: Ada
+users[2]{id,name}:
+  1,Ada
+  2,Bob
+config:
+  debug: true
+  limits:
+    max: 10
+"odd key": "a: b"
+tags[2]: x,y`
+
+func TestUnmarshalRawFields(t *testing.T) {
+	var fields map[string]RawValue
+	if err := UnmarshalFromString(rawTestInput, &fields); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := map[string]RawValue{
+		"name":    RawValue("Ada"),
+		"users":   RawValue("[2]{id,name}:\n  1,Ada\n  2,Bob"),
+		"config":  RawValue("debug: true\nlimits:\n  max: 10"),
+		"odd key": RawValue(`"a: b"`),
+		"tags":    RawValue("[2]: x,y"),
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("Unmarshal() = %q, want %q", fields, want)
+	}
+
+	// Each field decodes on its own
+	var users []interface{}
+	if err := UnmarshalFromString(string(fields["users"]), &users); err != nil {
+		t.Fatalf("Unmarshal(users) error = %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("users = %#v, want 2 rows", users)
+	}
+	var config map[string]interface{}
+	if err := UnmarshalFromString(string(fields["config"]), &config); err != nil {
+		t.Fatalf("Unmarshal(config) error = %v", err)
+	}
+	if got, _ := GetInt64(config, "limits.max"); got != 10 {
+		t.Errorf("config.limits.max = %d, want 10", got)
+	}
+}
+
+func TestUnmarshalRawValue(t *testing.T) {
+	var raw RawValue
+	if err := UnmarshalFromString("a:\n  b: [", &raw); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if string(raw) != "a:\n  b: [" {
+		t.Errorf("Unmarshal() = %q", raw)
+	}
+}
+
+func TestUnmarshalRawValueStrict(t *testing.T) {
+	for _, input := range []string{
+		"a:\n   b: 1",
+		"a:\n\tb: 1",
+		"a: 1\nplain\nc: 2",
+	} {
+		var raw RawValue
+		if err := UnmarshalFromString(input, &raw); err == nil {
+			t.Errorf("Unmarshal(%q) expected error, got %q", input, raw)
+		}
+		if err := UnmarshalFromString(input, &raw, WithStrictDecoding(false)); err != nil {
+			t.Errorf("Unmarshal(%q, non-strict) error = %v", input, err)
+		}
+		if string(raw) != input {
+			t.Errorf("Unmarshal(%q, non-strict) = %q", input, raw)
+		}
+	}
+}
+
+func TestUnmarshalRawStruct(t *testing.T) {
+	type config struct {
+		Debug  bool     `json:"debug"`
+		Limits RawValue `json:"limits"`
+	}
+	type base struct {
+		Name string
+	}
+	var doc struct {
+		base
+		Users  RawValue `json:"users"`
+		Config *config  `json:"config"`
+		Tags   []string `json:"tags"`
+		Odd    string   `json:"odd key"`
+		Skip   RawValue `json:"-"`
+	}
+	if err := UnmarshalFromString(rawTestInput, &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if doc.Name != "Ada" {
+		t.Errorf("Name = %q, want Ada", doc.Name)
+	}
+	if string(doc.Users) != "[2]{id,name}:\n  1,Ada\n  2,Bob" {
+		t.Errorf("Users = %q", doc.Users)
+	}
+	if doc.Config == nil || !doc.Config.Debug || string(doc.Config.Limits) != "max: 10" {
+		t.Errorf("Config = %+v", doc.Config)
+	}
+	if !reflect.DeepEqual(doc.Tags, []string{"x", "y"}) {
+		t.Errorf("Tags = %q", doc.Tags)
+	}
+	if doc.Odd != "a: b" {
+		t.Errorf("Odd = %q, want %q", doc.Odd, "a: b")
+	}
+	if doc.Skip != nil {
+		t.Errorf("Skip = %q, want nil", doc.Skip)
+	}
+
+	var bad struct {
+		Name int `json:"name"`
+	}
+	if err := UnmarshalFromString(rawTestInput, &bad); err == nil {
+		t.Errorf("Unmarshal() into int field expected error")
+	}
+}
+
+func TestUnmarshalRawExpandPaths(t *testing.T) {
+	// Structs without RawValue fields take the regular decoder
+	var plain struct {
+		A struct {
+			B int `json:"b"`
+		} `json:"a"`
+	}
+	if err := UnmarshalFromString("a.b: 1", &plain, WithExpandPaths("safe")); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if plain.A.B != 1 {
+		t.Errorf("A.B = %d, want 1", plain.A.B)
+	}
+
+	var raw struct {
+		A RawValue `json:"a"`
+	}
+	if err := UnmarshalFromString("a.b: 1", &raw, WithExpandPaths("safe")); err == nil {
+		t.Errorf("Unmarshal() into RawValue field with path expansion expected error")
+	}
+	var fields map[string]RawValue
+	if err := UnmarshalFromString("a.b: 1", &fields, WithExpandPaths("safe")); err == nil {
+		t.Errorf("Unmarshal() into map[string]RawValue with path expansion expected error")
+	}
+}
+
+func TestUnmarshalRawFieldsErrors(t *testing.T) {
+	for _, input := range []string{
+		"[2]: a,b",
+		"plain",
+		"  a: 1",
+		"a: 1\nb\nc: 2",
+		"a: 1\n  b: 2",
+		`"a: 1`,
+	} {
+		var fields map[string]RawValue
+		if err := UnmarshalFromString(input, &fields); err == nil {
+			t.Errorf("Unmarshal(%q) expected error, got %q", input, fields)
+		}
+	}
+}
+
+func TestMarshalRawValue(t *testing.T) {
+	var fields map[string]RawValue
+	if err := UnmarshalFromString(rawTestInput, &fields); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "root",
+			value: fields["config"],
+			want:  "debug: true\nlimits:\n  max: 10",
+		},
+		{
+			name: "nested fields",
+			value: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"users":  fields["users"],
+					"config": fields["config"],
+					"name":   fields["name"],
+					"empty":  RawValue(""),
+				},
+			},
+			want: "outer:\n  config:\n    debug: true\n    limits:\n      max: 10\n  empty:\n  name: Ada\n  users[2]{id,name}:\n    1,Ada\n    2,Bob",
+		},
+		{
+			name:  "list items",
+			value: map[string]interface{}{"items": []interface{}{fields["config"], fields["tags"], fields["odd key"], int64(1)}},
+			want:  "items[4]:\n  - debug: true\n    limits:\n      max: 10\n  - [2]: x,y\n  - \"a: b\"\n  - 1",
+		},
+		{
+			name:  "list item array",
+			value: map[string]interface{}{"items": []interface{}{fields["users"], 5}},
+			want:  "items[2]:\n  - [2]{id,name}:\n    1,Ada\n    2,Bob\n  - 5",
+		},
+		{
+			name: "list item fields",
+			value: map[string]interface{}{"items": []interface{}{
+				func() *OrderedMap {
+					om := NewOrderedMap()
+					om.Set("config", fields["config"])
+					om.Set("users", fields["users"])
+					return om
+				}(),
+			}},
+			want: "items[1]:\n  - config:\n      debug: true\n      limits:\n        max: 10\n    users[2]{id,name}:\n      1,Ada\n      2,Bob",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalToString(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Marshal() =\n%s\nwant\n%s", got, tt.want)
+			}
+
+			// The output must be valid TOON
+			if _, err := ParseTreeFromString(got); err != nil {
+				t.Errorf("ParseTree(output) error = %v", err)
+			}
+			var v interface{}
+			if err := UnmarshalFromString(got, &v); err != nil {
+				t.Errorf("Unmarshal(output) error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package toon
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// EncodeRows writes the result set of a query to w as a tabular array under
+// key, with the header taken from rows.Columns(). An empty key writes a root
+// array.
+//
+// Each row is encoded as a tabular line when it is scanned, so the values of
+// the result set are never held in memory. The encoded lines are buffered
+// until the last row because the header carries the row count; with
+// DelimiterAuto the row values are buffered instead.
+//
+// Column values become TOON primitives: sql.Null* and other driver.Valuer
+// types by their driver value, []byte as a string (base64 when it is not
+// valid UTF-8), time.Time in RFC 3339 format with nanoseconds, and SQL NULL
+// as null. Float formats are rejected; round in the query instead.
+//
+// rows is not closed. An error from Scan or from converting a value stops
+// reading at that row, so the caller should always close rows, as the
+// example does.
+//
+// Example:
+//
+//	rows, err := db.Query("SELECT id, name, created_at FROM users")
+//	defer rows.Close()
+//	err = toon.EncodeRows(os.Stdout, "users", rows)
+//	// users[2]{id,name,created_at}:
+//	//   1,Ada,"2025-01-01T09:00:00Z"
+//	//   2,Bob,"2025-01-02T09:00:00Z"
+func EncodeRows(w io.Writer, key string, rows *sql.Rows, opts ...EncodeOption) error {
+	encOpts := applyEncodeOptions(opts...)
+	if err := validateEncodeOptions(encOpts); err != nil {
+		return err
+	}
+	resolveAutoDelimiter(encOpts)
+	if formatsFloats(encOpts) {
+		return &EncodeError{Message: "float formatting is not supported by EncodeRows"}
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		key = encodeKey(key)
+	}
+
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	lines := newWriter(encOpts.Indent)
+	cells := make([]string, len(columns))
+	var kept []Value // cells before encoding, for DelimiterAuto
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			val, err := sqlValue(v)
+			if err != nil {
+				return &EncodeError{Message: fmt.Sprintf("column %q: %v", columns[i], err), Value: v, Cause: err}
+			}
+			if encOpts.autoDelimiter {
+				kept = append(kept, val)
+				continue
+			}
+			if cells[i], err = encodePrimitive(val, encOpts.Delimiter); err != nil {
+				return err
+			}
+		}
+		if !encOpts.autoDelimiter {
+			lines.push(strings.Join(cells, encOpts.Delimiter), 1)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// With DelimiterAuto the rows are encoded once all cells are known
+	if encOpts.autoDelimiter && count > 0 {
+		if encOpts, err = withArrayDelimiter(encOpts, kept); err != nil {
+			return err
+		}
+		for i, val := range kept {
+			if cells[i%len(columns)], err = encodePrimitive(val, encOpts.Delimiter); err != nil {
+				return err
+			}
+			if (i+1)%len(columns) == 0 {
+				lines.push(strings.Join(cells, encOpts.Delimiter), 1)
+			}
+		}
+	}
+
+	out := newWriter(encOpts.Indent)
+	if count == 0 {
+		if err := encodeEmptyArray(out, key, 0, encOpts); err != nil {
+			return err
+		}
+	} else {
+		out.push(formatArrayHeader(key, count, columns, encOpts), 0)
+		out.pushRaw(newline + lines.String())
+	}
+	_, err = io.WriteString(w, out.String())
+	return err
+}
+
+// sqlValue converts a value scanned from a database into a TOON primitive.
+func sqlValue(v interface{}) (Value, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		if _, again := dv.(driver.Valuer); again {
+			return nil, fmt.Errorf("driver value %T is a driver.Valuer", dv)
+		}
+		return sqlValue(dv)
+	}
+
+	switch val := v.(type) {
+	case nil, bool, string, int64, float64:
+		return val, nil
+	case []byte:
+		if utf8.Valid(val) {
+			return string(val), nil
+		}
+		return base64.StdEncoding.EncodeToString(val), nil
+	case time.Time:
+		return val.Format(time.RFC3339Nano), nil
+	}
+
+	// Other numeric, boolean and string kinds, e.g. int32 or named types
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", v)
+}
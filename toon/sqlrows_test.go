@@ -0,0 +1,224 @@
+package toon
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTables holds the result sets served by the fake driver, by query.
+var fakeTables = map[string]fakeTable{}
+
+type fakeTable struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error // returned after the last row
+}
+
+type fakeDriver struct{}
+
+type fakeConn struct{}
+
+type fakeStmt struct{ query string }
+
+type fakeRows struct {
+	table fakeTable
+	next  int
+}
+
+func init() {
+	sql.Register("toonfake", fakeDriver{})
+}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return 0 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	table, ok := fakeTables[s.query]
+	if !ok {
+		return nil, errors.New("unknown query " + s.query)
+	}
+	return &fakeRows{table: table}, nil
+}
+
+func (r *fakeRows) Columns() []string { return r.table.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next == len(r.table.rows) {
+		if r.table.err != nil {
+			return r.table.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.table.rows[r.next])
+	r.next++
+	return nil
+}
+
+// cents is a driver.Valuer stored as an integer number of cents.
+type cents int64
+
+func (c cents) Value() (driver.Value, error) { return float64(c) / 100, nil }
+
+// status is a named string type a driver may return as is.
+type status string
+
+func queryFake(t *testing.T, table fakeTable) *sql.Rows {
+	t.Helper()
+	query := t.Name()
+	fakeTables[query] = table
+	t.Cleanup(func() { delete(fakeTables, query) })
+
+	db, err := sql.Open("toonfake", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	t.Cleanup(func() { rows.Close() })
+	return rows
+}
+
+func TestEncodeRows(t *testing.T) {
+	created := time.Date(2025, 1, 2, 9, 30, 0, 500, time.UTC)
+
+	tests := []struct {
+		name  string
+		key   string
+		table fakeTable
+		opts  []EncodeOption
+		want  string
+	}{
+		{
+			name: "driver types",
+			key:  "users",
+			table: fakeTable{
+				columns: []string{"id", "name", "score", "active", "created_at", "avatar"},
+				rows: [][]driver.Value{
+					{int64(1), "Ada", 9.5, true, created, []byte("a.png")},
+					{int64(2), "Bob, Jr.", nil, false, nil, []byte{0xff, 0x00}},
+				},
+			},
+			want: "users[2]{id,name,score,active,created_at,avatar}:\n" +
+				"  1,Ada,9.5,true,\"2025-01-02T09:30:00.0000005Z\",a.png\n" +
+				"  2,\"Bob, Jr.\",null,false,null,/wA=",
+		},
+		{
+			name: "valuers",
+			key:  "items",
+			table: fakeTable{
+				columns: []string{"sku", "price", "qty", "status", "n"},
+				rows: [][]driver.Value{
+					{sql.NullString{String: "A-1", Valid: true}, cents(1999), sql.NullInt64{Int64: 3, Valid: true}, status("new"), int32(7)},
+					{sql.NullString{}, cents(5), sql.NullInt64{}, status("true"), uint8(8)},
+				},
+			},
+			want: "items[2]{sku,price,qty,status,n}:\n  A-1,19.99,3,new,7\n  null,0.05,null,\"true\",8",
+		},
+		{
+			name: "options and quoted names",
+			key:  "order items",
+			table: fakeTable{
+				columns: []string{"id", "unit price"},
+				rows:    [][]driver.Value{{int64(1), "a|b"}},
+			},
+			opts: []EncodeOption{WithDelimiter(pipe), WithLengthMarker("#"), WithIndent(4)},
+			want: "\"order items\"[#1|]{id|\"unit price\"}:\n    1|\"a|b\"",
+		},
+		{
+			name: "automatic delimiter",
+			key:  "people",
+			table: fakeTable{
+				columns: []string{"id", "name"},
+				rows:    [][]driver.Value{{int64(1), "Bob, Jr."}, {int64(2), "Ann"}},
+			},
+			opts: []EncodeOption{WithDelimiter(DelimiterAuto)},
+			want: "people[2|]{id|name}:\n  1|Bob, Jr.\n  2|Ann",
+		},
+		{
+			name: "root array",
+			table: fakeTable{
+				columns: []string{"n"},
+				rows:    [][]driver.Value{{int64(1)}, {int64(2)}},
+			},
+			want: "[2]{n}:\n  1\n  2",
+		},
+		{
+			name:  "no rows",
+			key:   "users",
+			table: fakeTable{columns: []string{"id"}},
+			want:  "users[0]:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := queryFake(t, tt.table)
+			var out strings.Builder
+			if err := EncodeRows(&out, tt.key, rows, tt.opts...); err != nil {
+				t.Fatalf("EncodeRows() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("EncodeRows() =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+
+			// The output is valid TOON
+			var decoded interface{}
+			if err := UnmarshalFromString(out.String(), &decoded); err != nil {
+				t.Errorf("Unmarshal() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestEncodeRowsErrors(t *testing.T) {
+	t.Run("rows error", func(t *testing.T) {
+		failure := errors.New("connection reset")
+		rows := queryFake(t, fakeTable{
+			columns: []string{"id"},
+			rows:    [][]driver.Value{{int64(1)}},
+			err:     failure,
+		})
+		var out strings.Builder
+		if err := EncodeRows(&out, "rows", rows); !errors.Is(err, failure) {
+			t.Errorf("EncodeRows() error = %v, want %v", err, failure)
+		}
+		if out.Len() != 0 {
+			t.Errorf("EncodeRows() wrote %q after an error", out.String())
+		}
+	})
+
+	t.Run("unsupported value", func(t *testing.T) {
+		rows := queryFake(t, fakeTable{
+			columns: []string{"v"},
+			rows:    [][]driver.Value{{struct{ A int }{1}}},
+		})
+		var encErr *EncodeError
+		if err := EncodeRows(&strings.Builder{}, "rows", rows); !errors.As(err, &encErr) {
+			t.Errorf("EncodeRows() error = %v, want EncodeError", err)
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		rows := queryFake(t, fakeTable{columns: []string{"v"}})
+		if err := EncodeRows(&strings.Builder{}, "rows", rows, WithDelimiter(";")); err == nil {
+			t.Error("EncodeRows() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,625 @@
+package toon
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchema is a JSON Schema document, passed to Template.
+type JSONSchema []byte
+
+// Template returns a TOON skeleton of the documents described by t, for
+// showing a model the structure it should answer with. t is a
+// reflect.Type, a JSONSchema, or a value whose type is used, such as
+// User{} or []User(nil).
+//
+// Arrays get an [N] header, one placeholder row or item and a "..." line.
+// Primitives are written as type hints such as <integer>, <string>,
+// <date-time> or <string or null>. Arrays of flat objects are tabular,
+// as Marshal writes them.
+//
+// Struct fields are named as encoding/json names them, in declaration
+// order; JSON Schema properties keep their order. Schemas may use local
+// "$ref" references, "anyOf", "oneOf", "allOf", "enum" and string
+// "format"; recursive types and references end in a hint naming them.
+// Options set the indent, delimiter and length marker.
+//
+// Example:
+//
+//	type User struct {
+//		ID    int    `json:"id"`
+//		Name  string `json:"name"`
+//		Email string `json:"email"`
+//	}
+//	tmpl, err := toon.Template(struct {
+//		Users []User `json:"users"`
+//	}{})
+//	// users[N]{id,name,email}:
+//	//   <integer>,<string>,<string>
+//	//   ...
+func Template(t interface{}, opts ...EncodeOption) (string, error) {
+	encOpts := applyEncodeOptions(opts...)
+	if err := validateEncodeOptions(encOpts); err != nil {
+		return "", err
+	}
+	resolveAutoDelimiter(encOpts)
+
+	var root *templateNode
+	var err error
+	switch src := t.(type) {
+	case nil:
+		return "", &EncodeError{Message: "template needs a type or a JSON schema"}
+	case JSONSchema:
+		root, err = schemaTemplate(src)
+	case reflect.Type:
+		root, err = (&typeTemplates{active: map[reflect.Type]bool{}}).node(src)
+	default:
+		root, err = (&typeTemplates{active: map[reflect.Type]bool{}}).node(reflect.TypeOf(t))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tw := &templateWriter{w: newWriter(encOpts.Indent), opts: encOpts}
+	tw.value("", root, 0)
+	return tw.w.String(), nil
+}
+
+// templateKind is the kind of a templateNode.
+type templateKind int
+
+const (
+	templatePrimitive templateKind = iota
+	templateObject
+	templateArray
+)
+
+// templateNode is the shape of a value in a template.
+type templateNode struct {
+	kind     templateKind
+	hint     string          // primitive type hint, e.g. "integer"
+	nullable bool            // primitive may be null
+	fields   []templateField // object fields
+	elem     *templateNode   // array elements
+}
+
+// templateField is an object field of a template.
+type templateField struct {
+	key         string
+	placeholder bool // key is a hint for the keys of a map
+	node        *templateNode
+}
+
+// hintNode returns a primitive node with the given hint.
+func hintNode(hint string) *templateNode {
+	return &templateNode{kind: templatePrimitive, hint: hint}
+}
+
+// mapNode returns an object node whose keys are arbitrary.
+func mapNode(elem *templateNode) *templateNode {
+	return &templateNode{kind: templateObject, fields: []templateField{{key: "<key>", placeholder: true, node: elem}}}
+}
+
+// placeholder is the text written for a primitive node.
+func (n *templateNode) placeholder() string {
+	if n.nullable && n.hint != "null" && n.hint != "any" {
+		return "<" + n.hint + " or null>"
+	}
+	return "<" + n.hint + ">"
+}
+
+// tabular reports whether an array of n is written in tabular format.
+func (n *templateNode) tabular() bool {
+	if n.kind != templateObject || len(n.fields) == 0 {
+		return false
+	}
+	for _, f := range n.fields {
+		if f.placeholder || f.node.kind != templatePrimitive {
+			return false
+		}
+	}
+	return true
+}
+
+// encodedKey returns the field key as written in the template.
+func (f templateField) encodedKey() string {
+	if f.placeholder {
+		return f.key
+	}
+	return encodeKey(f.key)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	numberType        = reflect.TypeOf(json.Number(""))
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeTemplates builds template nodes from Go types.
+type typeTemplates struct {
+	active map[reflect.Type]bool // structs being built, to stop recursion
+}
+
+// node returns the template of values of type t.
+func (b *typeTemplates) node(t reflect.Type) (*templateNode, error) {
+	switch t {
+	case timeType:
+		return hintNode("date-time"), nil
+	case numberType:
+		return hintNode("number"), nil
+	case rawMessageType, rawValueType:
+		return hintNode("any"), nil
+	}
+	if t.Kind() != reflect.Interface && t.Kind() != reflect.Pointer {
+		if implements(t, jsonMarshalerType) {
+			return hintNode("any"), nil
+		}
+		if implements(t, textMarshalerType) {
+			return hintNode("string"), nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		n, err := b.node(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		n.nullable = true
+		return n, nil
+	case reflect.Bool:
+		return hintNode("boolean"), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return hintNode("integer"), nil
+	case reflect.Float32, reflect.Float64:
+		return hintNode("number"), nil
+	case reflect.String:
+		return hintNode("string"), nil
+	case reflect.Interface:
+		return hintNode("any"), nil
+	case reflect.Slice, reflect.Array:
+		// Byte slices are base64 strings in JSON
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return hintNode("string"), nil
+		}
+		elem, err := b.node(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &templateNode{kind: templateArray, elem: elem}, nil
+	case reflect.Map:
+		elem, err := b.node(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return mapNode(elem), nil
+	case reflect.Struct:
+		if b.active[t] {
+			return hintNode(t.Name()), nil
+		}
+		b.active[t] = true
+		defer delete(b.active, t)
+
+		n := &templateNode{kind: templateObject}
+		if err := b.structFields(t, n); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	return nil, &EncodeError{Message: fmt.Sprintf("unsupported template type %s", t)}
+}
+
+// structFields appends the JSON fields of struct type t to n, including
+// the fields of untagged embedded structs.
+func (b *typeTemplates) structFields(t reflect.Type, n *templateNode) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := b.structFields(ft, n); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		field, err := b.node(ft)
+		if err != nil {
+			return err
+		}
+		if hasStringOption(options, ft) {
+			field.hint = "string"
+		}
+		n.fields = append(n.fields, templateField{key: name, node: field})
+	}
+	return nil
+}
+
+// hasStringOption reports whether the json tag options make encoding/json
+// write a field of type t as a string: the ",string" option applies to
+// booleans, numbers and strings, and pointers to them.
+func hasStringOption(options string, t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+	default:
+		return false
+	}
+	for options != "" {
+		var opt string
+		opt, options, _ = strings.Cut(options, ",")
+		if opt == "string" {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaTemplates builds template nodes from a JSON Schema.
+type schemaTemplates struct {
+	root   Value
+	active map[string]bool // references being built, to stop recursion
+}
+
+// schemaTemplate returns the template of the documents valid for schema.
+func schemaTemplate(schema JSONSchema) (*templateNode, error) {
+	var doc interface{}
+	if bytes.HasPrefix(bytes.TrimSpace(schema), []byte("{")) {
+		obj := NewOrderedMap()
+		if err := json.Unmarshal(schema, obj); err != nil {
+			return nil, &EncodeError{Message: "invalid JSON schema", Cause: err}
+		}
+		doc = obj
+	} else if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, &EncodeError{Message: "invalid JSON schema", Cause: err}
+	}
+
+	b := &schemaTemplates{root: normalize(doc), active: map[string]bool{}}
+	return b.node(b.root)
+}
+
+// node returns the template of the values valid for schema s.
+func (b *schemaTemplates) node(s Value) (*templateNode, error) {
+	if _, ok := s.(bool); ok {
+		return hintNode("any"), nil
+	}
+	if _, _, ok := objectFields(s); !ok {
+		return nil, &EncodeError{Message: "invalid JSON schema: schema must be an object or a boolean", Value: s}
+	}
+
+	if ref, ok := schemaKeyword(s, "$ref").(string); ok {
+		return b.ref(ref)
+	}
+	if branches, ok := schemaKeyword(s, "allOf").([]Value); ok {
+		return b.allOf(branches)
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if branches, ok := schemaKeyword(s, keyword).([]Value); ok {
+			return b.anyOf(branches)
+		}
+	}
+
+	// The type is the first non-null entry of "type"
+	typ, nullable := "", false
+	switch t := schemaKeyword(s, "type").(type) {
+	case string:
+		typ = t
+	case []Value:
+		for _, item := range t {
+			if name, _ := item.(string); name == "null" {
+				nullable = true
+			} else if typ == "" {
+				typ = name
+			}
+		}
+		if typ == "" && nullable {
+			typ = "null"
+		}
+	}
+	if typ == "" {
+		switch {
+		case schemaKeyword(s, "properties") != nil:
+			typ = "object"
+		case schemaKeyword(s, "items") != nil:
+			typ = "array"
+		}
+	}
+
+	var n *templateNode
+	switch typ {
+	case "object":
+		var err error
+		if n, err = b.object(s); err != nil {
+			return nil, err
+		}
+	case "array":
+		elem, err := b.items(s)
+		if err != nil {
+			return nil, err
+		}
+		n = &templateNode{kind: templateArray, elem: elem}
+	case "string", "integer", "number", "boolean", "null", "":
+		n = hintNode(typ)
+		if format, ok := schemaKeyword(s, "format").(string); ok && typ == "string" {
+			n.hint = format
+		}
+		if values, ok := schemaKeyword(s, "enum").([]Value); ok && len(values) > 0 {
+			hint, err := enumHint(values)
+			if err != nil {
+				return nil, err
+			}
+			n.hint = hint
+		} else if c := schemaKeyword(s, "const"); c != nil {
+			hint, err := enumHint([]Value{c})
+			if err != nil {
+				return nil, err
+			}
+			n.hint = hint
+		}
+		if n.hint == "" {
+			n.hint = "any"
+		}
+	default:
+		return nil, &EncodeError{Message: "unsupported schema type", Value: typ}
+	}
+	n.nullable = nullable
+	return n, nil
+}
+
+// object returns the template of an object schema: its properties, or
+// arbitrary keys when only additionalProperties is given.
+func (b *schemaTemplates) object(s Value) (*templateNode, error) {
+	keys, props, _ := objectFields(schemaKeyword(s, "properties"))
+	if len(keys) == 0 {
+		if extra := schemaKeyword(s, "additionalProperties"); extra != nil && extra != false {
+			elem, err := b.node(extra)
+			if err != nil {
+				return nil, err
+			}
+			return mapNode(elem), nil
+		}
+	}
+
+	n := &templateNode{kind: templateObject}
+	for i, k := range keys {
+		field, err := b.node(props[i])
+		if err != nil {
+			return nil, err
+		}
+		n.fields = append(n.fields, templateField{key: k, node: field})
+	}
+	return n, nil
+}
+
+// items returns the template of the elements of an array schema.
+func (b *schemaTemplates) items(s Value) (*templateNode, error) {
+	items := schemaKeyword(s, "items")
+	if _, tuple := items.([]Value); items == nil || tuple {
+		return hintNode("any"), nil
+	}
+	return b.node(items)
+}
+
+// ref returns the template of the schema at the local reference ref,
+// such as "#/$defs/User".
+func (b *schemaTemplates) ref(ref string) (*templateNode, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, &EncodeError{Message: "unsupported schema reference", Value: ref}
+	}
+	s := b.root
+	name := "any"
+	for _, part := range strings.Split(strings.TrimPrefix(ref[1:], "/"), "/") {
+		if part == "" {
+			continue
+		}
+		name = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		if s = schemaKeyword(s, name); s == nil {
+			return nil, &EncodeError{Message: "unresolved schema reference", Value: ref}
+		}
+	}
+
+	if b.active[ref] {
+		return hintNode(name), nil
+	}
+	b.active[ref] = true
+	defer delete(b.active, ref)
+	return b.node(s)
+}
+
+// allOf returns the template of a schema combining branches: the fields
+// of all object branches, or the only branch.
+func (b *schemaTemplates) allOf(branches []Value) (*templateNode, error) {
+	merged := &templateNode{kind: templateObject}
+	for _, branch := range branches {
+		n, err := b.node(branch)
+		if err != nil {
+			return nil, err
+		}
+		if len(branches) == 1 {
+			return n, nil
+		}
+		if n.kind != templateObject {
+			return hintNode("any"), nil
+		}
+		merged.fields = append(merged.fields, n.fields...)
+	}
+	return merged, nil
+}
+
+// anyOf returns the template of a schema matching one of branches: the
+// only non-null branch, or the hints of primitive branches joined by "or".
+func (b *schemaTemplates) anyOf(branches []Value) (*templateNode, error) {
+	var nodes []*templateNode
+	nullable := false
+	for _, branch := range branches {
+		n, err := b.node(branch)
+		if err != nil {
+			return nil, err
+		}
+		if n.kind == templatePrimitive && n.hint == "null" {
+			nullable = true
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+
+	switch {
+	case len(nodes) == 0:
+		return hintNode("null"), nil
+	case len(nodes) == 1:
+		nodes[0].nullable = nodes[0].nullable || nullable
+		return nodes[0], nil
+	}
+	hints := make([]string, len(nodes))
+	for i, n := range nodes {
+		if n.kind != templatePrimitive {
+			return hintNode("any"), nil
+		}
+		hints[i] = n.hint
+	}
+	n := hintNode(strings.Join(hints, " or "))
+	n.nullable = nullable
+	return n, nil
+}
+
+// schemaKeyword returns the value of keyword in schema s, or nil.
+func schemaKeyword(s Value, keyword string) Value {
+	keys, values, _ := objectFields(s)
+	for i, k := range keys {
+		if k == keyword {
+			return values[i]
+		}
+	}
+	return nil
+}
+
+// enumHint joins the allowed values of an enum as TOON primitives.
+func enumHint(values []Value) (string, error) {
+	encoded := make([]string, len(values))
+	for i, v := range values {
+		if !isPrimitive(v) {
+			return "any", nil
+		}
+		s, err := encodePrimitive(v, comma)
+		if err != nil {
+			return "", err
+		}
+		encoded[i] = s
+	}
+	return strings.Join(encoded, " or "), nil
+}
+
+// templateWriter writes template nodes in the layout of the encoder.
+type templateWriter struct {
+	w    *writer
+	opts *EncodeOptions
+}
+
+// header formats the header of a template array of unknown length.
+func (t *templateWriter) header(key string, fields []string) string {
+	return formatHeader(key, t.opts.LengthMarker+"N", fields, t.opts)
+}
+
+// value writes n under the encoded key at depth; key is "" at the root.
+func (t *templateWriter) value(key string, n *templateNode, depth int) {
+	switch n.kind {
+	case templatePrimitive:
+		if key == "" {
+			t.w.push(n.placeholder(), depth)
+		} else {
+			t.w.push(key+colon+space+n.placeholder(), depth)
+		}
+	case templateArray:
+		t.array(key, n.elem, depth)
+	case templateObject:
+		if key != "" {
+			t.w.push(key+colon, depth)
+			depth++
+		}
+		for _, f := range n.fields {
+			t.value(f.encodedKey(), f.node, depth)
+		}
+	}
+}
+
+// array writes an array of elem under key at depth: inline with one
+// placeholder, tabular with one placeholder row, or as a list with one
+// placeholder item, each followed by "...".
+func (t *templateWriter) array(key string, elem *templateNode, depth int) {
+	switch {
+	case elem.kind == templatePrimitive:
+		t.w.push(t.header(key, nil)+space+elem.placeholder()+t.opts.Delimiter+"...", depth)
+	case elem.tabular():
+		names := make([]string, len(elem.fields))
+		cells := make([]string, len(elem.fields))
+		for i, f := range elem.fields {
+			names[i], cells[i] = f.key, f.node.placeholder()
+		}
+		t.w.push(t.header(key, names), depth)
+		t.w.push(strings.Join(cells, t.opts.Delimiter), depth+1)
+		t.w.push("...", depth+1)
+	default:
+		t.w.push(t.header(key, nil), depth)
+		t.listItem(elem, depth+1)
+		t.w.push(listItemPrefix+"...", depth+1)
+	}
+}
+
+// listItem writes one list item of n at depth, with the first field of an
+// object on the hyphen line as encodeListItemMap does.
+func (t *templateWriter) listItem(n *templateNode, depth int) {
+	switch {
+	case n.kind == templatePrimitive:
+		t.w.push(listItemPrefix+n.placeholder(), depth)
+		return
+	case n.kind == templateArray:
+		t.array(listItemPrefix, n.elem, depth)
+		return
+	case len(n.fields) == 0:
+		t.w.push(listItemMarker, depth)
+		return
+	}
+
+	// Later fields sit one level below the hyphen, which lines them up
+	// with the first field at the default indent of 2
+	for i, f := range n.fields {
+		key := f.encodedKey()
+		switch {
+		case i > 0:
+			t.value(key, f.node, depth+1)
+		case f.node.kind == templateObject:
+			t.w.push(listItemPrefix+key+colon, depth)
+			t.value("", f.node, depth+2)
+		default:
+			t.value(listItemPrefix+key, f.node, depth)
+		}
+	}
+}
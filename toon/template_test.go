@@ -0,0 +1,277 @@
+package toon
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type templateAddress struct {
+	City string  `json:"city"`
+	Zip  *string `json:"zip,omitempty"`
+}
+
+type templateBase struct {
+	ID int `json:"id"`
+}
+
+type templateUser struct {
+	templateBase
+	Name    string          `json:"name"`
+	Tags    []string        `json:"tags"`
+	Address templateAddress `json:"address"`
+	Created time.Time       `json:"created"`
+	Secret  string          `json:"-"`
+	hidden  int
+}
+
+type templateTree struct {
+	Name     string
+	Children []templateTree
+}
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		opts  []EncodeOption
+		want  string
+	}{
+		{
+			name: "tabular array",
+			input: struct {
+				Users []struct {
+					ID    int    `json:"id"`
+					Email string `json:"email"`
+				} `json:"users"`
+				Total int `json:"total"`
+			}{},
+			want: "users[N]{id,email}:\n  <integer>,<string>\n  ...\ntotal: <integer>",
+		},
+		{
+			name:  "list array of nested objects",
+			input: []templateUser(nil),
+			want: "[N]:\n  - id: <integer>\n    name: <string>\n    tags[N]: <string>,...\n" +
+				"    address:\n      city: <string>\n      zip: <string or null>\n    created: <date-time>\n  - ...",
+		},
+		{
+			name:  "reflect type",
+			input: reflect.TypeOf(templateAddress{}),
+			want:  "city: <string>\nzip: <string or null>",
+		},
+		{
+			name:  "recursive type",
+			input: templateTree{},
+			want:  "Name: <string>\nChildren[N]: <templateTree>,...",
+		},
+		{
+			name:  "map",
+			input: map[string]float64{},
+			want:  "<key>: <number>",
+		},
+		{
+			name:  "primitive",
+			input: true,
+			want:  "<boolean>",
+		},
+		{
+			name: "byte slice and interface",
+			input: struct {
+				Data  []byte
+				Extra interface{}
+			}{},
+			want: "Data: <string>\nExtra: <any>",
+		},
+		{
+			name: "delimiter and length marker",
+			input: []struct {
+				A int
+				B string
+			}{},
+			opts: []EncodeOption{WithDelimiter(pipe), WithLengthMarker("#")},
+			want: "[#N|]{A|B}:\n  <integer>|<string>\n  ...",
+		},
+		{
+			name: "nested list arrays",
+			input: struct {
+				Matrix [][]int `json:"matrix"`
+			}{},
+			want: "matrix[N]:\n  - [N]: <integer>,...\n  - ...",
+		},
+		{
+			name: "string option",
+			input: struct {
+				ID   int    `json:"id,string"`
+				OK   *bool  `json:"ok,omitempty,string"`
+				Tags []int  `json:"tags,string"`
+				Name string `json:"name,omitempty"`
+			}{},
+			want: "id: <string>\nok: <string or null>\ntags[N]: <integer>,...\nname: <string>",
+		},
+		{
+			name:  "list item fields at indent 4",
+			input: []templateUser(nil),
+			opts:  []EncodeOption{WithIndent(4)},
+			want: "[N]:\n    - id: <integer>\n        name: <string>\n        tags[N]: <string>,...\n" +
+				"        address:\n            city: <string>\n            zip: <string or null>\n        created: <date-time>\n    - ...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Template(tt.input, tt.opts...)
+			if err != nil {
+				t.Fatalf("Template() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Template() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateIndentParses(t *testing.T) {
+	for _, indent := range []int{2, 4} {
+		got, err := Template([]templateUser(nil), WithIndent(indent))
+		if err != nil {
+			t.Fatalf("Template() error = %v", err)
+		}
+		// Each array has a placeholder and a "..." element
+		doc, err := ParseTreeFromString(strings.ReplaceAll(got, "[N]", "[2]"), WithIndentSize(indent))
+		if err != nil {
+			t.Fatalf("ParseTree(indent %d) error = %v", indent, err)
+		}
+		item := doc.Root.(*ArrayNode).Items[0].(*ListItemNode).Value.(*ObjectNode)
+		if len(item.Fields) != 5 {
+			t.Errorf("indent %d: item has %d fields, want 5", indent, len(item.Fields))
+		}
+	}
+}
+
+func TestTemplateJSONSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{
+			name: "properties in order",
+			schema: `{"type":"object","properties":{
+				"users":{"type":"array","items":{"type":"object","properties":{
+					"id":{"type":"integer"},"name":{"type":"string"},"email":{"type":"string","format":"email"}}}},
+				"count":{"type":"integer"}}}`,
+			want: "users[N]{id,name,email}:\n  <integer>,<string>,<email>\n  ...\ncount: <integer>",
+		},
+		{
+			name: "references and nullable types",
+			schema: `{"properties":{"order":{"$ref":"#/$defs/Order"},"note":{"type":["string","null"]}},
+				"$defs":{"Order":{"type":"object","properties":{
+					"total":{"anyOf":[{"type":"number"},{"type":"null"}]},"paid":{"type":"boolean"}}}}}`,
+			want: "order:\n  total: <number or null>\n  paid: <boolean>\nnote: <string or null>",
+		},
+		{
+			name:   "enum and const",
+			schema: `{"type":"object","properties":{"status":{"enum":["ok","needs review",3]},"kind":{"const":"user"}}}`,
+			want:   "status: <ok or needs review or 3>\nkind: <user>",
+		},
+		{
+			name:   "union of primitives",
+			schema: `{"type":"object","properties":{"id":{"oneOf":[{"type":"string"},{"type":"integer"}]}}}`,
+			want:   "id: <string or integer>",
+		},
+		{
+			name: "all of",
+			schema: `{"allOf":[{"type":"object","properties":{"id":{"type":"integer"}}},
+				{"type":"object","properties":{"name":{"type":"string"}}}]}`,
+			want: "id: <integer>\nname: <string>",
+		},
+		{
+			name:   "additional properties",
+			schema: `{"type":"object","additionalProperties":{"type":"number"}}`,
+			want:   "<key>: <number>",
+		},
+		{
+			name:   "recursive reference",
+			schema: `{"$ref":"#/definitions/Node","definitions":{"Node":{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#/definitions/Node"}}}}}}`,
+			want:   "children[N]: <Node>,...",
+		},
+		{
+			name:   "root array without items",
+			schema: `{"type":"array"}`,
+			want:   "[N]: <any>,...",
+		},
+		{
+			name:   "boolean schema",
+			schema: `true`,
+			want:   "<any>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Template(JSONSchema(tt.schema))
+			if err != nil {
+				t.Fatalf("Template() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Template() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateMatchesMarshal(t *testing.T) {
+	type row struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	tmpl, err := Template(struct {
+		Users []row `json:"users"`
+	}{}, WithDelimiter(tab), WithLengthMarker("#"))
+	if err != nil {
+		t.Fatalf("Template() error = %v", err)
+	}
+
+	user := NewOrderedMap()
+	user.Set("id", 1)
+	user.Set("name", "Ada")
+	user.Set("email", "ada@example.com")
+	out, err := MarshalToString(map[string]interface{}{"users": []interface{}{user}}, WithDelimiter(tab), WithLengthMarker("#"))
+	if err != nil {
+		t.Fatalf("MarshalToString() error = %v", err)
+	}
+
+	header, _, _ := strings.Cut(tmpl, "\n")
+	marshaled, _, _ := strings.Cut(out, "\n")
+	if want := strings.Replace(marshaled, "#1", "#N", 1); header != want {
+		t.Errorf("template header = %q, want %q", header, want)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{"nil", nil, "template needs a type or a JSON schema"},
+		{"unsupported type", struct{ C chan int }{}, "unsupported template type chan int"},
+		{"invalid schema", JSONSchema(`{"type":`), "invalid JSON schema"},
+		{"unresolved reference", JSONSchema(`{"$ref":"#/$defs/Missing"}`), "unresolved schema reference: #/$defs/Missing"},
+		{"remote reference", JSONSchema(`{"$ref":"https://example.com/user.json"}`), "unsupported schema reference: https://example.com/user.json"},
+		{"unknown type", JSONSchema(`{"type":"decimal"}`), "unsupported schema type: decimal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Template(tt.input)
+			var encErr *EncodeError
+			if !errors.As(err, &encErr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Template() error = %v, want EncodeError containing %q", err, tt.want)
+			}
+		})
+	}
+}
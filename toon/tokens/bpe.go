@@ -0,0 +1,116 @@
+package tokens
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// BPE is a byte pair encoding tokenizer with a tiktoken vocabulary.
+// It is safe for concurrent use.
+type BPE struct {
+	ranks map[string]int
+}
+
+// LoadBPE reads a vocabulary in the tiktoken format: one line per token
+// with the base64-encoded token bytes and its rank, separated by a space.
+// Blank lines are ignored.
+func LoadBPE(r io.Reader) (*BPE, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		token, rank, ok := bytes.Cut(text, []byte(" "))
+		if !ok {
+			return nil, fmt.Errorf("tokens: line %d: expected \"token rank\"", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(token))
+		if err != nil {
+			return nil, fmt.Errorf("tokens: line %d: invalid token: %w", line, err)
+		}
+		n, err := strconv.Atoi(string(rank))
+		if err != nil {
+			return nil, fmt.Errorf("tokens: line %d: invalid rank: %w", line, err)
+		}
+		ranks[string(decoded)] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("tokens: empty vocabulary")
+	}
+	return &BPE{ranks: ranks}, nil
+}
+
+// LoadBPEFile reads a tiktoken vocabulary from the file at path.
+func LoadBPEFile(path string) (*BPE, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadBPE(f)
+}
+
+// Count implements Tokenizer.
+func (b *BPE) Count(text string) int {
+	n := 0
+	for _, piece := range split(text) {
+		n += len(b.encodePiece(piece))
+	}
+	return n
+}
+
+// Encode returns the token ranks of text. Bytes missing from the vocabulary
+// are returned as -1.
+func (b *BPE) Encode(text string) []int {
+	var ids []int
+	for _, piece := range split(text) {
+		ids = append(ids, b.encodePiece(piece)...)
+	}
+	return ids
+}
+
+// encodePiece splits piece into tokens by repeatedly merging the adjacent
+// pair with the lowest rank, and returns their ranks.
+func (b *BPE) encodePiece(piece string) []int {
+	if rank, ok := b.ranks[piece]; ok {
+		return []int{rank}
+	}
+
+	// parts holds the start offsets of the current tokens
+	parts := make([]int, len(piece)+1)
+	for i := range parts {
+		parts[i] = i
+	}
+	for len(parts) > 2 {
+		best, bestRank := -1, 0
+		for i := 0; i+2 < len(parts); i++ {
+			if rank, ok := b.ranks[piece[parts[i]:parts[i+2]]]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+
+	ids := make([]int, len(parts)-1)
+	for i := range ids {
+		rank, ok := b.ranks[piece[parts[i]:parts[i+1]]]
+		if !ok {
+			rank = -1
+		}
+		ids[i] = rank
+	}
+	return ids
+}
@@ -0,0 +1,129 @@
+package tokens
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"Hello world", []string{"Hello", " world"}},
+		{"it's 12345 ok", []string{"it", "'s", " ", "123", "45", " ok"}},
+		{`{"id": 1}`, []string{`{"`, "id", `":`, " ", "1", "}"}},
+		{"users[2]{id,name}:\n  1,Ada", []string{"users", "[", "2", "]{", "id", ",name", "}:\n", " ", " ", "1", ",Ada"}},
+		{"a  \n\n  b", []string{"a", "  \n\n", " ", " b"}},
+		{"end   ", []string{"end", "   "}},
+		{"日本語", []string{"日本語"}},
+	}
+
+	for _, tt := range tests {
+		if got := split(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestHeuristic(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"", 0},
+		{"hello", 1},
+		{"Hello world", 2},
+		{"internationalization", 4},
+		{"12345", 2},
+		{`{"id": 1}`, 6},
+		{"日本語", 3},
+	}
+
+	var tok Tokenizer = Heuristic{}
+	for _, tt := range tests {
+		if got := tok.Count(tt.input); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+
+	json := `{"users": [{"id": 1, "name": "Alice"}, {"id": 2, "name": "Bob"}]}`
+	toon := "users[2]{id,name}:\n  1,Alice\n  2,Bob"
+	if j, n := tok.Count(json), tok.Count(toon); n >= j {
+		t.Errorf("Count(TOON) = %d, want fewer than Count(JSON) = %d", n, j)
+	}
+}
+
+// testVocabulary returns a tiktoken vocabulary of all single bytes and a
+// few merges.
+func testVocabulary() string {
+	var sb strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, tok := range []string{"he", "ll", "hell", "hello", " w", " wo"} {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(tok)), 256+i)
+	}
+	return sb.String()
+}
+
+func TestBPE(t *testing.T) {
+	bpe, err := LoadBPE(strings.NewReader(testVocabulary()))
+	if err != nil {
+		t.Fatalf("LoadBPE() error = %v", err)
+	}
+
+	tests := []struct {
+		input string
+		want  []int
+	}{
+		{"hello", []int{259}},
+		{"hellx", []int{258, 'x'}},
+		{"hello world", []int{259, 261, 'r', 'l', 'd'}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := bpe.Encode(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Encode(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+		if got := bpe.Count(tt.input); got != len(tt.want) {
+			t.Errorf("Count(%q) = %d, want %d", tt.input, got, len(tt.want))
+		}
+	}
+}
+
+func TestLoadBPEFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.tiktoken")
+	if err := os.WriteFile(path, []byte(testVocabulary()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bpe, err := LoadBPEFile(path)
+	if err != nil {
+		t.Fatalf("LoadBPEFile() error = %v", err)
+	}
+	if got := bpe.Count("hello"); got != 1 {
+		t.Errorf("Count() = %d, want 1", got)
+	}
+
+	if _, err := LoadBPEFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadBPEFile(missing) error = nil, want error")
+	}
+}
+
+func TestLoadBPEErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"aGVsbG8=",
+		"!!! 1",
+		"aGVsbG8= one",
+	} {
+		if _, err := LoadBPE(strings.NewReader(input)); err == nil {
+			t.Errorf("LoadBPE(%q) error = nil, want error", input)
+		}
+	}
+}
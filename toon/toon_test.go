@@ -525,9 +525,9 @@ func TestAssignResultUnsupportedTarget(t *testing.T) {
 			errContains: "unsupported target type",
 		},
 		{
-			name:        "struct target",
+			name:        "struct value target",
 			input:       "name: Alice",
-			target:      &struct{ Name string }{},
+			target:      struct{ Name string }{},
 			errContains: "unsupported target type",
 		},
 	}
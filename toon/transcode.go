@@ -0,0 +1,1159 @@
+package toon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TranscodeJSON reads a JSON document from src and writes it to dst as TOON
+// without decoding the whole document into memory.
+//
+// Objects are written field by field as they are read, in source key order,
+// and numbers keep their source text (1.50 stays 1.50). An array header
+// carries the array length, so each array is held as encoded rows or lines
+// until its closing bracket; its elements are decoded one at a time. The
+// format of an array is chosen from its first element and switched to list
+// format if a later element does not fit, so arrays are laid out as Marshal
+// lays them out.
+//
+// Options are the encode options of Marshal. WithFlattenPaths and
+// WithSortKeys need the whole document and are rejected, as are
+// WithFloatFormat and WithFloatFormatAt, whose rounding Marshal reports.
+//
+// Example:
+//
+//	resp, err := http.Get(url)
+//	defer resp.Body.Close()
+//	err = toon.TranscodeJSON(os.Stdout, resp.Body, toon.WithDelimiter("\t"))
+func TranscodeJSON(dst io.Writer, src io.Reader, opts ...EncodeOption) error {
+	encOpts := applyEncodeOptions(opts...)
+	if err := validateEncodeOptions(encOpts); err != nil {
+		return err
+	}
+	resolveAutoDelimiter(encOpts)
+	if encOpts.FlattenPaths || encOpts.SortKeys {
+		return &EncodeError{Message: "path flattening and key sorting are not supported when transcoding"}
+	}
+	if formatsFloats(encOpts) {
+		return &EncodeError{Message: "float formatting is not supported when transcoding"}
+	}
+
+	dec := json.NewDecoder(src)
+	dec.UseNumber()
+
+	t := &transcoder{
+		dec:  dec,
+		opts: encOpts,
+		out:  bufio.NewWriter(dst),
+		w:    newWriter(encOpts.Indent),
+	}
+	if err := t.root(); err != nil {
+		return err
+	}
+	return t.out.Flush()
+}
+
+// transcoder streams JSON tokens into TOON lines.
+type transcoder struct {
+	dec   *json.Decoder
+	opts  *EncodeOptions
+	out   *bufio.Writer
+	w     *writer // lines not yet written to out
+	wrote bool    // whether any line has been written to out
+}
+
+// root transcodes the top-level value.
+func (t *transcoder) root() error {
+	tok, err := t.token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		err = t.object(0)
+	case json.Delim('['):
+		err = t.array("", 0)
+	default:
+		err = encodeValuePrimitive(t.w, "", tok, 0, t.opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.dec.Token(); err != io.EOF {
+		return &DecodeError{Message: "invalid JSON: unexpected data after top-level value"}
+	}
+	return t.flush()
+}
+
+// object streams the fields of an object whose '{' has been read.
+func (t *transcoder) object(depth int) error {
+	for t.dec.More() {
+		keyTok, err := t.token()
+		if err != nil {
+			return err
+		}
+		key := encodeKey(keyTok.(string))
+
+		tok, err := t.token()
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			t.w.push(key+colon, depth)
+			err = t.object(depth + 1)
+		case json.Delim('['):
+			err = t.array(key, depth)
+		default:
+			err = encodeValuePrimitive(t.w, key, tok, depth, t.opts)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := t.flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err := t.token()
+	return err
+}
+
+// array encodes an array whose '[' has been read, one element at a time.
+func (t *transcoder) array(key string, depth int) error {
+	a := &arrayStream{depth: depth, opts: t.opts}
+	for t.dec.More() {
+		item, err := t.value()
+		if err != nil {
+			return err
+		}
+		if err := a.add(item); err != nil {
+			return err
+		}
+	}
+
+	if _, err := t.token(); err != nil {
+		return err
+	}
+	return a.writeTo(t.w, key)
+}
+
+// value decodes the next JSON value.
+func (t *transcoder) value() (Value, error) {
+	return decodeJSONValue(t.dec)
+}
+
+// token reads the next JSON token.
+func (t *transcoder) token() (json.Token, error) {
+	return jsonToken(t.dec)
+}
+
+// FromJSON decodes a JSON document with objects as *OrderedMap in source key
+// order and numbers as json.Number, so Marshal keeps both. Data after the
+// top-level value is an error.
+//
+// Example:
+//
+//	v, err := toon.FromJSON(resp.Body)
+//	s, err := toon.MarshalToString(v, toon.WithSortKeys(true))
+func FromJSON(r io.Reader) (Value, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &DecodeError{Message: "invalid JSON: unexpected data after top-level value"}
+	}
+	return v, nil
+}
+
+// decodeJSONValue decodes the next JSON value from a decoder set to
+// UseNumber, with objects as *OrderedMap in source key order.
+func decodeJSONValue(dec *json.Decoder) (Value, error) {
+	tok, err := jsonToken(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := NewOrderedMap()
+		for dec.More() {
+			key, err := jsonToken(dec)
+			if err != nil {
+				return nil, err
+			}
+			val, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(key.(string), val)
+		}
+		_, err := jsonToken(dec)
+		return obj, err
+
+	case json.Delim('['):
+		items := []Value{}
+		for dec.More() {
+			item, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := jsonToken(dec)
+		return items, err
+
+	default:
+		return tok, nil
+	}
+}
+
+// jsonToken reads the next JSON token.
+func jsonToken(dec *json.Decoder) (json.Token, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, &DecodeError{Message: fmt.Sprintf("invalid JSON: %v", err), Cause: err}
+	}
+	return tok, nil
+}
+
+// flush writes pending lines to the output.
+func (t *transcoder) flush() error {
+	if t.w.Len() == 0 {
+		return nil
+	}
+	if t.wrote {
+		if _, err := t.out.WriteString(newline); err != nil {
+			return err
+		}
+	}
+	_, err := t.out.WriteString(t.w.String())
+	t.w.Reset()
+	t.wrote = true
+	return err
+}
+
+// arrayStream accumulates the encoded elements of an array until its length
+// is known. Elements are kept as inline cells or tabular rows while they fit
+// that format, and re-encoded as list items once one does not.
+type arrayStream struct {
+	depth  int
+	opts   *EncodeOptions
+	format arrayFormat
+	length int
+	cells  []string   // inline format
+	fields []string   // tabular format
+	rows   [][]string // tabular format
+	items  *writer    // list format
+	values []Value    // cells before encoding, kept for DelimiterAuto
+}
+
+// add appends an element.
+func (a *arrayStream) add(item Value) error {
+	a.length++
+
+	if a.length == 1 {
+		switch {
+		case isPrimitive(item):
+			a.format = arrayFormatInline
+		case isTabularRow(item):
+			a.format = arrayFormatTabular
+			a.fields = item.(*OrderedMap).Keys()
+		default:
+			a.format = arrayFormatList
+			a.items = newWriter(a.opts.Indent)
+		}
+	}
+
+	switch a.format {
+	case arrayFormatInline:
+		if isPrimitive(item) {
+			cell, err := encodePrimitive(item, a.opts.Delimiter)
+			if err != nil {
+				return err
+			}
+			a.cells = append(a.cells, cell)
+			a.keep(item)
+			return nil
+		}
+		a.toList()
+
+	case arrayFormatTabular:
+		if row, ok := a.row(item); ok {
+			cells := make([]string, len(row))
+			for i, val := range row {
+				cell, err := encodePrimitive(val, a.opts.Delimiter)
+				if err != nil {
+					return err
+				}
+				cells[i] = cell
+			}
+			a.rows = append(a.rows, cells)
+			a.keep(row...)
+			return nil
+		}
+		a.toList()
+	}
+
+	return encodeListItem(a.items, item, a.depth+1, a.opts, true)
+}
+
+// row returns the values of item in field order if it fits the table.
+func (a *arrayStream) row(item Value) ([]Value, bool) {
+	obj, ok := item.(*OrderedMap)
+	if !ok || obj.Len() != len(a.fields) {
+		return nil, false
+	}
+
+	row := make([]Value, len(a.fields))
+	for i, f := range a.fields {
+		val, ok := obj.Get(f)
+		if !ok || !isPrimitive(val) {
+			return nil, false
+		}
+		row[i] = val
+	}
+	return row, true
+}
+
+// toList re-encodes the elements collected so far as list items.
+func (a *arrayStream) toList() {
+	a.items = newWriter(a.opts.Indent)
+	itemDepth := a.depth + 1
+
+	for _, cell := range a.cells {
+		a.items.push(listItemPrefix+cell, itemDepth)
+	}
+
+	// Same layout as encodeListItemMap for primitive values
+	aligned := strings.Repeat(" ", itemDepth*a.opts.Indent+2)
+	for _, row := range a.rows {
+		for i, cell := range row {
+			field := encodeKey(a.fields[i])
+			if i == 0 {
+				a.items.push(listItemPrefix+field+colon+space+cell, itemDepth)
+			} else {
+				a.items.pushRaw(newline + aligned + field + colon + space + cell)
+			}
+		}
+	}
+
+	a.format = arrayFormatList
+	a.cells, a.rows, a.values = nil, nil, nil
+}
+
+// keep records cell values for choosing the delimiter at the end.
+func (a *arrayStream) keep(values ...Value) {
+	if a.opts.autoDelimiter {
+		a.values = append(a.values, values...)
+	}
+}
+
+// chooseDelimiter re-encodes the cells with the delimiter chosen for the
+// array when the delimiter is automatic.
+func (a *arrayStream) chooseDelimiter() error {
+	if !a.opts.autoDelimiter {
+		return nil
+	}
+	opts, err := withArrayDelimiter(a.opts, a.values)
+	if err != nil {
+		return err
+	}
+
+	next := 0
+	reencode := func(cells []string) error {
+		for i := range cells {
+			if cells[i], err = encodePrimitive(a.values[next], opts.Delimiter); err != nil {
+				return err
+			}
+			next++
+		}
+		return nil
+	}
+	if err := reencode(a.cells); err != nil {
+		return err
+	}
+	for _, row := range a.rows {
+		if err := reencode(row); err != nil {
+			return err
+		}
+	}
+	a.opts = opts
+	return nil
+}
+
+// writeTo writes the array with its header to w.
+func (a *arrayStream) writeTo(w *writer, key string) error {
+	if err := a.chooseDelimiter(); err != nil {
+		return err
+	}
+
+	switch a.format {
+	case arrayFormatInline:
+		w.push(formatArrayHeader(key, a.length, nil, a.opts)+space+strings.Join(a.cells, a.opts.Delimiter), a.depth)
+	case arrayFormatTabular:
+		w.push(formatArrayHeader(key, a.length, a.fields, a.opts), a.depth)
+		for _, row := range a.rows {
+			w.push(strings.Join(row, a.opts.Delimiter), a.depth+1)
+		}
+	case arrayFormatList:
+		w.push(formatArrayHeader(key, a.length, nil, a.opts), a.depth)
+		w.pushRaw(newline + a.items.String())
+	default:
+		return encodeEmptyArray(w, key, a.depth, a.opts)
+	}
+	return nil
+}
+
+// isTabularRow reports whether item can start a tabular array: a non-empty
+// object with primitive values only.
+func isTabularRow(item Value) bool {
+	obj, ok := item.(*OrderedMap)
+	return ok && obj.Len() > 0 && orderedMapHasPrimitiveValues(obj)
+}
+
+// TranscodeToJSON reads a TOON document from src and writes it to dst as JSON
+// without decoding the whole document into memory.
+//
+// Lines are read one at a time and each value is written as soon as it is
+// parsed, so memory use does not grow with the document. Fields are written
+// in source order and numbers keep their source text, so 1.50 stays 1.50 and
+// integers beyond float64 precision are not rounded. Tabular rows become
+// objects with the header's field order. A repeated key is written again
+// where it appears; JSON readers that keep the last value, as encoding/json
+// does, see the value Unmarshal returns.
+//
+// Options are the decode options of Unmarshal. Strict mode checks lengths and
+// indentation as lines are read; on error, part of the JSON may already have
+// been written to dst. WithExpandPaths("safe") merges dotted keys into nested
+// objects, placed where their first key appears. A later key can add to an
+// object seen earlier, so with path expansion the document is read and parsed
+// into a syntax tree first, as with ParseTree.
+//
+// Example:
+//
+//	err := toon.TranscodeToJSON(os.Stdout, strings.NewReader(reply))
+func TranscodeToJSON(dst io.Writer, src io.Reader, opts ...DecodeOption) error {
+	return TranscodeToJSONIndent(dst, src, "", opts...)
+}
+
+// TranscodeToJSONIndent is like TranscodeToJSON but indents the output: each
+// nesting level is indented by one copy of indent, as with json.MarshalIndent.
+// An empty indent writes compact JSON.
+//
+// Example:
+//
+//	err := toon.TranscodeToJSONIndent(os.Stdout, strings.NewReader(reply), "  ")
+func TranscodeToJSONIndent(dst io.Writer, src io.Reader, indent string, opts ...DecodeOption) error {
+	decOpts := applyDecodeOptions(opts...)
+	if err := validateDecodeOptions(decOpts); err != nil {
+		return err
+	}
+
+	j := &jsonWriter{
+		out:    bufio.NewWriter(dst),
+		indent: indent,
+		strict: decOpts.Strict,
+	}
+
+	var err error
+	if decOpts.ExpandPaths == "safe" {
+		err = j.expandDocument(src, decOpts)
+	} else {
+		s := &toonStream{
+			lines: newLineSource(src, decOpts),
+			j:     j,
+			opts:  decOpts,
+		}
+		err = s.root()
+	}
+	if err != nil {
+		return err
+	}
+	return j.out.Flush()
+}
+
+// lineSource reads TOON lines one at a time, keeping the lines read ahead
+// of the parser.
+type lineSource struct {
+	r       *bufio.Reader
+	opts    *DecodeOptions
+	pending []lineInfo // lines read but not consumed
+	number  int
+	offset  int
+	eof     bool
+	unit    int  // indentation of one nesting level
+	found   bool // unit was taken from the input
+}
+
+// newLineSource returns a lineSource reading from r.
+func newLineSource(r io.Reader, opts *DecodeOptions) *lineSource {
+	return &lineSource{r: bufio.NewReader(r), opts: opts, unit: opts.IndentSize}
+}
+
+// read appends the next input line to pending. It returns false at the end
+// of the input.
+func (ls *lineSource) read() (bool, error) {
+	if ls.eof {
+		return false, nil
+	}
+	text, err := ls.r.ReadString('\n')
+	if err == io.EOF {
+		ls.eof = true
+		if text == "" {
+			return false, nil
+		}
+	} else if err != nil {
+		return false, err
+	}
+	text = strings.TrimSuffix(text, newline)
+
+	ls.number++
+	line := lineInfo{
+		content:    strings.TrimLeft(text, " \t"),
+		indent:     calculateIndent(text),
+		lineNumber: ls.number,
+		offset:     ls.offset,
+		original:   text,
+		isBlank:    strings.TrimSpace(text) == "",
+	}
+	ls.offset += len(text) + 1
+
+	if ls.opts.Strict {
+		if err := validateLineIndentation([]lineInfo{line}, ls.opts.IndentSize); err != nil {
+			return false, err
+		}
+	} else if !ls.found && !line.isBlank && line.indent > 0 {
+		// As with ParseTree, the first indented line sets the unit
+		ls.unit, ls.found = line.indent, true
+	}
+
+	ls.pending = append(ls.pending, line)
+	return true, nil
+}
+
+// peekAt returns the non-blank line n lines ahead of the parser.
+func (ls *lineSource) peekAt(n int) (lineInfo, bool, error) {
+	for i := 0; ; i++ {
+		for i >= len(ls.pending) {
+			ok, err := ls.read()
+			if !ok || err != nil {
+				return lineInfo{}, false, err
+			}
+		}
+		if ls.pending[i].isBlank {
+			continue
+		}
+		if n == 0 {
+			return ls.pending[i], true, nil
+		}
+		n--
+	}
+}
+
+// peek returns the next non-blank line without consuming it.
+func (ls *lineSource) peek() (lineInfo, bool, error) {
+	return ls.peekAt(0)
+}
+
+// blank returns the first blank line before the next non-blank line.
+// It is only valid after a successful peek.
+func (ls *lineSource) blank() (lineInfo, bool) {
+	if len(ls.pending) > 0 && ls.pending[0].isBlank {
+		return ls.pending[0], true
+	}
+	return lineInfo{}, false
+}
+
+// next consumes the blank lines and the line returned by peek.
+func (ls *lineSource) next() {
+	for len(ls.pending) > 0 && ls.pending[0].isBlank {
+		ls.pending = ls.pending[1:]
+	}
+	if len(ls.pending) > 0 {
+		ls.pending = ls.pending[1:]
+	}
+}
+
+// toonStream parses TOON lines as they are read and writes each value as
+// JSON. It follows the grammar of the syntax tree parser.
+type toonStream struct {
+	lines *lineSource
+	j     *jsonWriter
+	opts  *DecodeOptions
+}
+
+// root writes the root value and rejects trailing content.
+func (s *toonStream) root() error {
+	first, ok, err := s.lines.peek()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.j.out.WriteString("{}")
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(first.content, openBracket):
+		s.lines.next()
+		err = s.array(first, contentStart(first), first.indent, 0)
+	case detectSingleLineType(first.content) == rootTypePrimitive:
+		_, more, perr := s.lines.peekAt(1)
+		if perr != nil {
+			return perr
+		}
+		if more {
+			err = s.object(first.indent, 0)
+			break
+		}
+		s.lines.next()
+		err = s.scalar(first, contentStart(first), contentEnd(first))
+	default:
+		err = s.object(first.indent, 0)
+	}
+	if err != nil {
+		return err
+	}
+
+	line, ok, err := s.lines.peek()
+	if err != nil {
+		return err
+	}
+	if ok {
+		return lineError(line, "unexpected content after root value")
+	}
+	return nil
+}
+
+// object writes an object of the fields indented exactly by indent.
+func (s *toonStream) object(indent, depth int) error {
+	s.j.out.WriteByte('{')
+	n, err := s.fields(indent, depth, 0)
+	if err != nil {
+		return err
+	}
+	s.j.end('}', n, depth)
+	return nil
+}
+
+// fields writes consecutive fields indented exactly by indent as members of
+// an object at depth that already has written members. It returns the new
+// member count.
+func (s *toonStream) fields(indent, depth, written int) (int, error) {
+	for {
+		line, ok, err := s.lines.peek()
+		if err != nil {
+			return 0, err
+		}
+		if !ok || line.indent < indent {
+			return written, nil
+		}
+		if line.indent > indent {
+			return 0, lineError(line, "unexpected indentation")
+		}
+
+		s.lines.next()
+		s.j.separator(written, depth+1)
+		if err := s.field(line, contentStart(line), line.indent, line.indent, depth+1); err != nil {
+			return 0, err
+		}
+		written++
+	}
+}
+
+// field writes the key and value of a field starting at byte start of a
+// consumed line. Nested arrays own lines indented deeper than arrayOwner;
+// nested objects own lines indented deeper than objectOwner.
+func (s *toonStream) field(line lineInfo, start, arrayOwner, objectOwner, depth int) error {
+	p := newParser(line.original[start:])
+	key, _, err := p.parseKeyWithQuoteInfo()
+	if err != nil {
+		return lineErrorAt(line, start+p.pos, errorMessage(err))
+	}
+	keyEnd := start + p.pos
+	if err := s.j.key(key); err != nil {
+		return err
+	}
+
+	if p.peek() == '[' {
+		return s.array(line, keyEnd, arrayOwner, depth)
+	}
+
+	p.skipWhitespace()
+	if p.peek() != ':' {
+		return lineErrorAt(line, start+p.pos, "expected ':' after key")
+	}
+	valueStart := skipSpaces(line.original, start+p.pos+1)
+	valueEnd := contentEnd(line)
+	if valueStart < valueEnd {
+		return s.scalar(line, valueStart, valueEnd)
+	}
+
+	next, ok, err := s.lines.peek()
+	if err != nil {
+		return err
+	}
+	if ok && next.indent > objectOwner {
+		return s.object(next.indent, depth)
+	}
+	s.j.out.WriteString("{}")
+	return nil
+}
+
+// array writes an array whose header starts at byte start of a consumed
+// line. Rows and list items must be indented deeper than owner.
+func (s *toonStream) array(line lineInfo, start, owner, depth int) error {
+	header, headerEnd, err := parseTreeArrayHeader(line, start)
+	if err != nil {
+		return err
+	}
+	valueStart := skipSpaces(line.original, headerEnd)
+	valueEnd := contentEnd(line)
+
+	s.j.out.WriteByte('[')
+	n := 0
+	switch {
+	case header.Fields != nil:
+		if valueStart < valueEnd {
+			return lineErrorAt(line, valueStart, "tabular array rows must start on the next line")
+		}
+		n, err = s.rows(header, owner, depth)
+	case valueStart < valueEnd:
+		for _, cell := range splitCells(line, valueStart, valueEnd, header.Delimiter) {
+			s.j.separator(n, depth+1)
+			if err := s.scalar(line, cell[0], cell[1]); err != nil {
+				return err
+			}
+			n++
+		}
+	default:
+		n, err = s.listItems(owner, depth)
+	}
+	if err != nil {
+		return err
+	}
+	s.j.end(']', n, depth)
+
+	if s.opts.Strict && n != header.Length {
+		return &DecodeError{
+			Message: fmt.Sprintf("array length mismatch: expected %d, got %d", header.Length, n),
+			Line:    line.lineNumber,
+			Context: line.original,
+		}
+	}
+	return nil
+}
+
+// rows writes the data rows of a tabular array as objects and returns
+// their count.
+func (s *toonStream) rows(header *ArrayHeader, owner, depth int) (int, error) {
+	n := 0
+	for {
+		line, ok, err := s.lines.peek()
+		if err != nil {
+			return 0, err
+		}
+		if !ok || line.indent <= owner || hasUnquotedColon(line.content) {
+			return n, nil
+		}
+		if err := s.checkBlank(); err != nil {
+			return 0, err
+		}
+		s.lines.next()
+
+		row := &RowNode{}
+		for _, cell := range splitCells(line, contentStart(line), contentEnd(line), header.Delimiter) {
+			scalar, err := newScalarNode(line, cell[0], cell[1])
+			if err != nil {
+				return 0, err
+			}
+			row.Cells = append(row.Cells, scalar)
+		}
+		if s.opts.Strict && len(row.Cells) != len(header.Fields) {
+			return 0, &DecodeError{
+				Message: fmt.Sprintf("tabular array row has wrong number of values: expected %d, got %d", len(header.Fields), len(row.Cells)),
+				Line:    line.lineNumber,
+				Context: line.original,
+			}
+		}
+
+		s.j.separator(n, depth+1)
+		if err := s.j.row(header.Fields, row, depth+1); err != nil {
+			return 0, err
+		}
+		n++
+	}
+}
+
+// listItems writes the "- " items of a list array and returns their count.
+func (s *toonStream) listItems(owner, depth int) (int, error) {
+	n := 0
+	for {
+		line, ok, err := s.lines.peek()
+		if err != nil {
+			return 0, err
+		}
+		if !ok || line.indent <= owner || !isListItemLine(line.content) {
+			return n, nil
+		}
+		if err := s.checkBlank(); err != nil {
+			return 0, err
+		}
+		s.lines.next()
+
+		s.j.separator(n, depth+1)
+		if err := s.listItem(line, depth+1); err != nil {
+			return 0, err
+		}
+		n++
+	}
+}
+
+// checkBlank rejects, in strict mode, a blank line before the next element
+// of an array.
+func (s *toonStream) checkBlank() error {
+	if line, ok := s.lines.blank(); ok && s.opts.Strict {
+		return &DecodeError{
+			Message: "blank lines not allowed within arrays in strict mode",
+			Line:    line.lineNumber,
+			Context: line.original,
+		}
+	}
+	return nil
+}
+
+// listItem writes a consumed list item line and everything nested under it.
+func (s *toonStream) listItem(line lineInfo, depth int) error {
+	end := contentEnd(line)
+	valueStart := skipSpaces(line.original, contentStart(line)+len(listItemMarker))
+
+	switch {
+	case valueStart >= end:
+		s.j.out.WriteString("{}")
+		return nil
+	case line.original[valueStart] == '[':
+		return s.array(line, valueStart, line.indent, depth)
+	case isFieldStart(line.original[valueStart:end]):
+		return s.listItemObject(line, valueStart, depth)
+	default:
+		return s.scalar(line, valueStart, end)
+	}
+}
+
+// listItemObject writes an object whose first field sits on the hyphen line.
+func (s *toonStream) listItemObject(line lineInfo, start, depth int) error {
+	s.j.out.WriteByte('{')
+	s.j.separator(0, depth+1)
+	if err := s.field(line, start, line.indent, line.indent+s.lines.unit, depth+1); err != nil {
+		return err
+	}
+
+	n := 1
+	next, ok, err := s.lines.peek()
+	if err != nil {
+		return err
+	}
+	if ok && next.indent > line.indent && !isListItemLine(next.content) {
+		if n, err = s.fields(next.indent, depth, n); err != nil {
+			return err
+		}
+	}
+	s.j.end('}', n, depth)
+	return nil
+}
+
+// scalar writes the primitive at line[start:end].
+func (s *toonStream) scalar(line lineInfo, start, end int) error {
+	node, err := newScalarNode(line, start, end)
+	if err != nil {
+		return err
+	}
+	return s.j.scalar(node)
+}
+
+// jsonWriter writes JSON text, and syntax trees with dotted keys expanded.
+type jsonWriter struct {
+	out    *bufio.Writer
+	indent string
+	strict bool // report path expansion conflicts
+}
+
+// expandDocument parses src into a syntax tree and writes it with dotted
+// keys expanded.
+func (j *jsonWriter) expandDocument(src io.Reader, opts *DecodeOptions) error {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	doc, err := parseDocument(string(data), opts)
+	if err != nil {
+		return err
+	}
+	if doc.Root == nil {
+		j.out.WriteString("{}")
+		return nil
+	}
+	return j.node(doc.Root, 0)
+}
+
+// node writes n at the given nesting depth.
+func (j *jsonWriter) node(n SyntaxNode, depth int) error {
+	switch node := n.(type) {
+	case *ObjectNode:
+		return j.object(node, depth)
+	case *ArrayNode:
+		return j.array(node, depth)
+	case *ListItemNode:
+		return j.node(node.Value, depth)
+	case *ScalarNode:
+		return j.scalar(node)
+	default:
+		return &DecodeError{Message: fmt.Sprintf("unexpected syntax node %T", n)}
+	}
+}
+
+// object writes an object with its dotted keys expanded.
+func (j *jsonWriter) object(obj *ObjectNode, depth int) error {
+	expanded, err := j.expandObject(obj)
+	if err != nil {
+		return err
+	}
+	return j.expanded(expanded, depth)
+}
+
+// expandObject merges the fields of obj into an ordered map whose values
+// are syntax nodes or, for nested and expanded objects, ordered maps. The
+// conflict rules are those of Unmarshal with WithExpandPaths("safe").
+func (j *jsonWriter) expandObject(obj *ObjectNode) (*OrderedMap, error) {
+	result := NewOrderedMap()
+	for _, f := range obj.Fields {
+		var value interface{} = f.Value
+		if nested, ok := f.Value.(*ObjectNode); ok {
+			expanded, err := j.expandObject(nested)
+			if err != nil {
+				return nil, err
+			}
+			value = expanded
+		}
+
+		var err error
+		if !f.Quoted && strings.Contains(f.Key, ".") && isExpandablePath(f.Key) {
+			err = j.expandPath(result, strings.Split(f.Key, "."), value)
+		} else {
+			err = j.assign(result, f.Key, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// expandPath sets the value of a dotted key split into parts, creating the
+// intermediate objects.
+func (j *jsonWriter) expandPath(target *OrderedMap, parts []string, value interface{}) error {
+	for _, part := range parts[:len(parts)-1] {
+		existing, exists := target.Get(part)
+		nested, isObject := existing.(*OrderedMap)
+		if !isObject {
+			if exists && j.strict {
+				return &DecodeError{
+					Message: fmt.Sprintf("path expansion conflict: key %q has type %s, cannot expand as object", part, jsonType(existing)),
+				}
+			}
+			nested = NewOrderedMap()
+			target.Set(part, nested)
+		}
+		target = nested
+	}
+
+	key := parts[len(parts)-1]
+	if existing, exists := target.Get(key); exists && j.strict {
+		existingType, newType := jsonType(existing), jsonType(value)
+		if existingType != newType && existingType != "null" && newType != "null" {
+			return &DecodeError{
+				Message: fmt.Sprintf("path expansion conflict: key %q already exists with type %s, cannot assign type %s", key, existingType, newType),
+			}
+		}
+	}
+	target.Set(key, value)
+	return nil
+}
+
+// assign sets a key that is not expanded.
+func (j *jsonWriter) assign(target *OrderedMap, key string, value interface{}) error {
+	if existing, exists := target.Get(key); exists && j.strict {
+		existingType, newType := jsonType(existing), jsonType(value)
+		if existingType == "object" && newType != "object" && newType != "null" {
+			return &DecodeError{
+				Message: fmt.Sprintf("path expansion conflict: key %q conflicts with expanded path (type %s cannot overwrite %s)", key, newType, existingType),
+			}
+		}
+	}
+	target.Set(key, value)
+	return nil
+}
+
+// jsonType returns the JSON type of a value of an expanded object.
+func jsonType(v interface{}) string {
+	switch node := v.(type) {
+	case *OrderedMap:
+		return "object"
+	case *ArrayNode:
+		return "array"
+	case *ScalarNode:
+		switch node.Value.(type) {
+		case nil:
+			return "null"
+		case string:
+			return "string"
+		case bool:
+			return "boolean"
+		default:
+			return "number"
+		}
+	default:
+		return "unknown"
+	}
+}
+
+// expanded writes an object built by expandObject.
+func (j *jsonWriter) expanded(obj *OrderedMap, depth int) error {
+	j.out.WriteByte('{')
+	for i, k := range obj.Keys() {
+		j.separator(i, depth+1)
+		if err := j.key(k); err != nil {
+			return err
+		}
+		value, _ := obj.Get(k)
+		var err error
+		if nested, ok := value.(*OrderedMap); ok {
+			err = j.expanded(nested, depth+1)
+		} else {
+			err = j.node(value.(SyntaxNode), depth+1)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	j.end('}', obj.Len(), depth)
+	return nil
+}
+
+// array writes the items of an array; tabular rows become objects.
+func (j *jsonWriter) array(arr *ArrayNode, depth int) error {
+	j.out.WriteByte('[')
+	for i, item := range arr.Items {
+		j.separator(i, depth+1)
+		var err error
+		if row, ok := item.(*RowNode); ok {
+			err = j.row(arr.Header.Fields, row, depth+1)
+		} else {
+			err = j.node(item, depth+1)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	j.end(']', len(arr.Items), depth)
+	return nil
+}
+
+// row writes a tabular row as an object with the header's field order.
+func (j *jsonWriter) row(fields []string, row *RowNode, depth int) error {
+	j.out.WriteByte('{')
+	written := 0
+	for i, f := range fields {
+		if i >= len(row.Cells) {
+			break
+		}
+		j.separator(written, depth+1)
+		if err := j.key(f); err != nil {
+			return err
+		}
+		if err := j.scalar(row.Cells[i]); err != nil {
+			return err
+		}
+		written++
+	}
+	j.end('}', written, depth)
+	return nil
+}
+
+// scalar writes a primitive. Numbers are written as in the source when the
+// source text is valid JSON.
+func (j *jsonWriter) scalar(s *ScalarNode) error {
+	switch s.Value.(type) {
+	case string, bool, nil:
+	default:
+		if !s.Quoted && isJSONNumber(s.Raw) {
+			j.out.WriteString(s.Raw)
+			return nil
+		}
+	}
+	return j.value(s.Value)
+}
+
+// key writes an object key and the following colon.
+func (j *jsonWriter) key(k string) error {
+	if err := j.value(k); err != nil {
+		return err
+	}
+	j.out.WriteByte(':')
+	if j.indent != "" {
+		j.out.WriteByte(' ')
+	}
+	return nil
+}
+
+// value writes v with encoding/json, without HTML escaping.
+func (j *jsonWriter) value(v Value) error {
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return &DecodeError{Message: fmt.Sprintf("cannot write %v as JSON", v), Cause: err}
+	}
+	j.out.WriteString(strings.TrimSuffix(buf.String(), newline))
+	return nil
+}
+
+// separator starts the i-th member of an object or array at depth.
+func (j *jsonWriter) separator(i, depth int) {
+	if i > 0 {
+		j.out.WriteByte(',')
+	}
+	j.newline(depth)
+}
+
+// end closes an object or array with n members.
+func (j *jsonWriter) end(c byte, n, depth int) {
+	if n > 0 {
+		j.newline(depth)
+	}
+	j.out.WriteByte(c)
+}
+
+// newline starts an indented line when indentation is enabled.
+func (j *jsonWriter) newline(depth int) {
+	if j.indent == "" {
+		return
+	}
+	j.out.WriteString(newline)
+	j.out.WriteString(strings.Repeat(j.indent, depth))
+}
@@ -0,0 +1,367 @@
+package toon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTranscodeJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []EncodeOption
+		want  string
+	}{
+		{
+			name:  "key order and number text",
+			input: `{"z": 1.50, "a": 1e-7, "n": null, "s": "x,y", "nested": {"b": true, "a": []}}`,
+			want:  "z: 1.50\na: 1e-7\nn: null\ns: \"x,y\"\nnested:\n  b: true\n  a[0]:",
+		},
+		{
+			name:  "tabular",
+			input: `{"users": [{"id": 1, "name": "Ada"}, {"name": "Bob", "id": 2}]}`,
+			want:  "users[2]{id,name}:\n  1,Ada\n  2,Bob",
+		},
+		{
+			name:  "tabular falls back to list",
+			input: `{"items": [{"id": 1}, {"id": 2}, {"id": 3, "tags": ["a"]}]}`,
+			want:  "items[3]:\n  - id: 1\n  - id: 2\n  - id: 3\n    tags[1]: a",
+		},
+		{
+			name:  "inline falls back to list",
+			input: `[1, "two", {"three": 3}]`,
+			want:  "[3]:\n  - 1\n  - two\n  - three: 3",
+		},
+		{
+			name:  "delimiter and length marker",
+			input: `{"t": ["a b", "c|d"], "rows": [{"x": 1, "y": 2}]}`,
+			opts:  []EncodeOption{WithDelimiter(pipe), WithLengthMarker("#")},
+			want:  "t[#2|]: a b|\"c|d\"\nrows[#1|]{x|y}:\n  1|2",
+		},
+		{
+			name:  "root primitive",
+			input: `"hello"`,
+			want:  "hello",
+		},
+		{
+			name:  "empty object",
+			input: `{}`,
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := TranscodeJSON(&out, strings.NewReader(tt.input), tt.opts...); err != nil {
+				t.Fatalf("TranscodeJSON() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("TranscodeJSON() =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscodeJSONMatchesMarshal(t *testing.T) {
+	files, err := filepath.Glob("../testdata/fixtures/encode/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no encode fixtures found: %v", err)
+	}
+
+	for _, file := range files {
+		fixture, err := loadFixture(file)
+		if err != nil {
+			t.Fatalf("loadFixture(%s) error = %v", file, err)
+		}
+
+		for _, tt := range fixture.Tests {
+			encOpts := fixtureOptionsToEncodeOptions(tt.Options)
+			if tt.ShouldError || (encOpts != nil && encOpts.FlattenPaths) {
+				continue
+			}
+			t.Run(filepath.Base(file)+"/"+tt.Name, func(t *testing.T) {
+				opts := encodeOptionsToFunctional(encOpts)
+				want, err := MarshalToString(tt.Input, opts...)
+				if err != nil {
+					t.Skipf("input does not encode: %v", err)
+				}
+
+				data, err := json.Marshal(tt.Input)
+				if err != nil {
+					t.Fatalf("json.Marshal() error = %v", err)
+				}
+				var out strings.Builder
+				if err := TranscodeJSON(&out, strings.NewReader(string(data)), opts...); err != nil {
+					t.Fatalf("TranscodeJSON() error = %v", err)
+				}
+				if out.String() != want {
+					t.Errorf("TranscodeJSON(%s) =\n%s\nwant\n%s", data, out.String(), want)
+				}
+			})
+		}
+	}
+}
+
+func TestTranscodeJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []EncodeOption
+	}{
+		{"empty input", "", nil},
+		{"truncated", `{"a": [1, 2`, nil},
+		{"invalid", `{"a": tru}`, nil},
+		{"trailing data", `{"a": 1} {"b": 2}`, nil},
+		{"flatten", `{"a": {"b": 1}}`, []EncodeOption{WithFlattenPaths(true)}},
+		{"sort keys", `{"a": 1}`, []EncodeOption{Canonical()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := TranscodeJSON(&out, strings.NewReader(tt.input), tt.opts...)
+			var decErr *DecodeError
+			var encErr *EncodeError
+			if !errors.As(err, &decErr) && !errors.As(err, &encErr) {
+				t.Errorf("TranscodeJSON() error = %v, want DecodeError or EncodeError", err)
+			}
+		})
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	v, err := FromJSON(strings.NewReader(`{"z": 12345678901234567890, "a": [1.50, {"y": null, "b": true}]}`))
+	if err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+	got, err := MarshalToString(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := "z: 12345678901234567890\na[2]:\n  - 1.50\n  - y: null\n    b: true"; got != want {
+		t.Errorf("Marshal(FromJSON()) = %q, want %q", got, want)
+	}
+
+	for _, input := range []string{`{"a": `, `{"a": 1} 2`, ``} {
+		var decErr *DecodeError
+		if _, err := FromJSON(strings.NewReader(input)); !errors.As(err, &decErr) {
+			t.Errorf("FromJSON(%q) error = %v, want DecodeError", input, err)
+		}
+	}
+}
+
+func TestMarshalJSONNumber(t *testing.T) {
+	got, err := MarshalToString(map[string]interface{}{"n": json.Number("12.50"), "xs": []interface{}{json.Number("1"), json.Number("2e3")}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := "n: 12.50\nxs[2]: 1,2e3"; got != want {
+		t.Errorf("Marshal() = %q, want %q", got, want)
+	}
+
+	if _, err := MarshalToString(json.Number("1.")); err == nil {
+		t.Error("Marshal(invalid json.Number) error = nil, want error")
+	}
+	if !Equal(json.Number("2e3"), int64(2000)) {
+		t.Error("Equal(json.Number, int64) = false, want true")
+	}
+}
+
+func TestTranscodeToJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opts   []DecodeOption
+		indent string
+		want   string
+	}{
+		{
+			name:  "key order and number text",
+			input: "z: 1.50\na: 12345678901234567890\nn: null\ns: \"05\"\nt: 05\nnested:\n  b: true\n  a[0]:",
+			want:  `{"z":1.50,"a":12345678901234567890,"n":null,"s":"05","t":"05","nested":{"b":true,"a":[]}}`,
+		},
+		{
+			name:  "tabular rows keep header order",
+			input: "users[2]{name,id}:\n  Ada,1\n  \"<Bob>\",2",
+			want:  `{"users":[{"name":"Ada","id":1},{"name":"<Bob>","id":2}]}`,
+		},
+		{
+			name:  "list items",
+			input: "items[3]:\n  - 1\n  - id: 2\n    tags[2|]: a|b\n  - [1]: x",
+			want:  `{"items":[1,{"id":2,"tags":["a","b"]},["x"]]}`,
+		},
+		{
+			name:  "repeated key",
+			input: "a: 1\nb: 2\na: 3",
+			opts:  []DecodeOption{WithStrictDecoding(false)},
+			want:  `{"a":1,"b":2,"a":3}`,
+		},
+		{
+			name:  "root array",
+			input: "[2]: x,\"y z\"",
+			want:  `["x","y z"]`,
+		},
+		{
+			name:  "root primitive",
+			input: "1e-7",
+			want:  `1e-7`,
+		},
+		{
+			name:  "empty document",
+			input: "",
+			want:  `{}`,
+		},
+		{
+			name:  "expand paths",
+			input: "z.y: 12345678901234567890\na: 1.50\nz.x: true\n\"q.r\": 1\nn:\n  m.k[1]: x",
+			opts:  []DecodeOption{WithExpandPaths("safe")},
+			want:  `{"z":{"y":12345678901234567890,"x":true},"a":1.50,"q.r":1,"n":{"m":{"k":["x"]}}}`,
+		},
+		{
+			name:  "expand paths last value wins",
+			input: "a: 1\na.b: 2",
+			opts:  []DecodeOption{WithExpandPaths("safe"), WithStrictDecoding(false)},
+			want:  `{"a":{"b":2}}`,
+		},
+		{
+			name:  "list item fields",
+			input: "[2]:\n  - a:\n      b: 1\n    c[1]{x}:\n      1\n    d: 2\n  -",
+			want:  `[{"a":{"b":1},"c":[{"x":1}],"d":2},{}]`,
+		},
+		{
+			name:   "indent",
+			input:  "a:\n  b[2]: 1,2\n  c[0]:\nd: x",
+			indent: "  ",
+			want:   "{\n  \"a\": {\n    \"b\": [\n      1,\n      2\n    ],\n    \"c\": []\n  },\n  \"d\": \"x\"\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := TranscodeToJSONIndent(&out, strings.NewReader(tt.input), tt.indent, tt.opts...); err != nil {
+				t.Fatalf("TranscodeToJSONIndent() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("TranscodeToJSON() =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+			if !json.Valid([]byte(out.String())) {
+				t.Errorf("TranscodeToJSON() wrote invalid JSON: %s", out.String())
+			}
+		})
+	}
+}
+
+func TestTranscodeToJSONMatchesUnmarshal(t *testing.T) {
+	files, err := filepath.Glob("../testdata/fixtures/decode/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no decode fixtures found: %v", err)
+	}
+
+	for _, file := range files {
+		fixture, err := loadFixture(file)
+		if err != nil {
+			t.Fatalf("loadFixture(%s) error = %v", file, err)
+		}
+
+		for _, tt := range fixture.Tests {
+			decOpts := fixtureOptionsToDecodeOptions(tt.Options)
+			input, ok := tt.Input.(string)
+			if !ok {
+				continue
+			}
+			t.Run(filepath.Base(file)+"/"+tt.Name, func(t *testing.T) {
+				var out strings.Builder
+				err := TranscodeToJSON(&out, strings.NewReader(input), decodeOptionsToFunctional(decOpts)...)
+				if tt.ShouldError {
+					if err == nil {
+						t.Errorf("TranscodeToJSON() error = nil, want error; output %s", out.String())
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("TranscodeToJSON() error = %v", err)
+				}
+
+				var got interface{}
+				if err := json.Unmarshal([]byte(out.String()), &got); err != nil {
+					t.Fatalf("json.Unmarshal(%s) error = %v", out.String(), err)
+				}
+				if !deepEqual(normalizeValue(got), normalizeValue(tt.Expected)) {
+					t.Errorf("TranscodeToJSON() = %s, want %#v", out.String(), tt.Expected)
+				}
+			})
+		}
+	}
+}
+
+func TestTranscodeToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []DecodeOption
+	}{
+		{"length mismatch", "a[2]: 1", nil},
+		{"bad indent", "a:\n   b: 1", nil},
+		{"blank line in array", "a[2]:\n  - 1\n\n  - 2", nil},
+		{"trailing content", "a: 1\n  b: 2", nil},
+		{"expand paths conflict", "a: 1\na.b: 2", []DecodeOption{WithExpandPaths("safe")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := TranscodeToJSON(&out, strings.NewReader(tt.input), tt.opts...)
+			var decErr *DecodeError
+			if !errors.As(err, &decErr) {
+				t.Errorf("TranscodeToJSON() error = %v, want DecodeError", err)
+			}
+		})
+	}
+}
+
+// rowSource produces a tabular document one row per read and records how
+// much JSON had been written when the last row was read.
+type rowSource struct {
+	rows    int
+	read    int
+	out     *strings.Builder
+	written int
+}
+
+func (r *rowSource) Read(p []byte) (int, error) {
+	var line string
+	switch {
+	case r.read == 0:
+		line = fmt.Sprintf("items[%d]{id,name}:\n", r.rows)
+	case r.read <= r.rows:
+		line = fmt.Sprintf("  %d,item%d\n", r.read, r.read)
+		if r.read == r.rows {
+			r.written = r.out.Len()
+		}
+	default:
+		return 0, io.EOF
+	}
+	r.read++
+	return copy(p, line), nil
+}
+
+func TestTranscodeToJSONStreams(t *testing.T) {
+	var out strings.Builder
+	src := &rowSource{rows: 10000, out: &out}
+	if err := TranscodeToJSON(&out, src); err != nil {
+		t.Fatalf("TranscodeToJSON() error = %v", err)
+	}
+	if src.written == 0 {
+		t.Errorf("TranscodeToJSON() wrote nothing before reading the last row")
+	}
+	if !json.Valid([]byte(out.String())) {
+		t.Errorf("TranscodeToJSON() wrote invalid JSON")
+	}
+}
@@ -0,0 +1,328 @@
+package toon
+
+import (
+	"io"
+	"strings"
+)
+
+// Pos identifies a position in TOON source text.
+type Pos struct {
+	Offset int // byte offset, starting at 0
+	Line   int // line number, starting at 1
+	Column int // byte column, starting at 1
+}
+
+// Span is the half-open source range [Start, End) covered by a syntax node.
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// SyntaxNode is implemented by every node of a Document tree:
+// *ObjectNode, *FieldNode, *ArrayNode, *ArrayHeader, *RowNode,
+// *ListItemNode and *ScalarNode.
+type SyntaxNode interface {
+	// Span returns the source range covered by the node.
+	Span() Span
+	syntaxNode()
+}
+
+// ArrayLayout identifies how an array is written in the source.
+type ArrayLayout int
+
+const (
+	// ArrayInline is a single-line array of primitives: key[3]: a,b,c
+	ArrayInline ArrayLayout = iota
+	// ArrayTabular is a header with field names followed by one row per line.
+	ArrayTabular
+	// ArrayList is a header followed by "- " items on subsequent lines.
+	ArrayList
+)
+
+// String returns the layout name.
+func (l ArrayLayout) String() string {
+	switch l {
+	case ArrayInline:
+		return "inline"
+	case ArrayTabular:
+		return "tabular"
+	case ArrayList:
+		return "list"
+	default:
+		return "unknown"
+	}
+}
+
+// Document is a concrete syntax tree of a TOON document.
+// Unlike the values produced by Unmarshal, it keeps field order, source
+// positions, original quoting, delimiters and declared array lengths.
+type Document struct {
+	// Root is an *ObjectNode, *ArrayNode or *ScalarNode, or nil for empty input.
+	Root SyntaxNode
+
+	source string
+	opts   *DecodeOptions
+	indent int
+}
+
+// ObjectNode is a sequence of fields at the same indentation.
+type ObjectNode struct {
+	Fields []*FieldNode
+	span   Span
+}
+
+// FieldNode is a single "key: value" entry of an object.
+type FieldNode struct {
+	// Key is the decoded key.
+	Key string
+	// RawKey is the key exactly as written, including quotes.
+	RawKey string
+	// Quoted reports whether the key was quoted.
+	Quoted bool
+	// KeySpan covers RawKey.
+	KeySpan Span
+	// Value is an *ObjectNode, *ArrayNode or *ScalarNode.
+	Value SyntaxNode
+	span  Span
+}
+
+// ArrayHeader is the bracketed part of an array: [#N|]{f1|f2}:
+type ArrayHeader struct {
+	// Length is the declared length.
+	Length int
+	// LengthMarker is the optional length prefix, e.g. "#".
+	LengthMarker string
+	// Delimiter is the active delimiter: "," | "\t" | "|".
+	Delimiter string
+	// Fields holds the decoded field names of a tabular array.
+	Fields []string
+	span   Span
+}
+
+// ArrayNode is an array together with its header.
+type ArrayNode struct {
+	Header *ArrayHeader
+	Layout ArrayLayout
+	// Items holds *ScalarNode values for inline arrays, *RowNode values for
+	// tabular arrays and *ListItemNode values for list arrays.
+	Items []SyntaxNode
+	span  Span
+}
+
+// RowNode is a single data row of a tabular array.
+type RowNode struct {
+	Cells []*ScalarNode
+	span  Span
+}
+
+// ListItemNode is a single "- " entry of a list array.
+type ListItemNode struct {
+	// Value is an *ObjectNode, *ArrayNode or *ScalarNode.
+	Value SyntaxNode
+	span  Span
+}
+
+// ScalarNode is a primitive value.
+type ScalarNode struct {
+	// Raw is the value exactly as written, including quotes.
+	Raw string
+	// Value is the decoded primitive.
+	Value Value
+	// Quoted reports whether the value was a quoted string.
+	Quoted bool
+	span   Span
+}
+
+// Span implements SyntaxNode.
+func (n *ObjectNode) Span() Span { return n.span }
+
+// Span implements SyntaxNode.
+func (n *FieldNode) Span() Span { return n.span }
+
+// Span implements SyntaxNode.
+func (n *ArrayHeader) Span() Span { return n.span }
+
+// Span implements SyntaxNode.
+func (n *ArrayNode) Span() Span { return n.span }
+
+// Span implements SyntaxNode.
+func (n *RowNode) Span() Span { return n.span }
+
+// Span implements SyntaxNode.
+func (n *ListItemNode) Span() Span { return n.span }
+
+// Span implements SyntaxNode.
+func (n *ScalarNode) Span() Span { return n.span }
+
+func (*ObjectNode) syntaxNode()   {}
+func (*FieldNode) syntaxNode()    {}
+func (*ArrayHeader) syntaxNode()  {}
+func (*ArrayNode) syntaxNode()    {}
+func (*RowNode) syntaxNode()      {}
+func (*ListItemNode) syntaxNode() {}
+func (*ScalarNode) syntaxNode()   {}
+
+// Field returns the last field with the given key, or nil.
+func (n *ObjectNode) Field(key string) *FieldNode {
+	for i := len(n.Fields) - 1; i >= 0; i-- {
+		if n.Fields[i].Key == key {
+			return n.Fields[i]
+		}
+	}
+	return nil
+}
+
+// ParseTree parses TOON input from r into a concrete syntax tree.
+//
+// Decoding options are honored for validation: in strict mode (the default)
+// indentation, array lengths and row widths are checked just as Unmarshal
+// does. Dotted keys are never expanded; the tree reflects the source as written.
+//
+// Example:
+//
+//	doc, err := toon.ParseTree(strings.NewReader("users[1]{id,name}:\n  1,Ada"))
+//	field := doc.Root.(*toon.ObjectNode).Fields[0]
+//	arr := field.Value.(*toon.ArrayNode)
+//	// arr.Layout == toon.ArrayTabular, arr.Header.Fields == []string{"id", "name"}
+func ParseTree(r io.Reader, opts ...DecodeOption) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	decOpts := applyDecodeOptions(opts...)
+	if err := validateDecodeOptions(decOpts); err != nil {
+		return nil, err
+	}
+
+	return parseDocument(string(data), decOpts)
+}
+
+// ParseTreeFromString parses a TOON string into a concrete syntax tree.
+//
+// This is a convenience function that wraps ParseTree.
+func ParseTreeFromString(s string, opts ...DecodeOption) (*Document, error) {
+	return ParseTree(strings.NewReader(s), opts...)
+}
+
+// Source returns the text the document was parsed from.
+func (d *Document) Source() string {
+	return d.source
+}
+
+// Text returns the source text covered by n.
+func (d *Document) Text(n SyntaxNode) string {
+	span := n.Span()
+	return d.source[span.Start.Offset:span.End.Offset]
+}
+
+// Value converts the tree into the value model produced by Unmarshal.
+func (d *Document) Value() Value {
+	if d.Root == nil {
+		return map[string]Value{}
+	}
+	return syntaxNodeValue(d.Root)
+}
+
+// Node converts the tree into a Node, keeping fields in source order.
+// Duplicate keys keep the position of their first occurrence and the last value.
+func (d *Document) Node() *Node {
+	if d.Root == nil {
+		return NewObject()
+	}
+	return syntaxNodeToNode(d.Root)
+}
+
+// syntaxNodeToNode converts a syntax node into a Node.
+func syntaxNodeToNode(n SyntaxNode) *Node {
+	switch node := n.(type) {
+	case *ObjectNode:
+		obj := NewObject()
+		for _, f := range node.Fields {
+			obj.set(f.Key, syntaxNodeToNode(f.Value))
+		}
+		return obj
+	case *FieldNode:
+		return syntaxNodeToNode(node.Value)
+	case *ArrayNode:
+		arr := NewArray()
+		for _, item := range node.Items {
+			if row, ok := item.(*RowNode); ok {
+				arr.appendItems(rowToNode(node.Header.Fields, row))
+			} else {
+				arr.appendItems(syntaxNodeToNode(item))
+			}
+		}
+		return arr
+	case *ListItemNode:
+		return syntaxNodeToNode(node.Value)
+	case *RowNode:
+		return rowToNode(nil, node)
+	case *ScalarNode:
+		scalar, err := NewNode(node.Value)
+		if err != nil {
+			return NewNull()
+		}
+		return scalar
+	default:
+		return NewNull()
+	}
+}
+
+// rowToNode converts a tabular row into an object with the header's field order.
+func rowToNode(fields []string, row *RowNode) *Node {
+	obj := NewObject()
+	for i, k := range fields {
+		if i < len(row.Cells) {
+			obj.set(k, syntaxNodeToNode(row.Cells[i]))
+		}
+	}
+	return obj
+}
+
+// syntaxNodeValue converts a syntax node into a decoded value.
+func syntaxNodeValue(n SyntaxNode) Value {
+	switch node := n.(type) {
+	case *ScalarNode:
+		return node.Value
+	case *ObjectNode:
+		result := make(map[string]Value, len(node.Fields))
+		for _, f := range node.Fields {
+			result[f.Key] = syntaxNodeValue(f.Value)
+		}
+		return result
+	case *FieldNode:
+		return syntaxNodeValue(node.Value)
+	case *ArrayNode:
+		result := make([]Value, len(node.Items))
+		for i, item := range node.Items {
+			result[i] = arrayItemValue(node.Header, item)
+		}
+		return result
+	case *ListItemNode:
+		return syntaxNodeValue(node.Value)
+	case *RowNode:
+		return rowValue(nil, node)
+	default:
+		return nil
+	}
+}
+
+// arrayItemValue converts an array item, attaching tabular field names to rows.
+func arrayItemValue(header *ArrayHeader, item SyntaxNode) Value {
+	if row, ok := item.(*RowNode); ok {
+		return rowValue(header.Fields, row)
+	}
+	return syntaxNodeValue(item)
+}
+
+// rowValue converts a tabular row into a map keyed by the header fields.
+func rowValue(fields []string, row *RowNode) Value {
+	result := make(map[string]Value, len(fields))
+	for i, k := range fields {
+		if i < len(row.Cells) {
+			result[k] = row.Cells[i].Value
+		}
+	}
+	return result
+}
@@ -0,0 +1,183 @@
+package toon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseTreeDecodeFixtures checks that the syntax tree agrees with the
+// decoder on every decode fixture that does not rely on path expansion.
+func TestParseTreeDecodeFixtures(t *testing.T) {
+	fixtureDir := "../testdata/fixtures/decode"
+
+	entries, err := os.ReadDir(fixtureDir)
+	if err != nil {
+		t.Fatalf("Failed to read fixture directory: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		fixture, err := loadFixture(filepath.Join(fixtureDir, entry.Name()))
+		if err != nil {
+			t.Fatalf("Failed to load fixture %s: %v", entry.Name(), err)
+		}
+
+		t.Run(entry.Name(), func(t *testing.T) {
+			for _, test := range fixture.Tests {
+				opts := fixtureOptionsToDecodeOptions(test.Options)
+				if opts != nil && opts.ExpandPaths == "safe" {
+					continue
+				}
+				input, ok := test.Input.(string)
+				if !ok {
+					continue
+				}
+
+				t.Run(test.Name, func(t *testing.T) {
+					doc, err := ParseTreeFromString(input, decodeOptionsToFunctional(opts)...)
+					if test.ShouldError {
+						if err == nil {
+							t.Errorf("ParseTree() expected error\nInput: %q\nGot: %#v", input, doc.Value())
+						}
+						return
+					}
+					if err != nil {
+						t.Fatalf("ParseTree() error = %v\nInput: %q", err, input)
+					}
+					got := normalizeValue(doc.Value())
+					want := normalizeValue(test.Expected)
+					if !deepEqual(got, want) {
+						t.Errorf("ParseTree() mismatch\nInput: %q\nExpected: %#v\nGot: %#v", input, want, got)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestParseTreeSpans(t *testing.T) {
+	input := "name: Ada\nusers[#2|]{id|\"full name\"}:\n  1|\"Ada L\"\n  2|Bob\ntags[2]: a,\"b c\""
+	doc, err := ParseTreeFromString(input)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+
+	root, ok := doc.Root.(*ObjectNode)
+	if !ok || len(root.Fields) != 3 {
+		t.Fatalf("expected object with 3 fields, got %#v", doc.Root)
+	}
+
+	name := root.Fields[0]
+	if got := doc.Text(name.Value); got != "Ada" {
+		t.Errorf("name value text = %q, want %q", got, "Ada")
+	}
+	if span := name.Value.Span(); span.Start.Line != 1 || span.Start.Column != 7 {
+		t.Errorf("name value starts at %d:%d, want 1:7", span.Start.Line, span.Start.Column)
+	}
+
+	users := root.Fields[1].Value.(*ArrayNode)
+	if users.Layout != ArrayTabular {
+		t.Errorf("users layout = %v, want tabular", users.Layout)
+	}
+	h := users.Header
+	if h.Length != 2 || h.LengthMarker != "#" || h.Delimiter != "|" {
+		t.Errorf("unexpected header: %+v", h)
+	}
+	if len(h.Fields) != 2 || h.Fields[1] != "full name" {
+		t.Errorf("header fields = %q", h.Fields)
+	}
+	if got := doc.Text(h); got != "[#2|]{id|\"full name\"}:" {
+		t.Errorf("header text = %q", got)
+	}
+
+	row := users.Items[0].(*RowNode)
+	cell := row.Cells[1]
+	if !cell.Quoted || cell.Raw != "\"Ada L\"" || cell.Value != "Ada L" {
+		t.Errorf("unexpected cell: %+v", cell)
+	}
+	if span := cell.Span(); span.Start.Line != 3 || span.Start.Column != 5 {
+		t.Errorf("cell starts at %d:%d, want 3:5", span.Start.Line, span.Start.Column)
+	}
+	if got := doc.Text(users); got != "[#2|]{id|\"full name\"}:\n  1|\"Ada L\"\n  2|Bob" {
+		t.Errorf("users text = %q", got)
+	}
+
+	tags := root.Fields[2].Value.(*ArrayNode)
+	if tags.Layout != ArrayInline || len(tags.Items) != 2 {
+		t.Fatalf("unexpected tags node: %+v", tags)
+	}
+	if got := doc.Text(tags.Items[1]); got != "\"b c\"" {
+		t.Errorf("tags[1] text = %q", got)
+	}
+}
+
+func TestParseTreeListItems(t *testing.T) {
+	input := "items[3]:\n  - id: 1\n    nested:\n      x: 1\n  - [2]: a,b\n  - text"
+	doc, err := ParseTreeFromString(input)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+
+	items := doc.Root.(*ObjectNode).Fields[0].Value.(*ArrayNode)
+	if items.Layout != ArrayList || len(items.Items) != 3 {
+		t.Fatalf("unexpected items node: %+v", items)
+	}
+
+	first := items.Items[0].(*ListItemNode)
+	obj, ok := first.Value.(*ObjectNode)
+	if !ok || len(obj.Fields) != 2 {
+		t.Fatalf("expected object item with 2 fields, got %#v", first.Value)
+	}
+	if got := doc.Text(first); got != "- id: 1\n    nested:\n      x: 1" {
+		t.Errorf("first item text = %q", got)
+	}
+
+	if _, ok := items.Items[1].(*ListItemNode).Value.(*ArrayNode); !ok {
+		t.Errorf("second item should be an array")
+	}
+	if s, ok := items.Items[2].(*ListItemNode).Value.(*ScalarNode); !ok || s.Value != "text" {
+		t.Errorf("third item should be scalar 'text'")
+	}
+}
+
+func TestParseTreeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []DecodeOption
+		wantLine int
+	}{
+		{name: "length mismatch", input: "a: 1\ntags[3]: x,y", wantLine: 2},
+		{name: "row width", input: "rows[1]{a,b}:\n  1", wantLine: 2},
+		{name: "unterminated string", input: "a: 1\nb: \"oops", wantLine: 2},
+		{name: "bad indentation", input: "a:\n   b: 1", wantLine: 2},
+		{name: "missing colon", input: "a 1\nb: 2", wantLine: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTreeFromString(tt.input, tt.opts...)
+			de, ok := err.(*DecodeError)
+			if !ok {
+				t.Fatalf("expected *DecodeError, got %T (%v)", err, err)
+			}
+			if de.Line != tt.wantLine {
+				t.Errorf("error line = %d, want %d (%v)", de.Line, tt.wantLine, de)
+			}
+		})
+	}
+}
+
+func TestParseTreeNonStrictKeepsDeclaredLength(t *testing.T) {
+	doc, err := ParseTreeFromString("tags[3]: x,y", WithStrictDecoding(false))
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+	arr := doc.Root.(*ObjectNode).Fields[0].Value.(*ArrayNode)
+	if arr.Header.Length != 3 || len(arr.Items) != 2 {
+		t.Errorf("declared %d, items %d; want 3 and 2", arr.Header.Length, len(arr.Items))
+	}
+}
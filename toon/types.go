@@ -1,15 +1,28 @@
 package toon
 
+import "github.com/sstraus/toon_go/toon/tokens"
+
 // Value represents any TOON-encodable value.
 // Valid types are: nil, bool, int, int64, float64, string, []Value, map[string]Value
 type Value interface{}
 
+// RawValue is an unparsed TOON subtree, stored as a standalone TOON document:
+// an object's fields at column 1, a root array header such as "[2]{id,name}:"
+// followed by its rows, or a single primitive.
+//
+// Unmarshal into *RawValue, *map[string]RawValue or a struct with RawValue
+// fields captures source text without parsing it; the text can be decoded
+// later with Unmarshal. Marshal writes a RawValue verbatim, re-indented to
+// the depth where it appears.
+type RawValue []byte
+
 // EncodeOptions configures encoding behavior.
 type EncodeOptions struct {
 	// Indent specifies the number of spaces for indentation (default: 2)
 	Indent int
 
 	// Delimiter specifies the delimiter for array values: "," | "\t" | "|" (default: ",")
+	// DelimiterAuto picks one per array to minimize quoting
 	Delimiter string
 
 	// LengthMarker specifies the prefix for array length markers (default: "")
@@ -20,13 +33,41 @@ type EncodeOptions struct {
 	// Example: {"a":{"b":1}} becomes "a.b: 1"
 	FlattenPaths bool
 
-	// FlattenDepth limits flattening recursion depth (default: 0 = unlimited)
+	// FlattenDepth limits the number of segments in a flattened key
+	// (default with WithFlattenPaths: unlimited; 0 disables folding)
 	// Only applies when FlattenPaths is true
 	FlattenDepth int
 
 	// Strict enables strict collision detection when flattening paths (default: false)
 	// When true, returns error on key collisions; when false, last value wins
 	Strict bool
+
+	// SortKeys writes object keys in sorted order, ignoring the insertion
+	// order of OrderedMap values, and formats json.Number values like other
+	// numbers (default: false)
+	SortKeys bool
+
+	// TokenBudget is the maximum number of tokens in the output; values are
+	// truncated with elision markers to fit (default: 0, no budget)
+	TokenBudget int
+
+	// Tokenizer counts tokens for TokenBudget (default: tokens.Heuristic)
+	Tokenizer tokens.Tokenizer
+
+	// Report, when set, receives the lossy conversions made while encoding
+	Report *EncodeReport
+
+	// FloatFormat controls how floats are written (default: shortest
+	// round-trip decimal)
+	FloatFormat FloatFormat
+
+	// FloatFormats overrides FloatFormat for the values at or below the
+	// paths used as keys
+	FloatFormats map[string]FloatFormat
+
+	// autoDelimiter is set when Delimiter was DelimiterAuto; arrays then
+	// choose their own delimiter
+	autoDelimiter bool
 }
 
 // DecodeOptions configures decoding behavior.
@@ -91,6 +132,7 @@ func WithIndent(n int) EncodeOption {
 }
 
 // WithDelimiter sets the delimiter for array values: "," | "\t" | "|" (default: ",").
+// DelimiterAuto picks the delimiter for each array to minimize quoting.
 func WithDelimiter(d string) EncodeOption {
 	return func(opts *EncodeOptions) {
 		opts.Delimiter = d
@@ -113,7 +155,8 @@ func WithFlattenPaths(enabled bool) EncodeOption {
 	}
 }
 
-// WithFlattenDepth limits flattening recursion depth (default: 0 = unlimited).
+// WithFlattenDepth limits the number of segments in a flattened key
+// (default: unlimited; 0 disables folding).
 // Only applies when FlattenPaths is enabled.
 func WithFlattenDepth(depth int) EncodeOption {
 	return func(opts *EncodeOptions) {
@@ -129,6 +172,60 @@ func WithStrict(strict bool) EncodeOption {
 	}
 }
 
+// WithSortKeys writes object keys in sorted order, including the keys of
+// OrderedMap values, which otherwise keep their insertion order. json.Number
+// values are formatted like other numbers instead of keeping their text.
+func WithSortKeys(enabled bool) EncodeOption {
+	return func(opts *EncodeOptions) {
+		opts.SortKeys = enabled
+	}
+}
+
+// WithTokenBudget limits the output to n tokens as counted by tokenizer
+// (default: tokens.Heuristic when nil). Long arrays keep their first and
+// last rows, then long strings are shortened, then deep subtrees are
+// dropped, until the output fits; each elision is marked in the output.
+// Encoding fails if even the most truncated output is over budget.
+func WithTokenBudget(n int, tokenizer tokens.Tokenizer) EncodeOption {
+	return func(opts *EncodeOptions) {
+		opts.TokenBudget = n
+		opts.Tokenizer = tokenizer
+	}
+}
+
+// WithReport stores the lossy conversions made by Marshal in r, such as
+// the truncations done for WithTokenBudget. r is reset at the start of each
+// call, so it is empty after an error.
+func WithReport(r *EncodeReport) EncodeOption {
+	return func(opts *EncodeOptions) {
+		opts.Report = r
+	}
+}
+
+// WithFloatFormat sets how floats are written, such as the number of
+// significant digits or decimals kept. Rounded floats are listed in the
+// report given with WithReport.
+// Example: FloatFormat{Digits: 2, Fixed: true} writes 19.999 as 20 and
+// 0.125 as 0.12.
+func WithFloatFormat(f FloatFormat) EncodeOption {
+	return func(opts *EncodeOptions) {
+		opts.FloatFormat = f
+	}
+}
+
+// WithFloatFormatAt sets how floats at or below path are written, using
+// Get path syntax. A path without array indexes, such as "readings.value",
+// also matches the values inside arrays, such as "readings[3].value".
+// The nearest enclosing path wins.
+func WithFloatFormatAt(path string, f FloatFormat) EncodeOption {
+	return func(opts *EncodeOptions) {
+		if opts.FloatFormats == nil {
+			opts.FloatFormats = make(map[string]FloatFormat)
+		}
+		opts.FloatFormats[path] = f
+	}
+}
+
 // Decoding options
 
 // WithKeyMode sets how to decode map keys (default: StringKeys).
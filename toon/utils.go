@@ -1,6 +1,7 @@
 package toon
 
 import (
+	"encoding/json"
 	"math"
 	"reflect"
 )
@@ -14,7 +15,7 @@ func isPrimitive(v Value) bool {
 	switch v.(type) {
 	case bool, int, int8, int16, int32, int64,
 		uint, uint8, uint16, uint32, uint64,
-		float32, float64, string:
+		float32, float64, string, json.Number:
 		return true
 	default:
 		return false
@@ -187,7 +188,7 @@ func sortKeysWithArraysFirst(keys []string, rv reflect.Value) {
 	for _, k := range keys {
 		mapKey := reflect.ValueOf(k)
 		val := rv.MapIndex(mapKey).Interface()
-		if isList(val) {
+		if _, raw := val.(RawValue); isList(val) && !raw {
 			arrayKeys = append(arrayKeys, k)
 		} else {
 			otherKeys = append(otherKeys, k)
@@ -231,6 +232,15 @@ func normalize(v Value) Value {
 	case map[string]interface{}:
 		return normalizeMap(val)
 
+	case RawValue, json.Number:
+		return val
+
+	case *Node:
+		return normalize(val.Value())
+
+	case Node:
+		return normalize(val.Value())
+
 	case OrderedMap:
 		return normalizeOrderedMap(&val)
 
@@ -320,7 +330,7 @@ func normalizeFloat(f float64) Value {
 	}
 
 	// Check if it's a whole number
-	if f == math.Trunc(f) && f >= math.MinInt64 && f <= math.MaxInt64 {
+	if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
 		return int64(f)
 	}
 
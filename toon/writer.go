@@ -7,6 +7,9 @@ type writer struct {
 	buf        *strings.Builder
 	indent     string
 	indentSize int
+
+	// costs records the output of each value when set (see Heatmap)
+	costs *costTrace
 }
 
 // newWriter creates a new writer with the given indentation size.
@@ -51,3 +54,18 @@ func (w *writer) Len() int {
 func (w *writer) Reset() {
 	w.buf.Reset()
 }
+
+// enter starts the output of the value v at seg below the current value.
+// It does nothing unless costs are traced.
+func (w *writer) enter(seg pathSegment, v Value) {
+	if w.costs != nil {
+		w.costs.enter(seg, v, w.buf.Len())
+	}
+}
+
+// leave ends the output of the value started by the last enter.
+func (w *writer) leave() {
+	if w.costs != nil {
+		w.costs.leave(w.buf.Len())
+	}
+}